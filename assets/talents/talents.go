@@ -0,0 +1,8 @@
+// Package talents embeds the talent tree configs shared by the UI and the sim, so both work
+// with the same talent layouts.
+package talents
+
+import "embed"
+
+//go:embed *.json
+var FS embed.FS
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var aplToJSON bool
+
+var aplCmd = &cobra.Command{
+	Use:   "apl [apl file]",
+	Short: "convert an APL between json and text",
+	Long:  "convert an APL rotation (APLRotation in protojson format) to the text syntax, or text back to json. Files ending in .json are converted to text, anything else to json",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aplMain(args[0])
+	},
+}
+
+func init() {
+	aplCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
+	aplCmd.Flags().BoolVar(&aplToJSON, "json", false, "convert to json regardless of the file extension")
+}
+
+func aplMain(aplFile string) error {
+	data, err := os.ReadFile(aplFile)
+	if err != nil {
+		return fmt.Errorf("failed to load apl file %q: %w", aplFile, err)
+	}
+
+	var output []byte
+	if strings.HasSuffix(aplFile, ".json") && !aplToJSON {
+		rotation := &proto.APLRotation{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, rotation); err != nil {
+			return fmt.Errorf("failed to load apl json file: %w", err)
+		}
+		output = []byte(core.FormatAPLText(rotation))
+	} else {
+		rotation, err := core.ParseAPLText(string(data))
+		if err != nil {
+			return fmt.Errorf("%s:%w", aplFile, err)
+		}
+		output, err = protojson.MarshalOptions{Multiline: true}.Marshal(rotation)
+		if err != nil {
+			return fmt.Errorf("failed to marshal apl: %w", err)
+		}
+	}
+
+	if outfile == "" {
+		fmt.Print(string(output))
+		return nil
+	}
+	return os.WriteFile(outfile, output, 0666)
+}
@@ -11,6 +11,8 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
+var simAPLTrace bool
+
 var simCmd = &cobra.Command{
 	Use:   "sim",
 	Short: "simulate items & settings",
@@ -21,6 +23,7 @@ func init() {
 	simCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format)")
 	simCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
 	simCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+	simCmd.Flags().BoolVar(&simAPLTrace, "apl-trace", false, "record the APL decisions of the first iteration in the output (aplTrace)")
 	simCmd.MarkFlagRequired("infile")
 }
 
@@ -35,6 +38,12 @@ func simMain(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to load input json file: %s", err)
 	}
+	if simAPLTrace {
+		if input.SimOptions == nil {
+			input.SimOptions = &proto.SimOptions{}
+		}
+		input.SimOptions.AplTrace = true
+	}
 
 	var output []byte
 	reporter := make(chan *proto.ProgressMetrics, 10)
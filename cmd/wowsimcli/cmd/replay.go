@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/encounters"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	replayBoss string
+	replayDump bool
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [combat log]",
+	Short: "replay a pull from a combat log against a raid",
+	Long:  "replay the boss abilities, add spawns and raid damage of a pull from a combat log against the raid from the input file, for the length of the pull",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return replayMain(args[0])
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format), whose encounter is replaced by the replay")
+	replayCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
+	replayCmd.Flags().StringVar(&replayBoss, "boss", "", "name of the boss, defaults to the first enemy to fight the raid")
+	replayCmd.Flags().BoolVar(&replayDump, "dump", false, "output the parsed replay (EncounterReplay in protojson format) instead of simulating it")
+}
+
+func replayMain(logFile string) error {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return fmt.Errorf("failed to open combat log: %w", err)
+	}
+	defer file.Close()
+
+	replay, err := encounters.ParseCombatLog(file, encounters.CombatLogOptions{Boss: replayBoss})
+	if err != nil {
+		return fmt.Errorf("failed to parse combat log: %w", err)
+	}
+
+	var output []byte
+	if replayDump {
+		output, err = protojson.MarshalOptions{Multiline: true}.Marshal(replay)
+		if err != nil {
+			return fmt.Errorf("failed to marshal replay: %w", err)
+		}
+	} else {
+		data, err := os.ReadFile(infile)
+		if err != nil {
+			return fmt.Errorf("failed to load input json file %q: %w", infile, err)
+		}
+		input := &proto.RaidSimRequest{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, input); err != nil {
+			return fmt.Errorf("failed to load input json file: %w", err)
+		}
+
+		// Keep the input's execute proportions, but fight the replayed targets for
+		// exactly as long as the pull lasted.
+		replayEncounter := encounters.NewReplayEncounter(replay)
+		if input.Encounter == nil {
+			input.Encounter = &proto.Encounter{}
+		}
+		input.Encounter.Duration = replayEncounter.Duration
+		input.Encounter.DurationVariation = 0
+		input.Encounter.UseHealth = false
+		input.Encounter.Targets = replayEncounter.Targets
+		input.Encounter.TargetGroups = nil
+
+		result := core.RunRaidSim(input)
+		if result.ErrorResult != "" {
+			return fmt.Errorf("sim failed: %s", result.ErrorResult)
+		}
+		output, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal final results: %w", err)
+		}
+	}
+
+	if outfile == "" {
+		fmt.Print(string(output))
+		return nil
+	}
+	return os.WriteFile(outfile, output, 0666)
+}
@@ -17,7 +17,10 @@ func Execute(version string) {
 	rootCmd.AddCommand(newVersionCommand(version))
 	rootCmd.AddCommand(simCmd)
 	rootCmd.AddCommand(bulkCmd)
+	rootCmd.AddCommand(statWeightsCmd)
 	rootCmd.AddCommand(decodeLinkCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(aplCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	statWeightsFormat string
+	statWeightsMetric string
+)
+
+var statWeightsCmd = &cobra.Command{
+	Use:   "statweights",
+	Short: "calculate stat weights and EP values",
+	Long:  "calculate stat weights and EP values",
+	Run:   statWeightsMain,
+}
+
+func init() {
+	statWeightsCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (StatWeightsRequest in protojson format)")
+	statWeightsCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
+	statWeightsCmd.Flags().StringVar(&statWeightsFormat, "format", "json", "output format, either 'json' (StatWeightsResult in protojson format) or 'csv'")
+	statWeightsCmd.Flags().StringVar(&statWeightsMetric, "metric", "dps", "metric used for the csv output: dps, hps, tps, dtps, tmi or pdeath")
+	statWeightsCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+	statWeightsCmd.MarkFlagRequired("infile")
+}
+
+func statWeightsMain(cmd *cobra.Command, args []string) {
+	if statWeightsFormat != "json" && statWeightsFormat != "csv" {
+		log.Fatalf("unknown output format %q, expected 'json' or 'csv'", statWeightsFormat)
+	}
+
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.StatWeightsRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.StatWeightsAsync(input, reporter)
+
+	var finalResult *proto.StatWeightsResult
+	for v := range reporter {
+		if v.FinalWeightResult != nil {
+			finalResult = v.FinalWeightResult
+			break
+		}
+		if verbose {
+			// Progress goes to stderr so the result can still be piped from stdout.
+			fmt.Fprintf(os.Stderr, "Stat Weights Progress: %d / %d iterations (completed %d / %d sims)\n", v.CompletedIterations, v.TotalIterations, v.CompletedSims, v.TotalSims)
+		}
+	}
+
+	if finalResult == nil {
+		log.Fatalf("stat weights ended without a final result")
+	}
+
+	var output []byte
+	if statWeightsFormat == "csv" {
+		values, err := statWeightValuesForMetric(finalResult, statWeightsMetric)
+		if err != nil {
+			log.Fatalf("failed to format results: %s", err)
+		}
+		output, err = statWeightsToCSV(values)
+		if err != nil {
+			log.Fatalf("failed to format results: %s", err)
+		}
+	} else {
+		output, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(finalResult)
+		if err != nil {
+			log.Fatalf("failed to marshal final results: %s", err)
+		}
+	}
+
+	if outfile == "" {
+		fmt.Print(string(output))
+	} else {
+		err = os.WriteFile(outfile, output, 0666)
+		if err != nil {
+			log.Fatalf("failed to write output file:: %s", err)
+		}
+		if verbose {
+			fmt.Printf("Wrote output file: `%s` successfully.\n", outfile)
+		}
+	}
+}
+
+func statWeightValuesForMetric(result *proto.StatWeightsResult, metric string) (*proto.StatWeightValues, error) {
+	switch strings.ToLower(metric) {
+	case "dps":
+		return result.Dps, nil
+	case "hps":
+		return result.Hps, nil
+	case "tps":
+		return result.Tps, nil
+	case "dtps":
+		return result.Dtps, nil
+	case "tmi":
+		return result.Tmi, nil
+	case "pdeath", "p_death":
+		return result.PDeath, nil
+	}
+	return nil, fmt.Errorf("unknown metric %q", metric)
+}
+
+// statWeightsToCSV writes one row per stat that has a non-zero weight or EP value.
+func statWeightsToCSV(values *proto.StatWeightValues) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"stat", "weight", "stdev", "ep", "ep_stdev"}); err != nil {
+		return nil, err
+	}
+
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 4, 64)
+	}
+	writeRow := func(name string, getValue func(*proto.UnitStats) float64) error {
+		weight := getValue(values.GetWeights())
+		ep := getValue(values.GetEpValues())
+		if weight == 0 && ep == 0 {
+			return nil
+		}
+		return w.Write([]string{
+			name,
+			formatFloat(weight),
+			formatFloat(getValue(values.GetWeightsStdev())),
+			formatFloat(ep),
+			formatFloat(getValue(values.GetEpValuesStdev())),
+		})
+	}
+
+	for i := 0; i < int(stats.Len); i++ {
+		err := writeRow(stats.Stat(i).StatName(), func(us *proto.UnitStats) float64 {
+			if i >= len(us.GetStats()) {
+				return 0
+			}
+			return us.GetStats()[i]
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < stats.PseudoStatsLen; i++ {
+		err := writeRow(proto.PseudoStat(i).String(), func(us *proto.UnitStats) float64 {
+			if i >= len(us.GetPseudoStats()) {
+				return 0
+			}
+			return us.GetPseudoStats()[i]
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -238,6 +238,7 @@ func init() {
 				Label:    "Deathfrost",
 				ActionID: actionID,
 				Duration: time.Second * 8,
+				Mechanic: core.MechanicSlow,
 			})
 			core.AtkSpeedReductionEffect(aura, 1.15)
 			debuffs[i] = aura
@@ -55,7 +55,6 @@ func init() {
 			})
 		}
 
-		numHits := min(5, character.Env.GetNumTargets())
 		debuffAuras := make([]*core.Aura, len(character.Env.Encounter.TargetUnits))
 		for i, target := range character.Env.Encounter.TargetUnits {
 			debuffAuras[i] = makeDebuffAura(target)
@@ -70,14 +69,12 @@ func init() {
 			FlatThreatBonus:  63,
 
 			ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-				curTarget := target
-				for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
+				for _, curTarget := range sim.Environment.CleaveTargetUnits(target, target.Position, 10, 5) {
 					result := spell.CalcDamage(sim, curTarget, 0, spell.OutcomeMagicHit)
 					if result.Landed() {
-						debuffAuras[target.Index].Activate(sim)
+						debuffAuras[curTarget.Index].Activate(sim)
 					}
 					spell.DealDamage(sim, result)
-					curTarget = sim.Environment.NextTargetUnit(curTarget)
 				}
 			},
 		})
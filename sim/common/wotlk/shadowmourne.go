@@ -35,8 +35,9 @@ func init() {
 			ThreatMultiplier: 1,
 
 			ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-				baseDamage := sim.Roll(1900, 2100) / float64(sim.GetNumTargets())
-				for _, target := range sim.Encounter.TargetUnits {
+				targets := player.TargetUnitsAround(10)
+				baseDamage := sim.Roll(1900, 2100) / float64(len(targets))
+				for _, target := range targets {
 					spell.CalcAndDealDamage(sim, target, baseDamage, spell.OutcomeMagicHit) // probably has a very low crit rate
 				}
 			},
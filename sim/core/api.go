@@ -16,6 +16,11 @@ func ComputeStats(csr *proto.ComputeStatsRequest) *proto.ComputeStatsResult {
 	if encounter == nil {
 		encounter = &proto.Encounter{}
 	}
+	if err := validateTargets(encounter); err != nil {
+		return &proto.ComputeStatsResult{
+			ErrorResult: err.Error(),
+		}
+	}
 
 	_, raidStats, encounterStats := NewEnvironment(csr.Raid, encounter, true)
 
@@ -29,13 +34,25 @@ func ComputeStats(csr *proto.ComputeStatsRequest) *proto.ComputeStatsResult {
  * Returns stat weights and EP values, with standard deviations, for all stats.
  */
 func StatWeights(request *proto.StatWeightsRequest) *proto.StatWeightsResult {
-	result := CalcStatWeight(request, stats.Stat(request.EpReferenceStat), nil)
+	return StatWeightsCtx(context.Background(), request)
+}
+
+/**
+ * Same as StatWeights, but stops all running sims when ctx is cancelled.
+ * Stats whose sims finished are still returned, with the result marked as cancelled.
+ */
+func StatWeightsCtx(ctx context.Context, request *proto.StatWeightsRequest) *proto.StatWeightsResult {
+	result := CalcStatWeight(ctx, request, stats.Stat(request.EpReferenceStat), nil)
 	return result.ToProto()
 }
 
 func StatWeightsAsync(request *proto.StatWeightsRequest, progress chan *proto.ProgressMetrics) {
+	StatWeightsAsyncCtx(context.Background(), request, progress)
+}
+
+func StatWeightsAsyncCtx(ctx context.Context, request *proto.StatWeightsRequest, progress chan *proto.ProgressMetrics) {
 	go func() {
-		result := CalcStatWeight(request, stats.Stat(request.EpReferenceStat), progress)
+		result := CalcStatWeight(ctx, request, stats.Stat(request.EpReferenceStat), progress)
 		progress <- &proto.ProgressMetrics{
 			FinalWeightResult: result.ToProto(),
 		}
@@ -46,11 +63,23 @@ func StatWeightsAsync(request *proto.StatWeightsRequest, progress chan *proto.Pr
  * Runs multiple iterations of the sim with a full raid.
  */
 func RunRaidSim(request *proto.RaidSimRequest) *proto.RaidSimResult {
-	return RunSim(request, nil, nil)
+	return RunRaidSimCtx(context.Background(), request)
+}
+
+/**
+ * Same as RunRaidSim, but stops after the current iteration when ctx is cancelled.
+ * The metrics of the completed iterations are returned, with the result marked as cancelled.
+ */
+func RunRaidSimCtx(ctx context.Context, request *proto.RaidSimRequest) *proto.RaidSimResult {
+	return RunSim(ctx, request, nil)
 }
 
 func RunRaidSimAsync(request *proto.RaidSimRequest, progress chan *proto.ProgressMetrics) {
-	RunConcurrentRaidSimAsync(request, progress)
+	RunRaidSimAsyncCtx(context.Background(), request, progress)
+}
+
+func RunRaidSimAsyncCtx(ctx context.Context, request *proto.RaidSimRequest, progress chan *proto.ProgressMetrics) {
+	RunConcurrentRaidSimAsync(ctx, request, progress)
 }
 
 func RunBulkSim(request *proto.BulkSimRequest) *proto.BulkSimResult {
@@ -60,3 +89,15 @@ func RunBulkSim(request *proto.BulkSimRequest) *proto.BulkSimResult {
 func RunBulkSimAsync(ctx context.Context, request *proto.BulkSimRequest, progress chan *proto.ProgressMetrics) {
 	go BulkSim(ctx, request, progress)
 }
+
+/**
+ * Sweeps one or two stats over a range and returns the DPS at each point, along with
+ * breakpoints such as hit caps and DoT tick thresholds.
+ */
+func StatScaling(request *proto.StatScalingRequest) *proto.StatScalingResult {
+	return StatScalingSim(context.Background(), request, nil)
+}
+
+func StatScalingAsync(ctx context.Context, request *proto.StatScalingRequest, progress chan *proto.ProgressMetrics) {
+	go StatScalingSim(ctx, request, progress)
+}
@@ -1,7 +1,9 @@
 package core_test
 
 import (
+	"context"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/wowsims/cata/sim/core"
@@ -11,6 +13,10 @@ import (
 	"github.com/wowsims/cata/sim/hunter/marksmanship"
 )
 
+var registerMarksmanshipHunter sync.Once
+var registerFeralDruid sync.Once
+var registerBloodDeathKnight sync.Once
+
 func getTestPlayerMM() *proto.Player {
 	var FullConsumes = &proto.Consumes{
 		Flask:         proto.Flask_FlaskOfTheWinds,
@@ -48,7 +54,8 @@ func getTestPlayerMM() *proto.Player {
 		},
 	}
 
-	marksmanship.RegisterMarksmanshipHunter()
+	// Registered once, as more than one test uses this player.
+	registerMarksmanshipHunter.Do(marksmanship.RegisterMarksmanshipHunter)
 
 	return &proto.Player{
 		Race:           proto.Race_RaceOrc,
@@ -90,7 +97,7 @@ func getTestPlayerBloodDk() *proto.Player {
 		Food:          proto.Food_FoodBeerBasedCrocolisk,
 	}
 
-	blood.RegisterBloodDeathKnight()
+	registerBloodDeathKnight.Do(blood.RegisterBloodDeathKnight)
 
 	return &proto.Player{
 		Race:           proto.Race_RaceWorgen,
@@ -132,7 +139,7 @@ func getTestPlayerFeralCat() *proto.Player {
 		PrepopPotion:  proto.Potions_PotionOfTheTolvir,
 	}
 
-	feral.RegisterFeralDruid()
+	registerFeralDruid.Do(feral.RegisterFeralDruid)
 
 	return &proto.Player{
 		Race:           proto.Race_RaceTauren,
@@ -185,3 +192,39 @@ func TestConcurrentRaidSim(t *testing.T) {
 		core.CompareConcurrentSimResultsTest(t, strconv.Itoa(i), stRes, mtRes, 0.00001)
 	}
 }
+
+func TestCancelledRaidSim(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerMM())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A cancelled sim still finishes its first iteration, so there are partial metrics to return.
+	stRes := core.RunRaidSimCtx(ctx, rsr)
+	if !stRes.Cancelled || stRes.ErrorResult != "" || stRes.RaidMetrics.Dps.Avg == 0 {
+		t.Fatalf("RunRaidSimCtx() = cancelled %t, error %q, want cancelled partial result", stRes.Cancelled, stRes.ErrorResult)
+	}
+
+	progress := make(chan *proto.ProgressMetrics, 10)
+	core.RunConcurrentRaidSimAsync(ctx, rsr, progress)
+	var mtRes *proto.RaidSimResult
+	for msg := range progress {
+		if msg.FinalRaidResult != nil {
+			mtRes = msg.FinalRaidResult
+		}
+	}
+	if mtRes == nil || !mtRes.Cancelled || mtRes.ErrorResult != "" {
+		t.Fatalf("RunConcurrentRaidSimAsync() = %v, want cancelled partial result", mtRes)
+	}
+	if mtRes.AvgIterationDuration != stRes.AvgIterationDuration {
+		t.Fatalf("RunConcurrentRaidSimAsync() avg duration = %f, want %f", mtRes.AvgIterationDuration, stRes.AvgIterationDuration)
+	}
+
+	swRes := core.StatWeightsCtx(ctx, &proto.StatWeightsRequest{
+		Player:     rsr.Raid.Parties[0].Players[0],
+		Encounter:  rsr.Encounter,
+		SimOptions: rsr.SimOptions,
+	})
+	if !swRes.Cancelled {
+		t.Fatalf("StatWeightsCtx() returned result not marked as cancelled")
+	}
+}
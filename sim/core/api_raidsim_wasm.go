@@ -3,15 +3,17 @@
 package core
 
 import (
+	"context"
+
 	"github.com/wowsims/cata/sim/core/proto"
 )
 
 // Note: WASM can't do threads with go, so there's no reason to even compile the whole concurrency code. Instead just run sims directly.
 
-func RunConcurrentRaidSimAsync(request *proto.RaidSimRequest, progress chan *proto.ProgressMetrics) {
-	go RunSim(request, progress, nil)
+func RunConcurrentRaidSimAsync(ctx context.Context, request *proto.RaidSimRequest, progress chan *proto.ProgressMetrics) {
+	go RunSim(ctx, request, progress)
 }
 
 func RunConcurrentRaidSimSync(request *proto.RaidSimRequest) *proto.RaidSimResult {
-	return RunSim(request, nil, nil)
+	return RunSim(context.Background(), request, nil)
 }
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/wowsims/cata/sim/core/proto"
@@ -13,6 +14,18 @@ type APLRotation struct {
 	prepullActions []*APLAction
 	priorityList   []*APLAction
 
+	// The priority list in the same form as named action lists, along with the
+	// config index of each action.
+	priorityActionList *APLActionList
+
+	// Named action lists, in config order and by name.
+	actionLists      []*APLActionList
+	actionListByName map[string]*APLActionList
+
+	// Variables by name, and the first Set Variable action for each of them.
+	variables       map[string]*APLVariable
+	variableConfigs map[string]*aplVariableConfig
+
 	// Action currently controlling this rotation (only used for certain actions, such as StrictSequence).
 	controllingActions []APLActionImpl
 
@@ -26,17 +39,32 @@ type APLRotation struct {
 	// Used inside of actions/value to determine whether they will occur during the prepull or regular rotation.
 	parsingPrepull bool
 
-	// Used to avoid recursive APL loops.
+	// Used inside of actions to determine which named action list they belong to.
+	parsingList string
+
+	// Used to avoid recursive APL loops. Recursive action lists are rejected
+	// during parsing instead, see resolveActionList.
 	inLoop bool
 
 	// Used to override MCD restrictions within sequences.
 	inSequence bool
 
+	// Set while choosing the action DoNextAction performs, see decideNextAction.
+	deciding bool
+
+	// Set while looking for the next action without setting variables or
+	// recording the decision, see peekNextAction.
+	peeking bool
+
+	// Decision being recorded for the APL trace, or nil when not tracing.
+	curDecision *proto.APLTraceDecision
+
 	// Validation warnings that occur during proto parsing.
 	// We return these back to the user for display in the UI.
 	curWarnings          []string
 	prepullWarnings      [][]string
 	priorityListWarnings [][]string
+	actionListWarnings   [][][]string
 }
 
 func (rot *APLRotation) ValidationWarning(message string, vals ...interface{}) {
@@ -56,6 +84,18 @@ func (rot *APLRotation) doAndRecordWarnings(warningsList *[]string, isPrepull bo
 	rot.parsingPrepull = false
 }
 
+// Invokes the fn function and returns the warnings generated during its invocation
+// instead of recording them, for parsing config whose warnings belong elsewhere.
+func (rot *APLRotation) doWithoutWarnings(isPrepull bool, fn func()) []string {
+	curWarnings, parsingPrepull := rot.curWarnings, rot.parsingPrepull
+	rot.curWarnings = nil
+	rot.parsingPrepull = isPrepull
+	fn()
+	warnings := rot.curWarnings
+	rot.curWarnings, rot.parsingPrepull = curWarnings, parsingPrepull
+	return warnings
+}
+
 func (unit *Unit) newCustomRotation() *APLRotation {
 	return unit.newAPLRotation(&proto.APLRotation{
 		Type: proto.APLRotation_TypeAPL,
@@ -78,7 +118,11 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 		unit:                 unit,
 		prepullWarnings:      make([][]string, len(config.PrepullActions)),
 		priorityListWarnings: make([][]string, len(config.PriorityList)),
+		actionListWarnings:   make([][][]string, len(config.ActionLists)),
+		actionListByName:     make(map[string]*APLActionList),
+		variables:            make(map[string]*APLVariable),
 	}
+	rotation.findVariableConfigs(config)
 
 	// Parse prepull actions
 	for i, prepullItem := range config.PrepullActions {
@@ -122,6 +166,58 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 		})
 	}
 
+	rotation.priorityActionList = &APLActionList{
+		rot:              rotation,
+		actions:          rotation.priorityList,
+		configIdx:        -1,
+		actionConfigIdxs: configIdxs,
+		metrics:          make([]aplItemMetrics, len(rotation.priorityList)),
+	}
+
+	// Parse action lists
+	for i, listConfig := range config.ActionLists {
+		rotation.actionListWarnings[i] = make([][]string, len(listConfig.Items))
+		list := &APLActionList{
+			rot:       rotation,
+			name:      listConfig.Name,
+			configIdx: i,
+		}
+
+		rotation.parsingList = listConfig.Name
+		for j, aplItem := range listConfig.Items {
+			rotation.doAndRecordWarnings(&rotation.actionListWarnings[i][j], false, func() {
+				if !aplItem.Hide {
+					action := rotation.newAPLAction(aplItem.Action)
+					if action != nil {
+						list.actions = append(list.actions, action)
+						list.actionConfigIdxs = append(list.actionConfigIdxs, j)
+					}
+				}
+			})
+		}
+		rotation.parsingList = ""
+		list.metrics = make([]aplItemMetrics, len(list.actions))
+
+		if listConfig.Name == "" || rotation.actionListByName[listConfig.Name] != nil {
+			if len(listConfig.Items) > 0 {
+				rotation.actionListWarnings[i][0] = append(rotation.actionListWarnings[i][0], fmt.Sprintf("Action lists must have a unique name, ignoring list '%s'", listConfig.Name))
+			}
+			continue
+		}
+
+		for _, action := range list.actions {
+			for _, innerAction := range action.GetAllActions() {
+				if callList, ok := innerAction.impl.(*APLActionCallList); ok {
+					list.calledLists = append(list.calledLists, callList.name)
+				} else if runList, ok := innerAction.impl.(*APLActionRunList); ok {
+					list.calledLists = append(list.calledLists, runList.name)
+				}
+			}
+		}
+		rotation.actionLists = append(rotation.actionLists, list)
+		rotation.actionListByName[list.name] = list
+	}
+
 	// Finalize
 	for i, action := range rotation.prepullActions {
 		rotation.doAndRecordWarnings(&rotation.prepullWarnings[i], true, func() {
@@ -133,6 +229,13 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 			action.Finalize(rotation)
 		})
 	}
+	for _, list := range rotation.actionLists {
+		for i, action := range list.actions {
+			rotation.doAndRecordWarnings(&rotation.actionListWarnings[list.configIdx][list.actionConfigIdxs[i]], false, func() {
+				action.Finalize(rotation)
+			})
+		}
+	}
 
 	// Remove MCDs that are referenced by APL actions, so that the Autocast Other Cooldowns
 	// action does not include them.
@@ -174,6 +277,11 @@ func (rot *APLRotation) getStats() *proto.APLStats {
 	return &proto.APLStats{
 		PrepullActions: MapSlice(rot.prepullWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
 		PriorityList:   MapSlice(rot.priorityListWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
+		ActionLists: MapSlice(rot.actionListWarnings, func(listWarnings [][]string) *proto.APLActionListStats {
+			return &proto.APLActionListStats{
+				Items: MapSlice(listWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
+			}
+		}),
 	}
 }
 
@@ -182,7 +290,12 @@ func (rot *APLRotation) allAPLActions() []*APLAction {
 		return []*APLAction{}
 	}
 
-	return Flatten(MapSlice(rot.priorityList, func(action *APLAction) []*APLAction {
+	actions := rot.priorityList
+	for _, list := range rot.actionLists {
+		actions = append(slices.Clip(actions), list.actions...)
+	}
+
+	return Flatten(MapSlice(actions, func(action *APLAction) []*APLAction {
 		// Check if action is nil before calling GetAllActions
 		if action == nil {
 			return []*APLAction{}
@@ -201,6 +314,12 @@ func (rot *APLRotation) reset(sim *Simulation) {
 	rot.inLoop = false
 	rot.interruptChannelIf = nil
 	rot.allowChannelRecastOnInterrupt = false
+	rot.deciding = false
+	rot.peeking = false
+	rot.curDecision = nil
+	for _, variable := range rot.variables {
+		variable.reset()
+	}
 	for _, action := range rot.allAPLActions() {
 		action.impl.Reset(sim)
 	}
@@ -230,7 +349,7 @@ func (apl *APLRotation) DoNextAction(sim *Simulation) {
 	apl.inLoop = true
 
 	apl.unit.UpdatePosition(sim)
-	for nextAction := apl.getNextAction(sim); nextAction != nil; i, nextAction = i+1, apl.getNextAction(sim) {
+	for nextAction := apl.decideNextAction(sim); nextAction != nil; i, nextAction = i+1, apl.decideNextAction(sim) {
 		if i > 1000 {
 			panic(fmt.Sprintf("[USER_ERROR] Infinite loop detected, current action:\n%s", nextAction))
 		}
@@ -260,13 +379,31 @@ func (apl *APLRotation) getNextAction(sim *Simulation) *APLAction {
 		return apl.controllingActions[len(apl.controllingActions)-1].GetNextAction(sim)
 	}
 
-	for _, action := range apl.priorityList {
-		if action.IsReady(sim) {
-			return action
-		}
-	}
+	return apl.getNextActionFromList(sim, apl.priorityActionList)
+}
 
-	return nil
+// Like getNextAction, but for checks outside of DoNextAction, so Set Variable
+// actions are skipped instead of performed.
+func (apl *APLRotation) peekNextAction(sim *Simulation) *APLAction {
+	wasPeeking := apl.peeking
+	apl.peeking = true
+	nextAction := apl.getNextAction(sim)
+	apl.peeking = wasPeeking
+	return nextAction
+}
+
+// Like getNextAction, but for the action DoNextAction performs, so the decision
+// is recorded in the APL item metrics and trace.
+func (apl *APLRotation) decideNextAction(sim *Simulation) *APLAction {
+	apl.deciding = true
+	var nextAction *APLAction
+	if sim.tracingAPL {
+		nextAction = apl.getNextTracedAction(sim)
+	} else {
+		nextAction = apl.getNextAction(sim)
+	}
+	apl.deciding = false
+	return nextAction
 }
 
 func (apl *APLRotation) pushControllingAction(ca APLActionImpl) {
@@ -294,7 +431,7 @@ func (apl *APLRotation) shouldInterruptChannel(sim *Simulation) bool {
 	}
 
 	// Allow next action to interrupt the channel, but if the action is the same action then it still needs to continue.
-	nextAction := apl.getNextAction(sim)
+	nextAction := apl.peekNextAction(sim)
 	if nextAction == nil {
 		return false
 	}
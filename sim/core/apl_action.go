@@ -168,6 +168,16 @@ func (rot *APLRotation) newAPLActionImpl(config *proto.APLAction) APLActionImpl
 	case *proto.APLAction_StrictSequence:
 		return rot.newActionStrictSequence(config.GetStrictSequence())
 
+	// Action lists
+	case *proto.APLAction_CallList:
+		return rot.newActionCallList(config.GetCallList())
+	case *proto.APLAction_RunList:
+		return rot.newActionRunList(config.GetRunList())
+
+	// Variables
+	case *proto.APLAction_SetVariable:
+		return rot.newActionSetVariable(config.GetSetVariable())
+
 	// Misc
 	case *proto.APLAction_ChangeTarget:
 		return rot.newActionChangeTarget(config.GetChangeTarget())
@@ -27,7 +27,12 @@ func (rot *APLRotation) newActionCastSpell(config *proto.APLActionCastSpell) APL
 	}
 }
 func (action *APLActionCastSpell) IsReady(sim *Simulation) bool {
-	return action.spell.CanCastOrQueue(sim, action.target.Get()) && (!action.spell.Flags.Matches(SpellFlagMCD) || action.spell.Unit.GCD.IsReady(sim) || action.spell.Unit.Rotation.inSequence)
+	target := action.target.Get()
+	// Don't waste casts on targets which are immune to the spell, e.g. bleeds on some adds.
+	if target != nil && target.IsImmuneToSpell(action.spell) {
+		return false
+	}
+	return action.spell.CanCastOrQueue(sim, target) && (!action.spell.Flags.Matches(SpellFlagMCD) || action.spell.Unit.GCD.IsReady(sim) || action.spell.Unit.Rotation.inSequence)
 }
 func (action *APLActionCastSpell) Execute(sim *Simulation) {
 	action.spell.CastOrQueue(sim, action.target.Get())
@@ -175,10 +180,10 @@ func (action *APLActionMultidot) IsReady(sim *Simulation) bool {
 			}
 		}
 	} else {
-		for i := int32(0); i < action.maxDots; i++ {
-			target := sim.Encounter.TargetUnits[i]
+		for i := int32(0); i < min(action.maxDots, sim.GetNumTargets()); i++ {
+			target := sim.Encounter.ActiveTargetUnits[i]
 			dot := action.spell.Dot(target)
-			if (!dot.IsActive() || dot.RemainingDuration(sim) < maxOverlap) && action.spell.CanCastOrQueue(sim, target) {
+			if (!dot.IsActive() || dot.RemainingDuration(sim) < maxOverlap) && !target.IsImmuneToSpell(action.spell) && action.spell.CanCastOrQueue(sim, target) {
 				action.nextTarget = target
 				return true
 			}
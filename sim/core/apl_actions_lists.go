@@ -0,0 +1,225 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// A named list of actions, invoked by the Call List and Run List actions. The
+// priority list is also kept in this form, without a name.
+type APLActionList struct {
+	rot     *APLRotation
+	name    string
+	actions []*APLAction
+
+	// Names of the lists which are called by this list's actions.
+	calledLists []string
+
+	// Indices of the list and of its actions in the rotation config. The
+	// priority list has a list index of -1.
+	configIdx        int
+	actionConfigIdxs []int
+
+	// Usage of each action, see APLItemMetrics.
+	metrics []aplItemMetrics
+}
+
+// Returns the first ready action in the list, following any calls to other lists.
+func (list *APLActionList) getNextAction(sim *Simulation) *APLAction {
+	return list.rot.getNextActionFromList(sim, list)
+}
+
+// Returns the first ready action, following calls to action lists so that the
+// returned action is always the one which will be performed. Variables are set
+// along the way and the decision is recorded, unless the rotation is only peeking.
+func (rot *APLRotation) getNextActionFromList(sim *Simulation, list *APLActionList) *APLAction {
+	for i, action := range list.actions {
+		var itemMetrics *aplItemMetrics
+		var traceItem *proto.APLTraceItem
+		if !rot.peeking {
+			if rot.deciding {
+				itemMetrics = &list.metrics[i]
+				itemMetrics.evaluated++
+			}
+			traceItem = rot.traceListItem(list, i)
+		}
+
+		condition := action.condition == nil || action.condition.GetBool(sim)
+		if traceItem != nil {
+			traceItem.Condition = condition
+		}
+		if !condition {
+			continue
+		}
+		if itemMetrics != nil {
+			itemMetrics.conditionTrue++
+		}
+
+		var nextAction *APLAction
+		performed, endOfList := false, false
+		switch impl := action.impl.(type) {
+		case *APLActionCallList:
+			if impl.list != nil {
+				nextAction = impl.list.getNextAction(sim)
+			}
+		case *APLActionRunList:
+			if impl.list != nil {
+				nextAction = impl.list.getNextAction(sim)
+				endOfList = true
+			}
+		case *APLActionSetVariable:
+			// Like simc's variable action, variables are set in place and the
+			// list continues, so they are up to date for the actions after them.
+			if !rot.peeking {
+				impl.Execute(sim)
+				performed = true
+			}
+		default:
+			if impl.IsReady(sim) {
+				nextAction = action
+			}
+		}
+
+		if itemMetrics != nil && (performed || nextAction != nil) {
+			itemMetrics.fired++
+			itemMetrics.resourceSum += rot.unit.aplMetricsResource()
+		}
+		if traceItem != nil {
+			traceItem.Ready = performed || nextAction != nil
+			if !traceItem.Ready {
+				traceItem.Reason = aplActionNotReadyReason(sim, action)
+			}
+		}
+		if nextAction != nil || endOfList {
+			return nextAction
+		}
+	}
+	return nil
+}
+
+// Like getNextActionFromList, but for readiness checks, so Set Variable actions are
+// skipped instead of performed and the decision isn't recorded.
+func (rot *APLRotation) peekNextActionFromList(sim *Simulation, list *APLActionList) *APLAction {
+	wasPeeking := rot.peeking
+	rot.peeking = true
+	nextAction := rot.getNextActionFromList(sim, list)
+	rot.peeking = wasPeeking
+	return nextAction
+}
+
+// Returns the shortest chain of calls from one list to another, or nil if there is none.
+func (rot *APLRotation) findListCalls(from string, to string) []string {
+	visited := map[string]bool{from: true}
+	paths := [][]string{{from}}
+	for len(paths) > 0 {
+		path := paths[0]
+		paths = paths[1:]
+		list := rot.actionListByName[path[len(path)-1]]
+		if list == nil {
+			continue
+		}
+		for _, called := range list.calledLists {
+			if called == to {
+				return append(slices.Clone(path), called)
+			}
+			if !visited[called] {
+				visited[called] = true
+				paths = append(paths, append(slices.Clone(path), called))
+			}
+		}
+	}
+	return nil
+}
+
+// Looks up the list called by an action, or returns nil if it doesn't exist or
+// the call would be recursive.
+func (rot *APLRotation) resolveActionList(name string, callerName string) *APLActionList {
+	list := rot.actionListByName[name]
+	if list == nil {
+		rot.ValidationWarning("No action list with name: '%s'", name)
+		return nil
+	}
+
+	// Recursive lists would never finish looking for their next action, so
+	// disable any call which can loop back into its own list.
+	if callerName != "" {
+		if calls := rot.findListCalls(name, callerName); calls != nil {
+			rot.ValidationWarning("Action list '%s' calls itself through %s, ignoring this action", callerName, strings.Join(append([]string{callerName}, calls...), " -> "))
+			return nil
+		}
+	}
+	return list
+}
+
+type APLActionCallList struct {
+	defaultAPLActionImpl
+	name       string
+	callerName string
+	list       *APLActionList
+}
+
+func (rot *APLRotation) newActionCallList(config *proto.APLActionCallList) APLActionImpl {
+	if config.Name == "" {
+		rot.ValidationWarning("Call List must provide a list name")
+		return nil
+	}
+	return &APLActionCallList{
+		name:       config.Name,
+		callerName: rot.parsingList,
+	}
+}
+func (action *APLActionCallList) Finalize(rot *APLRotation) {
+	action.list = rot.resolveActionList(action.name, action.callerName)
+}
+func (action *APLActionCallList) IsReady(sim *Simulation) bool {
+	return action.list != nil && action.list.rot.peekNextActionFromList(sim, action.list) != nil
+}
+
+// Only reached when the list is performed from a sequence. Variables are only
+// set when the rotation chooses its next action, so the list is peeked here.
+func (action *APLActionCallList) Execute(sim *Simulation) {
+	if nextAction := action.list.rot.peekNextActionFromList(sim, action.list); nextAction != nil {
+		nextAction.Execute(sim)
+	}
+}
+func (action *APLActionCallList) String() string {
+	return fmt.Sprintf("Call List(name = '%s')", action.name)
+}
+
+type APLActionRunList struct {
+	defaultAPLActionImpl
+	name       string
+	callerName string
+	list       *APLActionList
+}
+
+func (rot *APLRotation) newActionRunList(config *proto.APLActionRunList) APLActionImpl {
+	if config.Name == "" {
+		rot.ValidationWarning("Run List must provide a list name")
+		return nil
+	}
+	return &APLActionRunList{
+		name:       config.Name,
+		callerName: rot.parsingList,
+	}
+}
+func (action *APLActionRunList) Finalize(rot *APLRotation) {
+	action.list = rot.resolveActionList(action.name, action.callerName)
+}
+
+// Outside of a priority list (e.g. inside a sequence) there is nothing to stop
+// evaluating, so this behaves like Call List.
+func (action *APLActionRunList) IsReady(sim *Simulation) bool {
+	return action.list != nil && action.list.rot.peekNextActionFromList(sim, action.list) != nil
+}
+func (action *APLActionRunList) Execute(sim *Simulation) {
+	if nextAction := action.list.rot.peekNextActionFromList(sim, action.list); nextAction != nil {
+		nextAction.Execute(sim)
+	}
+}
+func (action *APLActionRunList) String() string {
+	return fmt.Sprintf("Run List(name = '%s')", action.name)
+}
@@ -0,0 +1,137 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+var (
+	testSteadyShot = core.ActionID{SpellID: 56641}
+	testArcaneShot = core.ActionID{SpellID: 3044}
+)
+
+func makeAPLTestCase(t *testing.T, aplText string) *proto.RaidSimRequest {
+	rotation, err := core.ParseAPLText(aplText)
+	if err != nil {
+		t.Fatalf("failed to parse APL: %s", err)
+	}
+
+	player := getTestPlayerMM()
+	player.Rotation = rotation
+	player.DistanceFromTarget = 20
+	rsr := makeTestCase(player)
+	rsr.SimOptions.Iterations = 5
+	return rsr
+}
+
+func runAPLTest(t *testing.T, aplText string) *proto.RaidSimResult {
+	result := core.RunRaidSim(makeAPLTestCase(t, aplText))
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	return result
+}
+
+func TestAPLCallList(t *testing.T) {
+	// Arcane Shot is only used with enough focus, so the priority list continues
+	// with Steady Shot the rest of the time.
+	result := runAPLTest(t, `
+call_list(shots)
+cast_spell(56641)
+
+list shots
+cast_spell(3044) if current_focus >= 60
+`)
+	if casts := playerActionMetrics(result, testArcaneShot).Casts; casts == 0 {
+		t.Fatalf("expected Arcane Shot casts from the called list")
+	}
+	if casts := playerActionMetrics(result, testSteadyShot).Casts; casts == 0 {
+		t.Fatalf("expected Steady Shot casts after the called list")
+	}
+}
+
+func TestAPLRunList(t *testing.T) {
+	result := runAPLTest(t, `
+run_list(shots)
+cast_spell(56641)
+
+list shots
+cast_spell(3044)
+`)
+	if casts := playerActionMetrics(result, testArcaneShot).Casts; casts == 0 {
+		t.Fatalf("expected Arcane Shot casts from the run list")
+	}
+	if casts := playerActionMetrics(result, testSteadyShot).Casts; casts != 0 {
+		t.Fatalf("expected no actions after the run list, got %d Steady Shots", casts)
+	}
+
+	// Run lists are skipped when their condition is false.
+	result = runAPLTest(t, `
+run_list(shots) if false
+cast_spell(56641)
+
+list shots
+cast_spell(3044)
+`)
+	if casts := playerActionMetrics(result, testSteadyShot).Casts; casts == 0 {
+		t.Fatalf("expected Steady Shot casts when the run list is skipped")
+	}
+}
+
+func TestAPLRecursiveLists(t *testing.T) {
+	rsr := makeAPLTestCase(t, `
+call_list(a)
+call_list(missing)
+cast_spell(56641)
+
+list a
+call_list(b)
+
+list b
+call_list(a)
+cast_spell(3044)
+`)
+
+	stats := core.ComputeStats(&proto.ComputeStatsRequest{Raid: rsr.Raid, Encounter: rsr.Encounter})
+	rotationStats := stats.RaidStats.Parties[0].Players[0].RotationStats
+	if warnings := rotationStats.PriorityList[1].Warnings; len(warnings) != 1 || !strings.Contains(warnings[0], "No action list with name: 'missing'") {
+		t.Fatalf("expected a warning for the missing list, got %v", warnings)
+	}
+	for i, name := range []string{"a", "b"} {
+		if warnings := rotationStats.ActionLists[i].Items[0].Warnings; len(warnings) != 1 || !strings.Contains(warnings[0], "Action list '"+name+"' calls itself") {
+			t.Fatalf("expected a recursion warning in list %s, got %v", name, warnings)
+		}
+	}
+
+	// The recursive calls are ignored, so the rotation falls through to Steady Shot.
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	if casts := playerActionMetrics(result, testSteadyShot).Casts; casts == 0 {
+		t.Fatalf("expected Steady Shot casts after the recursive lists")
+	}
+	if casts := playerActionMetrics(result, testArcaneShot).Casts; casts != 0 {
+		t.Fatalf("expected the recursive lists to be ignored, got %d Arcane Shots", casts)
+	}
+}
+
+func TestAPLListReadinessHasNoSideEffects(t *testing.T) {
+	// The strict sequence checks whether the called list is ready without
+	// performing it, so the list's Set Variable action never runs.
+	result := runAPLTest(t, `
+strict_sequence(call_list(setter))
+cast_spell(3044) if !variable(done)
+cast_spell(56641)
+
+list setter
+set_variable(done, true)
+cast_spell(56641) if false
+`)
+	if casts := playerActionMetrics(result, testArcaneShot).Casts; casts == 0 {
+		t.Fatalf("expected Arcane Shot casts while the variable is unset")
+	}
+}
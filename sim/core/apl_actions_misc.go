@@ -173,7 +173,7 @@ func (rot *APLRotation) newActionMove(config *proto.APLActionMove) APLActionImpl
 }
 func (action *APLActionMove) IsReady(sim *Simulation) bool {
 	isPrepull := sim.CurrentTime < 0
-	return !action.unit.Moving && (action.moveRange.GetFloat(sim) != action.unit.DistanceFromTarget || isPrepull) && action.unit.Hardcast.Expires < sim.CurrentTime
+	return !action.unit.Moving && (action.moveRange.GetFloat(sim) != action.unit.DistanceFromTarget() || isPrepull) && action.unit.Hardcast.Expires < sim.CurrentTime
 }
 func (action *APLActionMove) Execute(sim *Simulation) {
 	moveRange := action.moveRange.GetFloat(sim)
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// A named value, stored by Set Variable actions and read by Variable values.
+type APLVariable struct {
+	name string
+
+	// Type of the first Set Variable action for this variable. Values from
+	// other Set Variable actions are coerced to it.
+	valueType proto.APLValueType
+
+	boolVal     bool
+	intVal      int32
+	floatVal    float64
+	durationVal time.Duration
+	stringVal   string
+}
+
+func (variable *APLVariable) set(sim *Simulation, value APLValue) {
+	switch variable.valueType {
+	case proto.APLValueType_ValueTypeBool:
+		variable.boolVal = value.GetBool(sim)
+	case proto.APLValueType_ValueTypeInt:
+		variable.intVal = value.GetInt(sim)
+	case proto.APLValueType_ValueTypeFloat:
+		variable.floatVal = value.GetFloat(sim)
+	case proto.APLValueType_ValueTypeDuration:
+		variable.durationVal = value.GetDuration(sim)
+	case proto.APLValueType_ValueTypeString:
+		variable.stringVal = value.GetString(sim)
+	}
+}
+
+func (variable *APLVariable) reset() {
+	*variable = APLVariable{
+		name:      variable.name,
+		valueType: variable.valueType,
+	}
+}
+
+// First Set Variable action for each variable, used to find its type.
+type aplVariableConfig struct {
+	config    *proto.APLActionSetVariable
+	isPrepull bool
+
+	// The action's value, parsed when the variable is first used, and the
+	// warnings from parsing it, which are reported on the action itself.
+	parsed   bool
+	value    APLValue
+	warnings []string
+}
+
+// Finds the first Set Variable action for each variable name, in the order the
+// actions appear in the rotation.
+func (rot *APLRotation) findVariableConfigs(config *proto.APLRotation) {
+	rot.variableConfigs = make(map[string]*aplVariableConfig)
+	var visit func(msg protoreflect.Message, isPrepull bool)
+	visit = func(msg protoreflect.Message, isPrepull bool) {
+		if setVariable, ok := msg.Interface().(*proto.APLActionSetVariable); ok {
+			if _, ok := rot.variableConfigs[setVariable.Name]; !ok {
+				rot.variableConfigs[setVariable.Name] = &aplVariableConfig{config: setVariable, isPrepull: isPrepull}
+			}
+			return
+		}
+		msg.Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+			if fd.Kind() != protoreflect.MessageKind || fd.IsMap() {
+				return true
+			}
+			if fd.IsList() {
+				for i := 0; i < val.List().Len(); i++ {
+					visit(val.List().Get(i).Message(), isPrepull)
+				}
+			} else {
+				visit(val.Message(), isPrepull)
+			}
+			return true
+		})
+	}
+
+	for _, prepullItem := range config.PrepullActions {
+		if !prepullItem.Hide && prepullItem.Action != nil {
+			visit(prepullItem.Action.ProtoReflect(), true)
+		}
+	}
+	items := config.PriorityList
+	for _, list := range config.ActionLists {
+		items = append(items[:len(items):len(items)], list.Items...)
+	}
+	for _, item := range items {
+		if !item.Hide && item.Action != nil {
+			visit(item.Action.ProtoReflect(), false)
+		}
+	}
+}
+
+// Returns the variable with the given name, finding its type from its first
+// Set Variable action if needed.
+func (rot *APLRotation) getVariable(name string) *APLVariable {
+	if variable, ok := rot.variables[name]; ok {
+		if variable.valueType == proto.APLValueType_ValueTypeUnknown {
+			rot.ValidationWarning("Variable '%s' is used in its own first Set Variable action", name)
+			return nil
+		}
+		return variable
+	}
+
+	varConfig, ok := rot.variableConfigs[name]
+	if !ok {
+		rot.ValidationWarning("No Set Variable action for variable: '%s'", name)
+		return nil
+	}
+
+	if varConfig.parsed {
+		// The value was parsed before and isn't valid.
+		rot.ValidationWarning("Variable '%s' has no valid value", name)
+		return nil
+	}
+
+	variable := &APLVariable{name: name}
+	rot.variables[name] = variable
+	varConfig.parsed = true
+	varConfig.warnings = rot.doWithoutWarnings(varConfig.isPrepull, func() {
+		varConfig.value = rot.newAPLValue(varConfig.config.Value)
+	})
+	if varConfig.value == nil {
+		delete(rot.variables, name)
+		rot.ValidationWarning("Variable '%s' has no valid value", name)
+		return nil
+	}
+	variable.valueType = varConfig.value.Type()
+	return variable
+}
+
+type APLActionSetVariable struct {
+	defaultAPLActionImpl
+	variable *APLVariable
+	value    APLValue
+}
+
+func (rot *APLRotation) newActionSetVariable(config *proto.APLActionSetVariable) APLActionImpl {
+	if config.Name == "" {
+		rot.ValidationWarning("Set Variable must provide a variable name")
+		return nil
+	}
+	variable := rot.getVariable(config.Name)
+
+	// The first Set Variable action's value was already parsed to find the
+	// variable's type, so reuse it along with its warnings.
+	var value APLValue
+	if varConfig := rot.variableConfigs[config.Name]; varConfig != nil && varConfig.config == config {
+		rot.curWarnings = append(rot.curWarnings, varConfig.warnings...)
+		value = varConfig.value
+	} else if variable != nil {
+		value = rot.newAPLValue(config.Value)
+	}
+	if variable == nil {
+		return nil
+	}
+	value = rot.coerceTo(value, variable.valueType)
+	if value == nil {
+		return nil
+	}
+	return &APLActionSetVariable{
+		variable: variable,
+		value:    value,
+	}
+}
+func (action *APLActionSetVariable) GetAPLValues() []APLValue {
+	return []APLValue{action.value}
+}
+func (action *APLActionSetVariable) IsReady(sim *Simulation) bool {
+	return true
+}
+func (action *APLActionSetVariable) Execute(sim *Simulation) {
+	action.variable.set(sim, action.value)
+}
+func (action *APLActionSetVariable) String() string {
+	return fmt.Sprintf("Set Variable(name = '%s', value = %s)", action.variable.name, action.value)
+}
@@ -0,0 +1,110 @@
+package core_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/warlock/affliction"
+)
+
+var registerAfflictionWarlock sync.Once
+
+var testDrainLife = core.ActionID{SpellID: 689}
+
+func TestAPLVariableValue(t *testing.T) {
+	aplText := func(pool string) string {
+		return `
+set_variable(pool, ` + pool + `)
+cast_spell(3044) if current_focus >= variable(pool)
+cast_spell(56641)
+`
+	}
+
+	if casts := playerActionMetrics(runAPLTest(t, aplText("40")), testArcaneShot).Casts; casts == 0 {
+		t.Fatalf("expected Arcane Shot casts when pooling to 40 focus")
+	}
+	if casts := playerActionMetrics(runAPLTest(t, aplText("200")), testArcaneShot).Casts; casts != 0 {
+		t.Fatalf("expected no Arcane Shot casts when pooling to 200 focus, got %d", casts)
+	}
+}
+
+func TestAPLVariableFlag(t *testing.T) {
+	// The flag stays set for the rest of the iteration, but is cleared before
+	// the next one.
+	const aplText = `
+set_variable(done, true) if current_time >= 30s
+cast_spell(3044) if !variable(done)
+cast_spell(56641)
+`
+	rsr := makeAPLTestCase(t, aplText)
+	rsr.SimOptions.Iterations = 1
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	casts := playerActionMetrics(result, testArcaneShot).Casts
+	if casts == 0 || casts > 30 {
+		t.Fatalf("expected Arcane Shot casts only in the first 30s, got %d", casts)
+	}
+
+	result = runAPLTest(t, aplText)
+	if allCasts := playerActionMetrics(result, testArcaneShot).Casts; allCasts < 4*casts {
+		t.Fatalf("expected Arcane Shot casts in every iteration, got %d casts over 5 iterations and %d in 1", allCasts, casts)
+	}
+}
+
+func TestAPLVariableWarnings(t *testing.T) {
+	rsr := makeAPLTestCase(t, `
+cast_spell(3044) if variable(missing)
+set_variable(count, variable(count) + 1)
+cast_spell(56641)
+`)
+
+	stats := core.ComputeStats(&proto.ComputeStatsRequest{Raid: rsr.Raid, Encounter: rsr.Encounter})
+	rotationStats := stats.RaidStats.Parties[0].Players[0].RotationStats
+	if warnings := rotationStats.PriorityList[0].Warnings; len(warnings) == 0 || !strings.Contains(warnings[0], "No Set Variable action for variable: 'missing'") {
+		t.Fatalf("expected a warning for the missing variable, got %v", warnings)
+	}
+	if warnings := rotationStats.PriorityList[1].Warnings; len(warnings) == 0 || !strings.Contains(warnings[0], "Variable 'count' has no valid value") {
+		t.Fatalf("expected a warning for the self-referencing variable, got %v", warnings)
+	}
+}
+
+func TestAPLChannelInterruptCheckSetsNoVariables(t *testing.T) {
+	// Each tick of the channel checks which action would interrupt it. That
+	// check reaches the Set Variable action, but must not perform it, or Drain
+	// Life is never channeled again.
+	rotation, err := core.ParseAPLText(`
+channel_spell(689, interrupt_if=true) if !variable(done) && !spell_is_channeling(689)
+set_variable(done, true) if spell_channeled_ticks(689) > 0
+`)
+	if err != nil {
+		t.Fatalf("failed to parse APL: %s", err)
+	}
+
+	registerAfflictionWarlock.Do(affliction.RegisterAfflictionWarlock)
+	rsr := makeTestCase(&proto.Player{
+		Name:      "Warlock",
+		Race:      proto.Race_RaceOrc,
+		Class:     proto.Class_ClassWarlock,
+		Equipment: &proto.EquipmentSpec{},
+		Rotation:  rotation,
+		Spec: &proto.Player_AfflictionWarlock{
+			AfflictionWarlock: &proto.AfflictionWarlock{
+				Options: &proto.AfflictionWarlock_Options{ClassOptions: &proto.WarlockOptions{}},
+			},
+		},
+	})
+	rsr.SimOptions.Iterations = 5
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	if casts := playerActionMetrics(result, testDrainLife).Casts; casts <= 2*rsr.SimOptions.Iterations {
+		t.Fatalf("expected Drain Life to be channeled throughout each iteration, got %d casts over %d iterations", casts, rsr.SimOptions.Iterations)
+	}
+}
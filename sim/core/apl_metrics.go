@@ -0,0 +1,79 @@
+package core
+
+import (
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Usage of an APL list item, summed over all iterations. Only evaluations made
+// by DoNextAction are counted, see decideNextAction.
+type aplItemMetrics struct {
+	evaluated     int64
+	conditionTrue int64
+	fired         int64
+	resourceSum   float64
+}
+
+// The resource reported in APL item metrics, which is the unit's main resource
+// for choosing actions.
+func (unit *Unit) aplMetricsResourceType() proto.ResourceType {
+	switch {
+	case unit.HasEnergyBar():
+		return proto.ResourceType_ResourceTypeEnergy
+	case unit.HasRageBar():
+		return proto.ResourceType_ResourceTypeRage
+	case unit.HasFocusBar():
+		return proto.ResourceType_ResourceTypeFocus
+	case unit.HasRunicPowerBar():
+		return proto.ResourceType_ResourceTypeRunicPower
+	case unit.HasManaBar():
+		return proto.ResourceType_ResourceTypeMana
+	}
+	return proto.ResourceType_ResourceTypeNone
+}
+
+func (unit *Unit) aplMetricsResource() float64 {
+	switch unit.aplMetricsResourceType() {
+	case proto.ResourceType_ResourceTypeEnergy:
+		return unit.CurrentEnergy()
+	case proto.ResourceType_ResourceTypeRage:
+		return unit.CurrentRage()
+	case proto.ResourceType_ResourceTypeFocus:
+		return unit.CurrentFocus()
+	case proto.ResourceType_ResourceTypeRunicPower:
+		return unit.CurrentRunicPower()
+	case proto.ResourceType_ResourceTypeMana:
+		return unit.CurrentMana()
+	}
+	return 0
+}
+
+func (list *APLActionList) getItemMetricsProto(resourceType proto.ResourceType) []*proto.APLItemMetrics {
+	itemMetrics := make([]*proto.APLItemMetrics, len(list.metrics))
+	for i, metrics := range list.metrics {
+		itemMetrics[i] = &proto.APLItemMetrics{
+			List:          list.name,
+			Index:         int32(list.actionConfigIdxs[i]),
+			Evaluated:     metrics.evaluated,
+			ConditionTrue: metrics.conditionTrue,
+			Fired:         metrics.fired,
+			ResourceType:  resourceType,
+		}
+		if metrics.fired > 0 {
+			itemMetrics[i].ResourceAvg = metrics.resourceSum / float64(metrics.fired)
+		}
+	}
+	return itemMetrics
+}
+
+func (rot *APLRotation) getItemMetricsProto() []*proto.APLItemMetrics {
+	if rot == nil || rot.priorityActionList == nil {
+		return nil
+	}
+
+	resourceType := rot.unit.aplMetricsResourceType()
+	itemMetrics := rot.priorityActionList.getItemMetricsProto(resourceType)
+	for _, list := range rot.actionLists {
+		itemMetrics = append(itemMetrics, list.getItemMetricsProto(resourceType)...)
+	}
+	return itemMetrics
+}
@@ -0,0 +1,49 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+func TestAPLItemMetrics(t *testing.T) {
+	result := runAPLTest(t, `
+hidden cast_spell(3044)
+cast_spell(3044) if current_focus >= 200
+call_list(shots)
+
+list shots
+cast_spell(56641)
+`)
+	metrics := result.RaidMetrics.Parties[0].Players[0]
+
+	items := make(map[string]map[int32]*proto.APLItemMetrics)
+	for _, item := range metrics.AplItems {
+		if items[item.List] == nil {
+			items[item.List] = make(map[int32]*proto.APLItemMetrics)
+		}
+		items[item.List][item.Index] = item
+	}
+	if len(metrics.AplItems) != 3 || items[""][0] != nil {
+		t.Fatalf("expected metrics for every item but the hidden one, got %v", metrics.AplItems)
+	}
+
+	if neverTrue := items[""][1]; neverTrue.Evaluated == 0 || neverTrue.ConditionTrue != 0 || neverTrue.Fired != 0 {
+		t.Fatalf("expected Arcane Shot to be evaluated but never true, got %v", neverTrue)
+	}
+
+	callList, steadyShot := items[""][2], items["shots"][0]
+	if steadyShot.Fired == 0 || steadyShot.Fired > steadyShot.ConditionTrue || steadyShot.ConditionTrue > steadyShot.Evaluated {
+		t.Fatalf("expected Steady Shot to fire, got %v", steadyShot)
+	}
+	if callList.Fired != steadyShot.Fired {
+		t.Fatalf("expected the call list to fire with Steady Shot, got %d and %d", callList.Fired, steadyShot.Fired)
+	}
+	if casts := playerActionMetrics(result, testSteadyShot).Casts; steadyShot.Fired < int64(casts) {
+		t.Fatalf("expected Steady Shot to fire at least as often as it was cast, got %d fired and %d casts", steadyShot.Fired, casts)
+	}
+
+	if steadyShot.ResourceType != proto.ResourceType_ResourceTypeFocus || steadyShot.ResourceAvg <= 0 || steadyShot.ResourceAvg > 100 {
+		t.Fatalf("expected the average focus when Steady Shot fired, got %v", steadyShot)
+	}
+}
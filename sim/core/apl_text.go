@@ -0,0 +1,900 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Text syntax for APL rotations, in the spirit of SimulationCraft action lists:
+//
+//	# Notes for the item below.
+//	prepull(-1s) cast_spell(78674)
+//	cast_spell(78674) if aura_is_active(48517) && gcd_is_ready
+//	hidden multidot(8921, 3, 0s)
+//	call_list(cooldowns) if is_execute_phase(E20)
+//
+//	list cooldowns
+//	cast_spell(12292)
+//
+// Each line is an action, optionally followed by `if <condition>`. Comment lines
+// directly above a priority list item are kept as its notes. A `list <name>`
+// line starts a named action list, which contains the items after it.
+//
+// The rest of the rotation is set by header lines. `type TypeSimple` sets the
+// rotation type, which defaults to TypeAPL, and `simple "<json>"` sets the simple
+// rotation settings, like the cooldowns, in protojson format.
+//
+// Actions and values are written as calls named after their field in the
+// APLAction or APLValue oneof, e.g. aura_is_active(48517). Arguments fill the
+// fields of the message in field number order, or can be named as in
+// aura_is_active(48517, source_unit=Target:1). Messages with a single repeated
+// field, like strict_sequence, take the list elements as arguments. Names, like
+// those of sequences and lists, can be written without quotes.
+//
+// Values support the usual operators (||, &&, !, comparisons and + - * /) and
+// constants like 5, 1.5s, 20% and "text". Action IDs are written as 12345 for
+// spells, item:12345 or other:OtherActionPotion, with an optional :tag suffix.
+// Units are written as Self, CurrentTarget, Target:1 or Pet:0 of Player:1.
+
+// Syntax error in an APL text, at a 1-based line and column.
+type APLTextError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err *APLTextError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", err.Line, err.Column, err.Message)
+}
+
+type aplTokenKind int
+
+const (
+	aplTokenEOF aplTokenKind = iota
+	aplTokenNewline
+	aplTokenComment
+	aplTokenIdent
+	aplTokenNumber
+	aplTokenString
+	aplTokenSymbol
+)
+
+type aplToken struct {
+	kind   aplTokenKind
+	text   string
+	line   int
+	column int
+}
+
+func (tok aplToken) String() string {
+	switch tok.kind {
+	case aplTokenEOF:
+		return "end of input"
+	case aplTokenNewline:
+		return "end of line"
+	case aplTokenString:
+		return strconv.Quote(tok.text)
+	default:
+		return "'" + tok.text + "'"
+	}
+}
+
+var aplSymbols = []string{"&&", "||", "==", "!=", "<=", ">=", "(", ")", "[", "]", ",", ":", "=", "!", "<", ">", "+", "-", "*", "/"}
+
+// Splits APL text into tokens. Line breaks are only significant outside of
+// parentheses and brackets, so long calls can be wrapped.
+func lexAPLText(text string) ([]aplToken, error) {
+	var tokens []aplToken
+	line, lineStart := 1, 0
+	depth := 0
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		tok := aplToken{line: line, column: i - lineStart + 1}
+
+		switch {
+		case c == '\n':
+			if depth == 0 {
+				tok.kind = aplTokenNewline
+				tokens = append(tokens, tok)
+			}
+			i++
+			line, lineStart = line+1, i
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case c == '#':
+			end := strings.IndexByte(text[i:], '\n')
+			if end == -1 {
+				end = len(text) - i
+			}
+			if depth == 0 {
+				tok.kind = aplTokenComment
+				tok.text = strings.TrimPrefix(strings.TrimRight(text[i+1:i+end], "\r"), " ")
+				tokens = append(tokens, tok)
+			}
+			i += end
+			continue
+		case c == '_' || unicode.IsLetter(rune(c)):
+			end := i + 1
+			for end < len(text) && (text[end] == '_' || unicode.IsLetter(rune(text[end])) || unicode.IsDigit(rune(text[end]))) {
+				end++
+			}
+			tok.kind = aplTokenIdent
+			tok.text = text[i:end]
+		case unicode.IsDigit(rune(c)) || (c == '.' && i+1 < len(text) && unicode.IsDigit(rune(text[i+1]))):
+			// Numbers keep their unit suffix, e.g. 1.5s or 20%.
+			end := i + 1
+			for end < len(text) && (text[end] == '.' || unicode.IsDigit(rune(text[end]))) {
+				end++
+			}
+			for end < len(text) && (text[end] == '%' || unicode.IsLetter(rune(text[end]))) {
+				end++
+			}
+			tok.kind = aplTokenNumber
+			tok.text = text[i:end]
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' && text[end] != '\n' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(text) || text[end] != '"' {
+				return nil, &APLTextError{Line: tok.line, Column: tok.column, Message: "unterminated string"}
+			}
+			unquoted, err := strconv.Unquote(text[i : end+1])
+			if err != nil {
+				return nil, &APLTextError{Line: tok.line, Column: tok.column, Message: "invalid string " + text[i:end+1]}
+			}
+			tok.kind = aplTokenString
+			tok.text = unquoted
+			end++
+			tokens = append(tokens, tok)
+			i = end
+			continue
+		default:
+			for _, symbol := range aplSymbols {
+				if strings.HasPrefix(text[i:], symbol) {
+					tok.kind = aplTokenSymbol
+					tok.text = symbol
+					break
+				}
+			}
+			if tok.kind != aplTokenSymbol {
+				return nil, &APLTextError{Line: tok.line, Column: tok.column, Message: fmt.Sprintf("unexpected character %q", c)}
+			}
+			switch tok.text {
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth = max(0, depth-1)
+			}
+		}
+
+		tokens = append(tokens, tok)
+		i += len(tok.text)
+	}
+
+	tokens = append(tokens, aplToken{kind: aplTokenEOF, line: line, column: len(text) - lineStart + 1})
+	return tokens, nil
+}
+
+type aplTextParser struct {
+	tokens []aplToken
+	pos    int
+}
+
+// Parses an APL rotation from its text syntax.
+func ParseAPLText(text string) (*proto.APLRotation, error) {
+	tokens, err := lexAPLText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &aplTextParser{tokens: tokens}
+	rotation, err := parser.parseRotation()
+	if err != nil {
+		return nil, err
+	}
+	return rotation, nil
+}
+
+func (parser *aplTextParser) peek() aplToken {
+	return parser.tokens[parser.pos]
+}
+
+func (parser *aplTextParser) next() aplToken {
+	tok := parser.tokens[parser.pos]
+	if tok.kind != aplTokenEOF {
+		parser.pos++
+	}
+	return tok
+}
+
+func (parser *aplTextParser) isSymbol(symbols ...string) bool {
+	tok := parser.peek()
+	return tok.kind == aplTokenSymbol && slices.Contains(symbols, tok.text)
+}
+
+func (parser *aplTextParser) isIdent(text string) bool {
+	tok := parser.peek()
+	return tok.kind == aplTokenIdent && tok.text == text
+}
+
+func (parser *aplTextParser) errorf(tok aplToken, format string, args ...any) error {
+	return &APLTextError{Line: tok.line, Column: tok.column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (parser *aplTextParser) expectSymbol(symbol string) error {
+	if !parser.isSymbol(symbol) {
+		return parser.errorf(parser.peek(), "expected '%s', found %s", symbol, parser.peek())
+	}
+	parser.next()
+	return nil
+}
+
+// Allows wrapping lines after an operator.
+func (parser *aplTextParser) skipNewlines() {
+	for parser.peek().kind == aplTokenNewline {
+		parser.next()
+	}
+}
+
+// Returns whether the next token is one of the given operators, also allowing
+// lines to be wrapped before it.
+func (parser *aplTextParser) isOperator(symbols ...string) bool {
+	pos := parser.pos
+	for parser.tokens[pos].kind == aplTokenNewline {
+		pos++
+	}
+	tok := parser.tokens[pos]
+	if tok.kind != aplTokenSymbol || !slices.Contains(symbols, tok.text) {
+		return false
+	}
+	parser.pos = pos
+	return true
+}
+
+func (parser *aplTextParser) parseRotation() (*proto.APLRotation, error) {
+	rotation := &proto.APLRotation{
+		Type: proto.APLRotation_TypeAPL,
+	}
+
+	var notes []string
+	var actionList *proto.APLActionList
+	typeSet := false
+	lastWasNewline := true
+	for {
+		tok := parser.peek()
+		switch tok.kind {
+		case aplTokenEOF:
+			return rotation, nil
+		case aplTokenNewline:
+			parser.next()
+			if lastWasNewline {
+				// Comments separated by a blank line aren't notes.
+				notes = nil
+			}
+			lastWasNewline = true
+			continue
+		case aplTokenComment:
+			parser.next()
+			notes = append(notes, tok.text)
+			lastWasNewline = false
+			continue
+		}
+		lastWasNewline = false
+
+		if parser.isIdent("list") {
+			parser.next()
+			nameTok := parser.next()
+			if nameTok.kind != aplTokenIdent && nameTok.kind != aplTokenString {
+				return nil, parser.errorf(nameTok, "expected a list name, found %s", nameTok)
+			}
+			actionList = &proto.APLActionList{Name: nameTok.text}
+			rotation.ActionLists = append(rotation.ActionLists, actionList)
+		} else if parser.isIdent("type") || parser.isIdent("simple") {
+			if err := parser.parseHeader(rotation, &typeSet); err != nil {
+				return nil, err
+			}
+		} else if err := parser.parseItem(rotation, actionList, notes); err != nil {
+			return nil, err
+		}
+		notes = nil
+
+		// Trailing comments are ignored.
+		if parser.peek().kind == aplTokenComment {
+			parser.next()
+		}
+		if tok := parser.peek(); tok.kind != aplTokenNewline && tok.kind != aplTokenEOF {
+			return nil, parser.errorf(tok, "expected end of line, found %s", tok)
+		}
+	}
+}
+
+// Parses a type or simple header line.
+func (parser *aplTextParser) parseHeader(rotation *proto.APLRotation, typeSet *bool) error {
+	keyword := parser.next()
+	tok := parser.next()
+	if keyword.text == "type" {
+		rotationType, ok := proto.APLRotation_Type_value[tok.text]
+		if tok.kind != aplTokenIdent || !ok {
+			return parser.errorf(tok, "expected a rotation type, found %s", tok)
+		}
+		if *typeSet {
+			return parser.errorf(keyword, "type is set twice")
+		}
+		*typeSet = true
+		rotation.Type = proto.APLRotation_Type(rotationType)
+		return nil
+	}
+
+	if tok.kind != aplTokenString {
+		return parser.errorf(tok, "expected the simple rotation as a json string, found %s", tok)
+	}
+	if rotation.Simple != nil {
+		return parser.errorf(keyword, "simple is set twice")
+	}
+	rotation.Simple = &proto.SimpleRotation{}
+	if err := protojson.Unmarshal([]byte(tok.text), rotation.Simple); err != nil {
+		return parser.errorf(tok, "invalid simple rotation: %s", err)
+	}
+	return nil
+}
+
+// Parses a prepull action, or an item of the priority list or of a named action list.
+func (parser *aplTextParser) parseItem(rotation *proto.APLRotation, actionList *proto.APLActionList, notes []string) error {
+	hide := false
+	if parser.isIdent("hidden") {
+		parser.next()
+		hide = true
+	}
+
+	if parser.isIdent("prepull") {
+		parser.next()
+		prepullAction := &proto.APLPrepullAction{Hide: hide}
+		if parser.isSymbol("(") {
+			parser.next()
+			doAt, err := parser.parseValue()
+			if err != nil {
+				return err
+			}
+			if err := parser.expectSymbol(")"); err != nil {
+				return err
+			}
+			prepullAction.DoAtValue = doAt
+		}
+		action, err := parser.parseAction()
+		if err != nil {
+			return err
+		}
+		prepullAction.Action = action
+		rotation.PrepullActions = append(rotation.PrepullActions, prepullAction)
+		return nil
+	}
+
+	action, err := parser.parseAction()
+	if err != nil {
+		return err
+	}
+	item := &proto.APLListItem{
+		Hide:   hide,
+		Notes:  strings.Join(notes, "\n"),
+		Action: action,
+	}
+	if actionList != nil {
+		actionList.Items = append(actionList.Items, item)
+	} else {
+		rotation.PriorityList = append(rotation.PriorityList, item)
+	}
+	return nil
+}
+
+var aplActionOneof = (&proto.APLAction{}).ProtoReflect().Descriptor().Oneofs().ByName("action")
+var aplValueOneof = (&proto.APLValue{}).ProtoReflect().Descriptor().Oneofs().ByName("value")
+
+func (parser *aplTextParser) parseAction() (*proto.APLAction, error) {
+	action := &proto.APLAction{}
+
+	tok := parser.next()
+	if tok.kind != aplTokenIdent {
+		return nil, parser.errorf(tok, "expected an action, found %s", tok)
+	}
+	if tok.text != "none" {
+		fd := aplActionOneof.Fields().ByName(protoreflect.Name(tok.text))
+		if fd == nil {
+			return nil, parser.errorf(tok, "unknown action %s", tok.text)
+		}
+		if err := parser.parseCall(action.ProtoReflect().Mutable(fd).Message()); err != nil {
+			return nil, err
+		}
+	}
+
+	if parser.isIdent("if") {
+		parser.next()
+		parser.skipNewlines()
+		condition, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		action.Condition = condition
+	}
+	return action, nil
+}
+
+func (parser *aplTextParser) parseValue() (*proto.APLValue, error) {
+	return parser.parseOr()
+}
+
+func (parser *aplTextParser) parseOr() (*proto.APLValue, error) {
+	return parser.parseVariadicOp("||", parser.parseAnd, func(vals []*proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Or{Or: &proto.APLValueOr{Vals: vals}}}
+	})
+}
+
+func (parser *aplTextParser) parseAnd() (*proto.APLValue, error) {
+	return parser.parseVariadicOp("&&", parser.parseCompare, func(vals []*proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_And{And: &proto.APLValueAnd{Vals: vals}}}
+	})
+}
+
+// Chains of the same operator become a single value, e.g. a && b && c is one
+// APLValueAnd. Parenthesized operands are kept as nested values.
+func (parser *aplTextParser) parseVariadicOp(symbol string, parseOperand func() (*proto.APLValue, error), makeValue func([]*proto.APLValue) *proto.APLValue) (*proto.APLValue, error) {
+	first, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.isOperator(symbol) {
+		return first, nil
+	}
+
+	vals := []*proto.APLValue{first}
+	for parser.isOperator(symbol) {
+		parser.next()
+		parser.skipNewlines()
+		val, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return makeValue(vals), nil
+}
+
+var aplCompareOps = map[string]proto.APLValueCompare_ComparisonOperator{
+	"==": proto.APLValueCompare_OpEq,
+	"!=": proto.APLValueCompare_OpNe,
+	"<":  proto.APLValueCompare_OpLt,
+	"<=": proto.APLValueCompare_OpLe,
+	">":  proto.APLValueCompare_OpGt,
+	">=": proto.APLValueCompare_OpGe,
+}
+
+var aplMathOps = map[string]proto.APLValueMath_MathOperator{
+	"+": proto.APLValueMath_OpAdd,
+	"-": proto.APLValueMath_OpSub,
+	"*": proto.APLValueMath_OpMul,
+	"/": proto.APLValueMath_OpDiv,
+}
+
+func (parser *aplTextParser) parseCompare() (*proto.APLValue, error) {
+	lhs, err := parser.parseMath(0)
+	if err != nil {
+		return nil, err
+	}
+	if !parser.isOperator("==", "!=", "<", "<=", ">", ">=") {
+		return lhs, nil
+	}
+
+	op := aplCompareOps[parser.next().text]
+	parser.skipNewlines()
+	rhs, err := parser.parseMath(0)
+	if err != nil {
+		return nil, err
+	}
+	if parser.isOperator("==", "!=", "<", "<=", ">", ">=") {
+		return nil, parser.errorf(parser.peek(), "comparisons can't be chained, use parentheses")
+	}
+	return &proto.APLValue{Value: &proto.APLValue_Cmp{Cmp: &proto.APLValueCompare{Op: op, Lhs: lhs, Rhs: rhs}}}, nil
+}
+
+// Parses left-associative math, with * and / (level 1) binding tighter than
+// + and - (level 0).
+func (parser *aplTextParser) parseMath(level int) (*proto.APLValue, error) {
+	parseOperand := func() (*proto.APLValue, error) {
+		if level == 0 {
+			return parser.parseMath(1)
+		}
+		return parser.parseUnary()
+	}
+	symbols := [][]string{{"+", "-"}, {"*", "/"}}[level]
+
+	lhs, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for parser.isOperator(symbols...) {
+		op := aplMathOps[parser.next().text]
+		parser.skipNewlines()
+		rhs, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &proto.APLValue{Value: &proto.APLValue_Math{Math: &proto.APLValueMath{Op: op, Lhs: lhs, Rhs: rhs}}}
+	}
+	return lhs, nil
+}
+
+func (parser *aplTextParser) parseUnary() (*proto.APLValue, error) {
+	if !parser.isSymbol("!") {
+		return parser.parsePrimary()
+	}
+	parser.next()
+	val, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &proto.APLValue{Value: &proto.APLValue_Not{Not: &proto.APLValueNot{Val: val}}}, nil
+}
+
+func newAPLConst(val string) *proto.APLValue {
+	return &proto.APLValue{Value: &proto.APLValue_Const{Const: &proto.APLValueConst{Val: val}}}
+}
+
+// Returns whether the text of a number, with an optional minus sign, is a
+// constant the sim can read: an integer which fits in 32 bits, a decimal, a
+// percentage or a duration.
+func isValidAPLNumber(text string) bool {
+	if number, ok := strings.CutSuffix(text, "%"); ok {
+		_, err := strconv.ParseFloat(number, 64)
+		return err == nil
+	}
+	if strings.IndexFunc(text, unicode.IsLetter) != -1 {
+		_, err := time.ParseDuration(text)
+		return err == nil
+	}
+	if !strings.Contains(text, ".") {
+		_, err := strconv.ParseInt(text, 10, 32)
+		return err == nil
+	}
+	_, err := strconv.ParseFloat(text, 64)
+	return err == nil
+}
+
+// Makes a constant from a number starting at tok.
+func (parser *aplTextParser) newNumberConst(tok aplToken, text string) (*proto.APLValue, error) {
+	if !isValidAPLNumber(text) {
+		return nil, parser.errorf(tok, "invalid number %s", text)
+	}
+	return newAPLConst(text), nil
+}
+
+func (parser *aplTextParser) parsePrimary() (*proto.APLValue, error) {
+	tok := parser.next()
+	switch tok.kind {
+	case aplTokenNumber:
+		return parser.newNumberConst(tok, tok.text)
+	case aplTokenString:
+		return newAPLConst(tok.text), nil
+	case aplTokenSymbol:
+		switch tok.text {
+		case "(":
+			val, err := parser.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := parser.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+			return val, nil
+		case "-":
+			if number := parser.peek(); number.kind == aplTokenNumber {
+				parser.next()
+				return parser.newNumberConst(tok, "-"+number.text)
+			}
+		}
+	case aplTokenIdent:
+		switch tok.text {
+		case "true", "false":
+			return newAPLConst(tok.text), nil
+		case "none":
+			return &proto.APLValue{}, nil
+		}
+		fd := aplValueOneof.Fields().ByName(protoreflect.Name(tok.text))
+		if fd == nil {
+			return nil, parser.errorf(tok, "unknown value %s", tok.text)
+		}
+		val := &proto.APLValue{}
+		if err := parser.parseCall(val.ProtoReflect().Mutable(fd).Message()); err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+	return nil, parser.errorf(tok, "expected a value, found %s", tok)
+}
+
+// Returns the fields of an action or value message in the order of their
+// positional arguments.
+func aplArgFields(md protoreflect.MessageDescriptor) []protoreflect.FieldDescriptor {
+	fields := make([]protoreflect.FieldDescriptor, md.Fields().Len())
+	for i := range fields {
+		fields[i] = md.Fields().Get(i)
+	}
+	slices.SortFunc(fields, func(a, b protoreflect.FieldDescriptor) int {
+		return int(a.Number() - b.Number())
+	})
+	return fields
+}
+
+// Whether the arguments of a message are the elements of its only field.
+func isAPLVariadic(fields []protoreflect.FieldDescriptor) bool {
+	return len(fields) == 1 && fields[0].IsList()
+}
+
+// Parses the optional argument list of an action or value into its message.
+func (parser *aplTextParser) parseCall(msg protoreflect.Message) error {
+	if !parser.isSymbol("(") {
+		return nil
+	}
+	parser.next()
+
+	fields := aplArgFields(msg.Descriptor())
+	variadic := isAPLVariadic(fields)
+	named := false
+	for i := 0; !parser.isSymbol(")"); i++ {
+		if i > 0 {
+			if err := parser.expectSymbol(","); err != nil {
+				return err
+			}
+			if parser.isSymbol(")") {
+				break
+			}
+		}
+
+		tok := parser.peek()
+		var fd protoreflect.FieldDescriptor
+		if tok.kind == aplTokenIdent && parser.tokens[parser.pos+1].kind == aplTokenSymbol && parser.tokens[parser.pos+1].text == "=" {
+			fd = msg.Descriptor().Fields().ByName(protoreflect.Name(tok.text))
+			if fd == nil {
+				return parser.errorf(tok, "%s has no argument %s", msg.Descriptor().Name(), tok.text)
+			}
+			if msg.Has(fd) {
+				return parser.errorf(tok, "argument %s is set twice", tok.text)
+			}
+			parser.next()
+			parser.next()
+			named = true
+		} else if named {
+			return parser.errorf(tok, "positional arguments must come before named arguments")
+		} else if variadic {
+			list := msg.Mutable(fields[0]).List()
+			elem, err := parser.parseSingular(fields[0], list.NewElement())
+			if err != nil {
+				return err
+			}
+			list.Append(elem)
+			continue
+		} else if i < len(fields) {
+			fd = fields[i]
+		} else {
+			return parser.errorf(tok, "too many arguments for %s", msg.Descriptor().Name())
+		}
+
+		if err := parser.parseField(msg, fd); err != nil {
+			return err
+		}
+	}
+	parser.next()
+	return nil
+}
+
+func (parser *aplTextParser) parseField(msg protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	if !fd.IsList() {
+		val, err := parser.parseSingular(fd, msg.NewField(fd))
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, val)
+		return nil
+	}
+
+	if err := parser.expectSymbol("["); err != nil {
+		return err
+	}
+	list := msg.Mutable(fd).List()
+	for i := 0; !parser.isSymbol("]"); i++ {
+		if i > 0 {
+			if err := parser.expectSymbol(","); err != nil {
+				return err
+			}
+			if parser.isSymbol("]") {
+				break
+			}
+		}
+		elem, err := parser.parseSingular(fd, list.NewElement())
+		if err != nil {
+			return err
+		}
+		list.Append(elem)
+	}
+	parser.next()
+	return nil
+}
+
+// Parses a single value of a field. The empty value is used as the starting
+// point for messages.
+func (parser *aplTextParser) parseSingular(fd protoreflect.FieldDescriptor, empty protoreflect.Value) (protoreflect.Value, error) {
+	tok := parser.peek()
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		var msg interface{ ProtoReflect() protoreflect.Message }
+		var err error
+		switch fd.Message().FullName() {
+		case "proto.APLValue":
+			msg, err = parser.parseValue()
+		case "proto.APLAction":
+			msg, err = parser.parseAction()
+		case "proto.ActionID":
+			msg, err = parser.parseActionID()
+		case "proto.UnitReference":
+			msg, err = parser.parseUnitReference()
+		default:
+			return empty, parser.errorf(tok, "arguments of type %s aren't supported", fd.Message().Name())
+		}
+		if err != nil {
+			return empty, err
+		}
+		return protoreflect.ValueOfMessage(msg.ProtoReflect()), nil
+	case protoreflect.EnumKind:
+		parser.next()
+		if tok.kind == aplTokenIdent {
+			if enumValue := fd.Enum().Values().ByName(protoreflect.Name(tok.text)); enumValue != nil {
+				return protoreflect.ValueOfEnum(enumValue.Number()), nil
+			}
+		}
+		return empty, parser.errorf(tok, "expected a value of %s, found %s", fd.Enum().Name(), tok)
+	case protoreflect.BoolKind:
+		parser.next()
+		if tok.kind == aplTokenIdent && (tok.text == "true" || tok.text == "false") {
+			return protoreflect.ValueOfBool(tok.text == "true"), nil
+		}
+		return empty, parser.errorf(tok, "expected true or false, found %s", tok)
+	case protoreflect.StringKind:
+		// Names like sequence or list names don't need quotes.
+		parser.next()
+		if tok.kind == aplTokenString || tok.kind == aplTokenIdent {
+			return protoreflect.ValueOfString(tok.text), nil
+		}
+		return empty, parser.errorf(tok, "expected a string, found %s", tok)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		val, err := parser.parseInt()
+		return protoreflect.ValueOfInt32(int32(val)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		val, err := parser.parseInt()
+		return protoreflect.ValueOfInt64(val), err
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		text := parser.parseSignedNumber()
+		val, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return empty, parser.errorf(tok, "expected a number, found %s", tok)
+		}
+		if fd.Kind() == protoreflect.FloatKind {
+			return protoreflect.ValueOfFloat32(float32(val)), nil
+		}
+		return protoreflect.ValueOfFloat64(val), nil
+	}
+	return empty, parser.errorf(tok, "arguments of type %s aren't supported", fd.Kind())
+}
+
+// Returns the text of a number with an optional minus sign, or of the next token
+// if it isn't a number.
+func (parser *aplTextParser) parseSignedNumber() string {
+	if parser.isSymbol("-") && parser.tokens[parser.pos+1].kind == aplTokenNumber {
+		parser.next()
+		return "-" + parser.next().text
+	}
+	return parser.next().text
+}
+
+func (parser *aplTextParser) parseInt() (int64, error) {
+	tok := parser.peek()
+	val, err := strconv.ParseInt(parser.parseSignedNumber(), 10, 64)
+	if err != nil {
+		return 0, parser.errorf(tok, "expected an integer, found %s", tok)
+	}
+	return val, nil
+}
+
+// Parses IDs like 12345, 12345:1, item:12345 or other:OtherActionPotion.
+func (parser *aplTextParser) parseActionID() (*proto.ActionID, error) {
+	actionID := &proto.ActionID{}
+
+	tok := parser.peek()
+	switch {
+	case tok.kind == aplTokenIdent && tok.text == "none":
+		parser.next()
+	case tok.kind == aplTokenIdent && (tok.text == "spell" || tok.text == "item"):
+		parser.next()
+		if err := parser.expectSymbol(":"); err != nil {
+			return nil, err
+		}
+		id, err := parser.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "spell" {
+			actionID.RawId = &proto.ActionID_SpellId{SpellId: int32(id)}
+		} else {
+			actionID.RawId = &proto.ActionID_ItemId{ItemId: int32(id)}
+		}
+	case tok.kind == aplTokenIdent && tok.text == "other":
+		parser.next()
+		if err := parser.expectSymbol(":"); err != nil {
+			return nil, err
+		}
+		nameTok := parser.next()
+		otherID, ok := proto.OtherAction_value[nameTok.text]
+		if nameTok.kind != aplTokenIdent || !ok {
+			return nil, parser.errorf(nameTok, "unknown other action %s", nameTok)
+		}
+		actionID.RawId = &proto.ActionID_OtherId{OtherId: proto.OtherAction(otherID)}
+	case tok.kind == aplTokenNumber:
+		id, err := parser.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		actionID.RawId = &proto.ActionID_SpellId{SpellId: int32(id)}
+	default:
+		return nil, parser.errorf(tok, "expected an action ID, found %s", tok)
+	}
+
+	if parser.isSymbol(":") {
+		parser.next()
+		tag, err := parser.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		actionID.Tag = int32(tag)
+	}
+	return actionID, nil
+}
+
+// Parses units like Self, Target:1 or Pet:0 of Player:1.
+func (parser *aplTextParser) parseUnitReference() (*proto.UnitReference, error) {
+	tok := parser.next()
+	unitType, ok := proto.UnitReference_Type_value[tok.text]
+	if tok.kind != aplTokenIdent || !ok {
+		return nil, parser.errorf(tok, "expected a unit, found %s", tok)
+	}
+	unit := &proto.UnitReference{Type: proto.UnitReference_Type(unitType)}
+
+	if parser.isSymbol(":") {
+		parser.next()
+		index, err := parser.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		unit.Index = int32(index)
+	}
+	if parser.isIdent("of") {
+		parser.next()
+		owner, err := parser.parseUnitReference()
+		if err != nil {
+			return nil, err
+		}
+		unit.Owner = owner
+	}
+	return unit, nil
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Formats an APL rotation in the text syntax parsed by ParseAPLText.
+func FormatAPLText(rotation *proto.APLRotation) string {
+	var sb strings.Builder
+
+	if rotation.Type != proto.APLRotation_TypeAPL {
+		sb.WriteString("type " + rotation.Type.String() + "\n")
+	}
+	if rotation.Simple != nil {
+		sb.WriteString("simple " + strconv.Quote(formatAPLSimpleRotation(rotation.Simple)) + "\n")
+	}
+	if sb.Len() > 0 && (len(rotation.PrepullActions) > 0 || len(rotation.PriorityList) > 0) {
+		sb.WriteString("\n")
+	}
+
+	for _, prepullAction := range rotation.PrepullActions {
+		if prepullAction.Hide {
+			sb.WriteString("hidden ")
+		}
+		sb.WriteString("prepull")
+		if prepullAction.DoAtValue != nil {
+			sb.WriteString("(" + formatAPLValue(prepullAction.DoAtValue, aplPrecedenceOr) + ")")
+		}
+		sb.WriteString(" " + formatAPLAction(prepullAction.Action) + "\n")
+	}
+
+	if len(rotation.PrepullActions) > 0 && len(rotation.PriorityList) > 0 {
+		sb.WriteString("\n")
+	}
+
+	formatAPLListItems(&sb, rotation.PriorityList)
+
+	for _, actionList := range rotation.ActionLists {
+		sb.WriteString("\nlist " + formatAPLName(actionList.Name) + "\n")
+		formatAPLListItems(&sb, actionList.Items)
+	}
+
+	return sb.String()
+}
+
+// Returns the simple rotation as compact json. protojson varies its whitespace
+// between runs, so the output is compacted to keep the text stable.
+func formatAPLSimpleRotation(simple *proto.SimpleRotation) string {
+	data, err := protojson.Marshal(simple)
+	if err != nil {
+		panic(err)
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, data); err != nil {
+		panic(err)
+	}
+	return compacted.String()
+}
+
+func formatAPLListItems(sb *strings.Builder, items []*proto.APLListItem) {
+	for _, item := range items {
+		if item.Notes != "" {
+			for _, line := range strings.Split(item.Notes, "\n") {
+				sb.WriteString("# " + line + "\n")
+			}
+		}
+		if item.Hide {
+			sb.WriteString("hidden ")
+		}
+		sb.WriteString(formatAPLAction(item.Action) + "\n")
+	}
+}
+
+var aplNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Names are written without quotes when possible.
+func formatAPLName(name string) string {
+	if aplNameRegex.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func formatAPLAction(action *proto.APLAction) string {
+	var text string
+	if fd := action.ProtoReflect().WhichOneof(aplActionOneof); fd != nil {
+		text = formatAPLCall(string(fd.Name()), action.ProtoReflect().Get(fd).Message())
+	} else {
+		text = "none"
+	}
+
+	if action.Condition != nil {
+		text += " if " + formatAPLValue(action.Condition, aplPrecedenceOr)
+	}
+	return text
+}
+
+// Binding strength of operators, used to only add the parentheses which are
+// needed to parse a value back into the same structure.
+const (
+	aplPrecedenceOr = iota
+	aplPrecedenceAnd
+	aplPrecedenceCompare
+	aplPrecedenceAdd
+	aplPrecedenceMul
+	aplPrecedenceUnary
+)
+
+var aplCompareSymbols = map[proto.APLValueCompare_ComparisonOperator]string{}
+var aplMathSymbols = map[proto.APLValueMath_MathOperator]string{}
+
+func init() {
+	for symbol, op := range aplCompareOps {
+		aplCompareSymbols[op] = symbol
+	}
+	for symbol, op := range aplMathOps {
+		aplMathSymbols[op] = symbol
+	}
+}
+
+// Formats a value which is an operand of an operator with the given precedence.
+func formatAPLValue(val *proto.APLValue, minPrecedence int) string {
+	text, precedence := formatAPLOperator(val)
+	if precedence < minPrecedence {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// Returns the text of a value, and the precedence of its outermost operator.
+func formatAPLOperator(val *proto.APLValue) (string, int) {
+	switch v := val.Value.(type) {
+	case *proto.APLValue_Or:
+		if len(v.Or.Vals) >= 2 {
+			return formatAPLOperands(v.Or.Vals, " || ", aplPrecedenceAnd), aplPrecedenceOr
+		}
+	case *proto.APLValue_And:
+		if len(v.And.Vals) >= 2 {
+			return formatAPLOperands(v.And.Vals, " && ", aplPrecedenceCompare), aplPrecedenceAnd
+		}
+	case *proto.APLValue_Cmp:
+		if symbol, ok := aplCompareSymbols[v.Cmp.Op]; ok && v.Cmp.Lhs != nil && v.Cmp.Rhs != nil {
+			return formatAPLValue(v.Cmp.Lhs, aplPrecedenceAdd) + " " + symbol + " " + formatAPLValue(v.Cmp.Rhs, aplPrecedenceAdd), aplPrecedenceCompare
+		}
+	case *proto.APLValue_Math:
+		if symbol, ok := aplMathSymbols[v.Math.Op]; ok && v.Math.Lhs != nil && v.Math.Rhs != nil {
+			precedence := aplPrecedenceAdd
+			if symbol == "*" || symbol == "/" {
+				precedence = aplPrecedenceMul
+			}
+			// Math is left-associative, so right operands need parentheses at
+			// the same precedence.
+			return formatAPLValue(v.Math.Lhs, precedence) + " " + symbol + " " + formatAPLValue(v.Math.Rhs, precedence+1), precedence
+		}
+	case *proto.APLValue_Not:
+		if v.Not.Val != nil {
+			return "!" + formatAPLValue(v.Not.Val, aplPrecedenceUnary), aplPrecedenceUnary
+		}
+	case *proto.APLValue_Const:
+		return formatAPLConst(v.Const.Val), aplPrecedenceUnary + 1
+	case nil:
+		return "none", aplPrecedenceUnary + 1
+	}
+
+	fd := val.ProtoReflect().WhichOneof(aplValueOneof)
+	return formatAPLCall(string(fd.Name()), val.ProtoReflect().Get(fd).Message()), aplPrecedenceUnary + 1
+}
+
+// Operands of the same variadic operator are wrapped in parentheses, so they
+// stay nested when parsed.
+func formatAPLOperands(vals []*proto.APLValue, separator string, minPrecedence int) string {
+	texts := make([]string, len(vals))
+	for i, val := range vals {
+		texts[i] = formatAPLValue(val, minPrecedence)
+	}
+	return strings.Join(texts, separator)
+}
+
+var aplNumberRegex = regexp.MustCompile(`^-?(\d+\.?\d*|\.\d+)[a-zA-Z%]*$`)
+
+// Constants which wouldn't parse as a number, like 5q, are quoted so they stay
+// strings.
+func formatAPLConst(val string) string {
+	if val == "true" || val == "false" || (aplNumberRegex.MatchString(val) && isValidAPLNumber(val)) {
+		return val
+	}
+	return strconv.Quote(val)
+}
+
+// Formats an action or value as a call of its oneof field name. Fields are
+// positional up to the first unset one, and named after it.
+func formatAPLCall(name string, msg protoreflect.Message) string {
+	fields := aplArgFields(msg.Descriptor())
+	var args []string
+	if isAPLVariadic(fields) {
+		list := msg.Get(fields[0]).List()
+		for i := 0; i < list.Len(); i++ {
+			args = append(args, formatAPLSingular(fields[0], list.Get(i)))
+		}
+	} else {
+		positional := true
+		for _, fd := range fields {
+			if !msg.Has(fd) {
+				positional = false
+				continue
+			}
+			arg := formatAPLField(fd, msg.Get(fd))
+			if !positional {
+				arg = string(fd.Name()) + "=" + arg
+			}
+			args = append(args, arg)
+		}
+	}
+
+	if len(args) == 0 {
+		return name
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func formatAPLField(fd protoreflect.FieldDescriptor, val protoreflect.Value) string {
+	if !fd.IsList() {
+		return formatAPLSingular(fd, val)
+	}
+
+	list := val.List()
+	elems := make([]string, list.Len())
+	for i := range elems {
+		elems[i] = formatAPLSingular(fd, list.Get(i))
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+func formatAPLSingular(fd protoreflect.FieldDescriptor, val protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		switch msg := val.Message().Interface().(type) {
+		case *proto.APLValue:
+			return formatAPLValue(msg, aplPrecedenceOr)
+		case *proto.APLAction:
+			return formatAPLAction(msg)
+		case *proto.ActionID:
+			return formatAPLActionID(msg)
+		case *proto.UnitReference:
+			return formatAPLUnitReference(msg)
+		}
+	case protoreflect.EnumKind:
+		if enumValue := fd.Enum().Values().ByNumber(val.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+	case protoreflect.StringKind:
+		return formatAPLName(val.String())
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(val.Float(), 'f', -1, 64)
+	}
+	return val.String()
+}
+
+func formatAPLActionID(actionID *proto.ActionID) string {
+	var text string
+	switch id := actionID.RawId.(type) {
+	case *proto.ActionID_SpellId:
+		text = strconv.Itoa(int(id.SpellId))
+	case *proto.ActionID_ItemId:
+		text = "item:" + strconv.Itoa(int(id.ItemId))
+	case *proto.ActionID_OtherId:
+		text = "other:" + id.OtherId.String()
+	default:
+		text = "none"
+	}
+
+	if actionID.Tag != 0 {
+		text += ":" + strconv.Itoa(int(actionID.Tag))
+	}
+	return text
+}
+
+func formatAPLUnitReference(unit *proto.UnitReference) string {
+	text := unit.Type.String()
+	if unit.Index != 0 {
+		text += ":" + strconv.Itoa(int(unit.Index))
+	}
+	if unit.Owner != nil {
+		text += " of " + formatAPLUnitReference(unit.Owner)
+	}
+	return text
+}
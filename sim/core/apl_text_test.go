@@ -0,0 +1,172 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	goproto "google.golang.org/protobuf/proto"
+)
+
+func TestParseAPLText(t *testing.T) {
+	rotation, err := ParseAPLText(`hidden prepull cast_spell(1)
+prepull(-1.5s) cast_spell(item:58091)
+
+# Keep Moonfire up.
+cast_spell(8921) if !dot_is_active(8921) || dot_remaining_time(8921) < 2s
+hidden cast_spell(2825:-1) if (current_mana_percent > 20% && is_execute_phase(E20))
+    || aura_is_active(48517, Target:1)
+strict_sequence(
+	cast_spell(78674),
+	cast_spell(other:OtherActionPotion),
+)
+`)
+	if err != nil {
+		t.Fatalf("failed to parse APL: %s", err)
+	}
+
+	expected := &proto.APLRotation{}
+	if err := protojson.Unmarshal([]byte(`{
+		"type": "TypeAPL",
+		"prepullActions": [
+			{"action": {"castSpell": {"spellId": {"spellId": 1}}}, "hide": true},
+			{"action": {"castSpell": {"spellId": {"itemId": 58091}}}, "doAtValue": {"const": {"val": "-1.5s"}}}
+		],
+		"priorityList": [
+			{"notes": "Keep Moonfire up.", "action": {"condition": {"or": {"vals": [
+				{"not": {"val": {"dotIsActive": {"spellId": {"spellId": 8921}}}}},
+				{"cmp": {"op": "OpLt", "lhs": {"dotRemainingTime": {"spellId": {"spellId": 8921}}}, "rhs": {"const": {"val": "2s"}}}}
+			]}}, "castSpell": {"spellId": {"spellId": 8921}}}},
+			{"hide": true, "action": {"condition": {"or": {"vals": [
+				{"and": {"vals": [
+					{"cmp": {"op": "OpGt", "lhs": {"currentManaPercent": {}}, "rhs": {"const": {"val": "20%"}}}},
+					{"isExecutePhase": {"threshold": "E20"}}
+				]}},
+				{"auraIsActive": {"auraId": {"spellId": 48517}, "sourceUnit": {"type": "Target", "index": 1}}}
+			]}}, "castSpell": {"spellId": {"spellId": 2825, "tag": -1}}}},
+			{"action": {"strictSequence": {"actions": [
+				{"castSpell": {"spellId": {"spellId": 78674}}},
+				{"castSpell": {"spellId": {"otherId": "OtherActionPotion"}}}
+			]}}}
+		]
+	}`), expected); err != nil {
+		t.Fatalf("failed to unmarshal expected APL: %s", err)
+	}
+
+	if !goproto.Equal(rotation, expected) {
+		t.Fatalf("unexpected APL:\n%v\nexpected:\n%v", rotation, expected)
+	}
+}
+
+func TestParseAPLTextErrors(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{"cast_spell(1) if foo", "1:18: unknown value foo"},
+		{"cast_spell(1)\nfoo(2)", "2:1: unknown action foo"},
+		{"cast_spell(1) if 1 < 2\n  < 3", "2:3: comparisons can't be chained, use parentheses"},
+		{"cast_spell(1,\n  Self, 3)", "2:9: too many arguments for APLActionCastSpell"},
+		{"cast_spell(1) if is_execute_phase(E99)", "1:35: expected a value of ExecutePhaseThreshold, found 'E99'"},
+		{"cast_spell(1) cast_spell(2)", "1:15: expected end of line, found 'cast_spell'"},
+		{"cast_spell(1) if \"abc", "1:18: unterminated string"},
+		{"cast_spell(1) if current_time > 5q", "1:33: invalid number 5q"},
+		{"cast_spell(1) if 1.2.3 > 1", "1:18: invalid number 1.2.3"},
+		{"cast_spell(1) if current_time == 99999999999999999999", "1:34: invalid number 99999999999999999999"},
+		{"cast_spell(1) if current_time > -5q", "1:33: invalid number -5q"},
+		{"type Foo", "1:6: expected a rotation type, found 'Foo'"},
+		{"type TypeAPL\ntype TypeSimple", "2:1: type is set twice"},
+		{"simple 1", "1:8: expected the simple rotation as a json string, found '1'"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseAPLText(testCase.text)
+		if err == nil || err.Error() != testCase.expected {
+			t.Errorf("parsing %q: expected error %q, got %v", testCase.text, testCase.expected, err)
+		}
+	}
+}
+
+// Every APL in the UI should survive formatting and parsing unchanged.
+func TestAPLTextRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../../ui/*/*/apls/*.apl.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("failed to find APL files: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %s", file, err)
+		}
+		expected := &proto.APLRotation{}
+		if err := protojson.Unmarshal(data, expected); err != nil {
+			t.Fatalf("failed to unmarshal %s: %s", file, err)
+		}
+		text := FormatAPLText(expected)
+		rotation, err := ParseAPLText(text)
+		if err != nil {
+			t.Fatalf("failed to parse formatted %s: %s\n%s", file, err, text)
+		}
+		if !goproto.Equal(rotation, expected) {
+			t.Fatalf("%s changed after formatting and parsing:\n%s\n%v\nexpected:\n%v", file, text, rotation, expected)
+		}
+		if formatted := FormatAPLText(rotation); formatted != text {
+			t.Fatalf("formatting %s isn't stable:\n%s\nthen:\n%s", file, text, formatted)
+		}
+	}
+}
+
+func TestAPLTextHeader(t *testing.T) {
+	rotation, err := ParseAPLText(`type TypeSimple
+simple "{\"cooldowns\":{\"hpPercentForDefensives\":0.5}}"
+
+cast_spell(1)
+`)
+	if err != nil {
+		t.Fatalf("failed to parse APL: %s", err)
+	}
+	if rotation.Type != proto.APLRotation_TypeSimple {
+		t.Fatalf("expected a simple rotation, got %s", rotation.Type)
+	}
+	if hpPercent := rotation.GetSimple().GetCooldowns().GetHpPercentForDefensives(); hpPercent != 0.5 {
+		t.Fatalf("expected the defensives threshold to be kept, got %f", hpPercent)
+	}
+	if len(rotation.PriorityList) != 1 {
+		t.Fatalf("expected 1 priority list item, got %d", len(rotation.PriorityList))
+	}
+}
+
+func TestAPLTextActionLists(t *testing.T) {
+	text := `call_list(cooldowns)
+run_list("single target") if current_time > 5s
+
+list cooldowns
+# Lust first.
+cast_spell(2825:-1)
+
+list "single target"
+cast_spell(78674)
+`
+	rotation, err := ParseAPLText(text)
+	if err != nil {
+		t.Fatalf("failed to parse APL: %s", err)
+	}
+
+	if len(rotation.PriorityList) != 2 || len(rotation.ActionLists) != 2 {
+		t.Fatalf("expected 2 priority list items and 2 action lists, got %v", rotation)
+	}
+	if name := rotation.PriorityList[1].Action.GetRunList().GetName(); name != "single target" {
+		t.Fatalf("expected a run list action, got %v", rotation.PriorityList[1].Action)
+	}
+	cooldowns := rotation.ActionLists[0]
+	if cooldowns.Name != "cooldowns" || len(cooldowns.Items) != 1 || cooldowns.Items[0].Notes != "Lust first." {
+		t.Fatalf("unexpected cooldowns list: %v", cooldowns)
+	}
+
+	if formatted := FormatAPLText(rotation); formatted != text {
+		t.Fatalf("formatting changed the APL:\n%s\nexpected:\n%s", formatted, text)
+	}
+}
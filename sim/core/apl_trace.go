@@ -0,0 +1,78 @@
+package core
+
+import (
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Like getNextAction, but records the decision in the APL trace.
+func (rot *APLRotation) getNextTracedAction(sim *Simulation) *APLAction {
+	decision := &proto.APLTraceDecision{
+		Timestamp: sim.CurrentTime.Seconds(),
+		Unit:      rot.unit.Label,
+	}
+	if len(rot.controllingActions) != 0 {
+		decision.ControllingAction = rot.controllingActions[len(rot.controllingActions)-1].String()
+	}
+
+	rot.curDecision = decision
+	nextAction := rot.getNextAction(sim)
+	rot.curDecision = nil
+
+	if nextAction != nil {
+		decision.Action = nextAction.impl.String()
+	}
+	sim.aplTrace = append(sim.aplTrace, decision)
+	return nextAction
+}
+
+// Adds an item of the given list to the decision being traced, or returns nil
+// when not tracing.
+func (rot *APLRotation) traceListItem(list *APLActionList, actionIdx int) *proto.APLTraceItem {
+	if rot.curDecision == nil {
+		return nil
+	}
+	item := &proto.APLTraceItem{
+		List:   list.name,
+		Index:  int32(list.actionConfigIdxs[actionIdx]),
+		Action: list.actions[actionIdx].impl.String(),
+	}
+	rot.curDecision.Items = append(rot.curDecision.Items, item)
+	return item
+}
+
+// Returns why an action whose condition is true can't be performed right now.
+func aplActionNotReadyReason(sim *Simulation, action *APLAction) string {
+	var reason string
+	switch impl := action.impl.(type) {
+	case *APLActionCastSpell:
+		reason = spellNotReadyReason(sim, impl.spell, impl.target.Get())
+	case *APLActionCastFriendlySpell:
+		reason = spellNotReadyReason(sim, impl.spell, impl.target.Get())
+	case *APLActionChannelSpell:
+		reason = spellNotReadyReason(sim, impl.spell, impl.target.Get())
+	case *APLActionMultidot:
+		reason = spellNotReadyReason(sim, impl.spell, impl.spell.Unit.CurrentTarget)
+	case *APLActionMultishield:
+		reason = spellNotReadyReason(sim, impl.spell, impl.spell.Unit)
+	case *APLActionCallList, *APLActionRunList:
+		reason = "no ready action in list"
+	}
+	if reason == "" {
+		reason = "not ready"
+	}
+	return reason
+}
+
+// Returns why the spell can't be cast on the target right now, or an empty
+// string if it can be.
+func spellNotReadyReason(sim *Simulation, spell *Spell, target *Unit) string {
+	if target != nil && target.IsImmuneToSpell(spell) {
+		return "target is immune"
+	}
+
+	// Tracing mustn't change the spell's state, so undo CanCast's cost update.
+	curCost := spell.CurCast.Cost
+	reason := spell.cantCastReason(sim, target)
+	spell.CurCast.Cost = curCost
+	return reason
+}
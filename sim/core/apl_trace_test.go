@@ -0,0 +1,112 @@
+package core_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Returns the traced decisions of the test player.
+func runAPLTraceTest(t *testing.T, rsr *proto.RaidSimRequest) []*proto.APLTraceDecision {
+	rsr.SimOptions.AplTrace = true
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	playerLabel := fmt.Sprintf("%s (#1)", rsr.Raid.Parties[0].Players[0].Name)
+	trace := core.FilterSlice(result.AplTrace, func(decision *proto.APLTraceDecision) bool { return decision.Unit == playerLabel })
+	if len(trace) == 0 {
+		t.Fatalf("expected an APL trace for %s", playerLabel)
+	}
+	return trace
+}
+
+// Returns the reasons given for the item at the index of the priority list.
+func aplTraceReasons(trace []*proto.APLTraceDecision, index int32) map[string]int {
+	reasons := make(map[string]int)
+	for _, decision := range trace {
+		for _, item := range decision.Items {
+			if item.List == "" && item.Index == index && item.Reason != "" {
+				reasons[item.Reason]++
+			}
+		}
+	}
+	return reasons
+}
+
+func TestAPLTrace(t *testing.T) {
+	trace := runAPLTraceTest(t, makeAPLTestCase(t, `
+cast_spell(3044) if current_focus >= 200
+cast_spell(56641)
+`))
+
+	steadyShots := 0
+	for i, decision := range trace {
+		if i > 0 && decision.Timestamp < trace[i-1].Timestamp {
+			t.Fatalf("expected only the first iteration in the trace, time went from %f to %f", trace[i-1].Timestamp, decision.Timestamp)
+		}
+		if decision.Unit == "" || len(decision.Items) == 0 {
+			t.Fatalf("expected a unit and evaluated items in every decision, got %v", decision)
+		}
+		if first := decision.Items[0]; first.Index != 0 || first.Condition || first.Ready {
+			t.Fatalf("expected the Arcane Shot condition to be false, got %v", first)
+		}
+		if decision.Action != "" {
+			last := decision.Items[len(decision.Items)-1]
+			if last.Index != 1 || !last.Condition || !last.Ready || last.Action != decision.Action {
+				t.Fatalf("expected the performed action to be the last ready item, got %v", decision)
+			}
+			steadyShots++
+		}
+	}
+	if steadyShots == 0 {
+		t.Fatalf("expected Steady Shot to be performed in the trace")
+	}
+
+	if reasons := aplTraceReasons(trace, 1); reasons["already casting"] == 0 {
+		t.Fatalf("expected Steady Shot to wait for its own casts, got %v", reasons)
+	}
+}
+
+func TestAPLTraceReasons(t *testing.T) {
+	rsr := makeAPLTestCase(t, `
+cast_spell(3044)
+cast_spell(56641)
+`)
+	if reasons := aplTraceReasons(runAPLTraceTest(t, rsr), 0); reasons["can't afford"] == 0 || reasons["blocked by GCD"] == 0 {
+		t.Fatalf("expected Arcane Shot to be unaffordable or blocked by the GCD at times, got %v", reasons)
+	}
+
+	rsr.Raid.Parties[0].Players[0].DistanceFromTarget = 0
+	if reasons := aplTraceReasons(runAPLTraceTest(t, rsr), 1); reasons["out of range"] == 0 {
+		t.Fatalf("expected Steady Shot to be out of range in melee, got %v", reasons)
+	}
+}
+
+func TestAPLTraceDisabled(t *testing.T) {
+	result := core.RunRaidSim(makeAPLTestCase(t, `cast_spell(56641)`))
+	if len(result.AplTrace) != 0 {
+		t.Fatalf("expected no APL trace unless requested, got %d decisions", len(result.AplTrace))
+	}
+}
+
+func TestAPLTraceHasNoSideEffects(t *testing.T) {
+	const aplText = `
+cast_spell(3044)
+cast_spell(56641)
+`
+	untraced := core.RunRaidSim(makeAPLTestCase(t, aplText))
+
+	rsr := makeAPLTestCase(t, aplText)
+	rsr.SimOptions.AplTrace = true
+	traced := core.RunRaidSim(rsr)
+	if traced.ErrorResult != "" || untraced.ErrorResult != "" {
+		t.Fatalf("sim failed: %s%s", traced.ErrorResult, untraced.ErrorResult)
+	}
+	if tracedDps, untracedDps := traced.RaidMetrics.Dps.Avg, untraced.RaidMetrics.Dps.Avg; tracedDps != untracedDps {
+		t.Fatalf("expected tracing not to change the results, got %0.2f DPS with the trace and %0.2f without", tracedDps, untracedDps)
+	}
+}
@@ -93,6 +93,8 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 		return rot.newValueIsExecutePhase(config.GetIsExecutePhase())
 	case *proto.APLValue_NumberTargets:
 		return rot.newValueNumberTargets(config.GetNumberTargets())
+	case *proto.APLValue_TargetHealthReached:
+		return rot.newValueTargetHealthReached(config.GetTargetHealthReached())
 
 	// Boss
 	case *proto.APLValue_BossSpellIsCasting:
@@ -206,6 +208,10 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 	case *proto.APLValue_SequenceTimeToReady:
 		return rot.newValueSequenceTimeToReady(config.GetSequenceTimeToReady())
 
+	// Variable values
+	case *proto.APLValue_Variable:
+		return rot.newValueVariable(config.GetVariable())
+
 	// Properties
 	case *proto.APLValue_ChannelClipDelay:
 		return rot.newValueChannelClipDelay(config.GetChannelClipDelay())
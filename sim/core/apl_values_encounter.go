@@ -124,3 +124,50 @@ func (value *APLValueIsExecutePhase) GetBool(sim *Simulation) bool {
 func (value *APLValueIsExecutePhase) String() string {
 	return "Is Execute Phase"
 }
+
+type APLValueTargetHealthReached struct {
+	DefaultAPLValueImpl
+	targetUnit UnitReference
+	fraction   float64
+}
+
+func (rot *APLRotation) newValueTargetHealthReached(config *proto.APLValueTargetHealthReached) APLValue {
+	targetUnit := rot.GetTargetUnit(config.TargetUnit)
+	if targetUnit.Get() == nil {
+		return nil
+	}
+	if config.HealthPercent <= 0 || config.HealthPercent > 100 {
+		rot.ValidationWarning("Health threshold must be between 0 and 100%%, got %0.1f%%", config.HealthPercent)
+		return nil
+	}
+	fraction := config.HealthPercent / 100
+
+	// Re-evaluate the rotation as soon as a target drops to the threshold,
+	// instead of waiting for the next scheduled action.
+	unit := rot.unit
+	for _, target := range unit.Env.Encounter.Targets {
+		target.OnHealthPercent(fraction, func(sim *Simulation) {
+			if unit.IsEnabled() && !sim.Options.Interactive {
+				unit.ReactToEvent(sim)
+			}
+		})
+	}
+
+	return &APLValueTargetHealthReached{
+		targetUnit: targetUnit,
+		fraction:   fraction,
+	}
+}
+func (value *APLValueTargetHealthReached) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeBool
+}
+func (value *APLValueTargetHealthReached) GetBool(sim *Simulation) bool {
+	target := value.targetUnit.Get()
+	if target.Type != EnemyUnit {
+		return false
+	}
+	return sim.Encounter.Targets[target.Index].HealthPercent() <= value.fraction
+}
+func (value *APLValueTargetHealthReached) String() string {
+	return fmt.Sprintf("Target Health Reached(%0.1f%%)", value.fraction*100)
+}
@@ -0,0 +1,34 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+func TestAPLTargetHealthReached(t *testing.T) {
+	arcaneShots := func(health float64) int32 {
+		rsr := makeAPLTestCase(t, `
+cast_spell(3044) if target_health_reached(CurrentTarget, 50)
+cast_spell(56641)
+`)
+		rsr.Encounter.Targets[0].Stats[stats.Health] = health
+		result := core.RunRaidSim(rsr)
+		if result.ErrorResult != "" {
+			t.Fatalf("sim failed: %s", result.ErrorResult)
+		}
+		return playerActionMetrics(result, testArcaneShot).Casts
+	}
+
+	// The target drops to half health around the middle of the fight.
+	if casts := arcaneShots(3_000_000); casts == 0 {
+		t.Fatalf("expected Arcane Shot casts once the target is below half health")
+	}
+	if casts := arcaneShots(100_000_000); casts != 0 {
+		t.Fatalf("expected no Arcane Shot casts while the target is above half health, got %d", casts)
+	}
+	if casts := arcaneShots(0); casts != 0 {
+		t.Fatalf("expected no Arcane Shot casts against a target without health, got %d", casts)
+	}
+}
@@ -16,7 +16,7 @@ func (rot *APLRotation) newValueCurrentHealth(config *proto.APLValueCurrentHealt
 	if unit.Get() == nil {
 		return nil
 	}
-	if !unit.Get().HasHealthBar() {
+	if !unit.Get().HasHealthBar() && unit.Get().Type != EnemyUnit {
 		rot.ValidationWarning("%s does not use Health", unit.Get().Label)
 		return nil
 	}
@@ -28,7 +28,11 @@ func (value *APLValueCurrentHealth) Type() proto.APLValueType {
 	return proto.APLValueType_ValueTypeFloat
 }
 func (value *APLValueCurrentHealth) GetFloat(sim *Simulation) float64 {
-	return value.unit.Get().CurrentHealth()
+	unit := value.unit.Get()
+	if unit.Type == EnemyUnit {
+		return sim.Encounter.Targets[unit.Index].RemainingHealth()
+	}
+	return unit.CurrentHealth()
 }
 func (value *APLValueCurrentHealth) String() string {
 	return "Current Health"
@@ -44,7 +48,7 @@ func (rot *APLRotation) newValueCurrentHealthPercent(config *proto.APLValueCurre
 	if unit.Get() == nil {
 		return nil
 	}
-	if !unit.Get().HasHealthBar() {
+	if !unit.Get().HasHealthBar() && unit.Get().Type != EnemyUnit {
 		rot.ValidationWarning("%s does not use Health", unit.Get().Label)
 		return nil
 	}
@@ -56,7 +60,11 @@ func (value *APLValueCurrentHealthPercent) Type() proto.APLValueType {
 	return proto.APLValueType_ValueTypeFloat
 }
 func (value *APLValueCurrentHealthPercent) GetFloat(sim *Simulation) float64 {
-	return value.unit.Get().CurrentHealthPercent()
+	unit := value.unit.Get()
+	if unit.Type == EnemyUnit {
+		return sim.Encounter.Targets[unit.Index].HealthPercent()
+	}
+	return unit.CurrentHealthPercent()
 }
 func (value *APLValueCurrentHealthPercent) String() string {
 	return fmt.Sprintf("Current Health %%")
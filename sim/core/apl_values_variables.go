@@ -0,0 +1,48 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+type APLValueVariable struct {
+	DefaultAPLValueImpl
+	variable *APLVariable
+}
+
+func (rot *APLRotation) newValueVariable(config *proto.APLValueVariable) APLValue {
+	if config.Name == "" {
+		rot.ValidationWarning("Variable must provide a variable name")
+		return nil
+	}
+	variable := rot.getVariable(config.Name)
+	if variable == nil {
+		return nil
+	}
+	return &APLValueVariable{
+		variable: variable,
+	}
+}
+func (value *APLValueVariable) Type() proto.APLValueType {
+	return value.variable.valueType
+}
+func (value *APLValueVariable) GetBool(sim *Simulation) bool {
+	return value.variable.boolVal
+}
+func (value *APLValueVariable) GetInt(sim *Simulation) int32 {
+	return value.variable.intVal
+}
+func (value *APLValueVariable) GetFloat(sim *Simulation) float64 {
+	return value.variable.floatVal
+}
+func (value *APLValueVariable) GetDuration(sim *Simulation) time.Duration {
+	return value.variable.durationVal
+}
+func (value *APLValueVariable) GetString(sim *Simulation) string {
+	return value.variable.stringVal
+}
+func (value *APLValueVariable) String() string {
+	return fmt.Sprintf("Variable(%s)", value.variable.name)
+}
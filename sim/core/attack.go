@@ -309,7 +309,9 @@ func (wa *WeaponAttack) swing(sim *Simulation) time.Duration {
 	// Update swing timer BEFORE the cast, so that APL checks for TimeToNextAuto behave correctly
 	// if the attack causes APL evaluations (e.g. from rage gain).
 	wa.swingAt = sim.CurrentTime + wa.curSwingDuration
-	attackSpell.Cast(sim, wa.unit.CurrentTarget)
+	if !wa.unit.CurrentTarget.isDespawned() {
+		attackSpell.Cast(sim, wa.unit.CurrentTarget)
+	}
 
 	if !sim.Options.Interactive && wa.unit.Rotation != nil {
 		wa.unit.ReactToEvent(sim)
@@ -584,7 +586,8 @@ func (aa *AutoAttacks) startPull(sim *Simulation) {
 }
 
 func (wa *WeaponAttack) IsInRange() bool {
-	return (wa.MinRange == 0. || wa.MinRange < wa.unit.DistanceFromTarget) && (wa.MaxRange == 0. || wa.MaxRange >= wa.unit.DistanceFromTarget)
+	distance := wa.unit.DistanceFromTarget()
+	return (wa.MinRange == 0. || wa.MinRange < distance) && (wa.MaxRange == 0. || wa.MaxRange+rangeTolerance >= distance)
 }
 
 // Stops the auto swing action for the rest of the iteration. Used for pets
@@ -52,6 +52,12 @@ type Aura struct {
 
 	Duration time.Duration // Duration of aura, upon being applied.
 
+	// Whether offensive dispels such as Purge can remove this aura.
+	Dispellable bool
+
+	// Bleed, Slow, etc. Units immune to the mechanic never gain this aura.
+	Mechanic SpellMechanic
+
 	startTime time.Duration // Time at which the aura was applied.
 	expires   time.Duration // Time at which aura will be removed.
 
@@ -535,14 +541,25 @@ restart:
 
 // Adds a new aura to the simulation. If an aura with the same ID already
 // exists it will be replaced with the new one.
-func (aura *Aura) Activate(sim *Simulation) {
+//
+// Returns whether the aura is active afterwards. It isn't if the unit is immune
+// to it or a stronger exclusive effect blocks it, so callers which go on to set
+// stacks must check the result.
+func (aura *Aura) Activate(sim *Simulation) bool {
+	if aura.Unit.IsImmuneToAura(aura) {
+		if sim.Log != nil && !aura.ActionID.IsEmptyAction() {
+			aura.Unit.Log(sim, "Immune to aura: %s", aura.ActionID)
+		}
+		return false
+	}
+
 	aura.metrics.Procs++
 	if aura.IsActive() {
 		if sim.Log != nil && !aura.ActionID.IsEmptyAction() {
 			aura.Unit.Log(sim, "Aura refreshed: %s", aura.ActionID)
 		}
 		aura.Refresh(sim)
-		return
+		return true
 	}
 
 	if aura.Duration == 0 {
@@ -560,7 +577,7 @@ func (aura *Aura) Activate(sim *Simulation) {
 					aura.ExclusiveEffects[j].Deactivate(sim)
 				}
 			}
-			return
+			return false
 		}
 	}
 
@@ -631,6 +648,7 @@ func (aura *Aura) Activate(sim *Simulation) {
 	if aura.OnGain != nil {
 		aura.OnGain(aura, sim)
 	}
+	return true
 }
 
 // Remove an aura by its ID
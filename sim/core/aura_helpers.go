@@ -285,8 +285,7 @@ func ApplyFixedUptimeAura(aura *Aura, uptime float64, tickLength time.Duration,
 			Period: tickLength,
 			OnAction: func(sim *Simulation) {
 				if sim.RandomFloat("FixedAura") < chancePerTick {
-					aura.Activate(sim)
-					if aura.MaxStacks > 0 {
+					if aura.Activate(sim) && aura.MaxStacks > 0 {
 						aura.AddStack(sim)
 					}
 				}
@@ -19,10 +19,13 @@ import (
 
 const (
 	defaultIterationsPerCombo = 1000
+
+	// TODO(Riotdog-GehennasEU): Make this configurable?
+	maxBulkResults = 30
 )
 
 // raidSimRunner runs a standard raid simulation.
-type raidSimRunner func(*proto.RaidSimRequest, chan *proto.ProgressMetrics, bool, chan bool) *proto.RaidSimResult
+type raidSimRunner func(context.Context, *proto.RaidSimRequest, chan *proto.ProgressMetrics, bool) *proto.RaidSimResult
 
 // bulkSimRunner runs a bulk simulation.
 type bulkSimRunner struct {
@@ -72,7 +75,14 @@ func (b *bulkSimRunner) Run(pctx context.Context, progress chan *proto.ProgressM
 		cancel()
 	}()
 
-	// Bulk simming is only supported for the single-player use (i.e. not whole raid-wide simming).
+	if b.Request.BulkSettings.GetGearOptimizer().GetEnabled() && b.Request.BulkSettings.GetTalentSearch().GetEnabled() {
+		return nil, errors.New("bulksim: the gear optimizer and talent search can't be enabled together")
+	}
+	if len(b.Request.BulkSettings.GetRaidItems()) > 0 {
+		return b.runRaidWide(ctx, progress)
+	}
+
+	// Without raid items, bulk simming is only supported for the single-player use.
 	// Verify that we have exactly 1 player.
 	var playerCount int
 	var player *proto.Player
@@ -96,56 +106,17 @@ func (b *bulkSimRunner) Run(pctx context.Context, progress chan *proto.ProgressM
 	// clean to reduce memory
 	player.Database = nil
 
+	if b.Request.BulkSettings.GetGearOptimizer().GetEnabled() {
+		return b.optimizeGear(ctx, player, progress)
+	}
+	if b.Request.BulkSettings.GetTalentSearch().GetEnabled() {
+		return b.searchTalents(ctx, player, progress)
+	}
+
 	// Gemming for now can happen before slots are decided.
 	// We might have to add logic after slot decisions if we want to enforce keeping meta gem active.
-
 	if b.Request.BulkSettings.AutoGem {
-		for _, replaceItem := range b.Request.BulkSettings.Items {
-			itemData := ItemsByID[replaceItem.Id]
-			if len(itemData.GemSockets) == 0 && itemData.Type != proto.ItemType_ItemTypeWaist {
-				continue
-			}
-
-			sockets := make([]int32, len(itemData.GemSockets))
-			if len(sockets) < len(replaceItem.Gems) {
-				// this means the extra gem was specified, just add an extra element
-				sockets = append(sockets, 0)
-			}
-			// now copy over what we have from inputs.
-			copy(sockets, replaceItem.Gems)
-			if itemData.Type == proto.ItemType_ItemTypeWaist {
-				// Assume waist always has the eternal belt buckle and add extra red gem.
-				// TODO: is there a better way to do this?
-				// Should we have a 'prismatic' standard gem in the defaults?
-				if len(sockets) == len(itemData.GemSockets) {
-					sockets = append(sockets, b.Request.BulkSettings.DefaultRedGem)
-				} else if len(sockets) > len(itemData.GemSockets) && sockets[len(sockets)-1] == 0 {
-					sockets[len(sockets)-1] = b.Request.BulkSettings.DefaultRedGem
-				}
-			}
-
-			for i, color := range itemData.GemSockets {
-				if sockets[i] > 0 {
-					// This means gem was already specified, skip autogem
-					continue
-				}
-				if ColorIntersects(color, proto.GemColor_GemColorRed) {
-					sockets[i] = b.Request.BulkSettings.DefaultRedGem
-				} else if ColorIntersects(color, proto.GemColor_GemColorYellow) {
-					sockets[i] = b.Request.BulkSettings.DefaultYellowGem
-				} else if ColorIntersects(color, proto.GemColor_GemColorBlue) {
-					sockets[i] = b.Request.BulkSettings.DefaultBlueGem
-				} else if ColorIntersects(color, proto.GemColor_GemColorMeta) {
-					sockets[i] = b.Request.BulkSettings.DefaultMetaGem
-				}
-			}
-			replaceItem.Gems = sockets
-		}
-	}
-
-	iterations := b.Request.GetBulkSettings().GetIterationsPerCombo()
-	if iterations <= 0 {
-		iterations = defaultIterationsPerCombo
+		b.autoGemItems(b.Request.BulkSettings.Items)
 	}
 
 	items := b.Request.GetBulkSettings().GetItems()
@@ -154,128 +125,146 @@ func (b *bulkSimRunner) Run(pctx context.Context, progress chan *proto.ProgressM
 	// 	return nil, fmt.Errorf("too many items specified (%d > %d), not computationally feasible", numItems, maxItemCount)
 	// }
 
-	// Create all distinct combinations of (item, slot). For example, let's say the only item we
-	// want to bulk sim is a one-handed item that can be worn both as an off-hand or a main-hand weapon.
-	// For each slot, we will create one itemWithSlot pair, so (item, off-hand) and (item, main-hand).
-	// We verify later that we are not emitting any invalid equipment set.
-	var distinctItemSlotCombos []*itemWithSlot
-	for index, is := range items {
-		item, ok := ItemsByID[is.Id]
-		if !ok {
-			return nil, fmt.Errorf("unknown item with id %d in bulk settings", is.Id)
-		}
-		for _, slot := range eligibleSlotsForItem(item) {
-			distinctItemSlotCombos = append(distinctItemSlotCombos, &itemWithSlot{
-				Item:  is,
-				Slot:  slot,
-				Index: index,
-			})
-		}
+	distinctItemSlotCombos, err := getDistinctItemSlotCombos(items)
+	if err != nil {
+		return nil, err
 	}
 	baseItems := player.Equipment.Items
 
 	allCombos := generateAllEquipmentSubstitutions(ctx, baseItems, b.Request.BulkSettings.Combinations, distinctItemSlotCombos)
 
-	var validCombos []singleBulkSim
-	count := 0
-	for sub := range allCombos {
-		count++
-		if count > 1000000 {
-			panic("over 1 million combos, abandoning attempt")
-		}
-		substitutedRequest, changeLog := createNewRequestWithSubstitution(b.Request.BaseSettings, sub, b.Request.BulkSettings.AutoEnchant)
-		if isValidEquipment(substitutedRequest.Raid.Parties[0].Players[0].Equipment) {
-			// Need to sim base dps of gear loudout
-			validCombos = append(validCombos, singleBulkSim{req: substitutedRequest, cl: changeLog, eq: sub})
-			// Todo(Netzone-GehennasEU): Make this its own step?
-			if !b.Request.BulkSettings.SimTalents {
-
-			} else {
-				var talentsToSim = b.Request.BulkSettings.GetTalentsToSim()
+	rankedResults, baseResult, err := b.rankCombos(ctx, b.streamCombos(ctx, allCombos), progress)
+	if err != nil {
+		return nil, err
+	}
 
-				if len(talentsToSim) > 0 {
-					for _, talent := range talentsToSim {
-						sr := goproto.Clone(substitutedRequest).(*proto.RaidSimRequest)
-						cl := *changeLog
-						if sr.Raid.Parties[0].Players[0].TalentsString == talent.TalentsString && goproto.Equal(talent.Glyphs, sr.Raid.Parties[0].Players[0].Glyphs) {
-							continue
-						}
+	result = newBulkSimResult(rankedResults, baseResult, maxBulkResults)
 
-						sr.Raid.Parties[0].Players[0].TalentsString = talent.TalentsString
-						sr.Raid.Parties[0].Players[0].Glyphs = talent.Glyphs
-						cl.TalentLoadout = talent
-						validCombos = append(validCombos, singleBulkSim{req: sr, cl: &cl, eq: sub})
-					}
-				}
-			}
+	if progress != nil {
+		progress <- &proto.ProgressMetrics{
+			FinalBulkResult: result,
 		}
 	}
 
-	// TODO(Riotdog-GehennasEU): Make this configurable?
-	maxResults := 30
-
-	var rankedResults []*itemSubstitutionSimResult
-	var baseResult *itemSubstitutionSimResult
-	newIters := int64(iterations)
-	if b.Request.BulkSettings.FastMode {
-		newIters /= 100
+	return result, nil
+}
 
-		// In fast mode try to keep starting iterations between 50 and 1000.
-		if newIters < 50 {
-			newIters = 50
-		}
-		if newIters > 1000 {
-			newIters = 1000
+// streamCombos converts the equipment substitutions into the sims to run, skipping invalid
+// equipment and adding the talent loadouts to sim for every substitution.
+func (b *bulkSimRunner) streamCombos(ctx context.Context, substitutions chan *equipmentSubstitution) <-chan singleBulkSim {
+	combos := make(chan singleBulkSim)
+	go func() {
+		defer close(combos)
+		send := func(combo singleBulkSim) bool {
+			select {
+			case combos <- combo:
+				return true
+			case <-ctx.Done():
+				return false
+			}
 		}
-	}
-
-	maxIterations := newIters * int64(len(validCombos))
-	if maxIterations > math.MaxInt32 {
-		return nil, fmt.Errorf("number of total iterations %d too large", maxIterations)
-	}
 
-	for {
-		var tempBase *itemSubstitutionSimResult
-		var err error
-		// TODO: we could theoretically make getRankedResults accept a channel of validCombos that stream in to it and launches sims as it gets them...
-		rankedResults, tempBase, err = b.getRankedResults(ctx, validCombos, newIters, progress)
+		for sub := range substitutions {
+			substitutedRequest, changeLog := createNewRequestWithSubstitution(b.Request.BaseSettings, sub, b.Request.BulkSettings.AutoEnchant)
+			if !isValidEquipment(substitutedRequest.Raid.Parties[0].Players[0].Equipment) {
+				continue
+			}
+			// Need to sim base dps of gear loudout
+			if !send(singleBulkSim{req: substitutedRequest, cl: changeLog, eq: sub}) {
+				return
+			}
+			// Todo(Netzone-GehennasEU): Make this its own step?
+			if !b.Request.BulkSettings.SimTalents {
+				continue
+			}
+			for _, talent := range b.Request.BulkSettings.GetTalentsToSim() {
+				sr := goproto.Clone(substitutedRequest).(*proto.RaidSimRequest)
+				cl := *changeLog
+				if sr.Raid.Parties[0].Players[0].TalentsString == talent.TalentsString && goproto.Equal(talent.Glyphs, sr.Raid.Parties[0].Players[0].Glyphs) {
+					continue
+				}
 
-		if err != nil {
-			return nil, err
-		}
-		// keep replacing the base result with more refined base until we don't have base in the ranked results anymore.
-		if tempBase != nil {
-			baseResult = tempBase
+				sr.Raid.Parties[0].Players[0].TalentsString = talent.TalentsString
+				sr.Raid.Parties[0].Players[0].Glyphs = talent.Glyphs
+				cl.TalentLoadout = talent
+				if !send(singleBulkSim{req: sr, cl: &cl, eq: sub}) {
+					return
+				}
+			}
 		}
+	}()
+	return combos
+}
 
-		// If we aren't doing fast mode, or if halving our results will be less than the maxResults, be done.
-		if !b.Request.BulkSettings.FastMode || len(rankedResults) <= maxResults*2 {
-			break
+// autoGemItems fills the empty sockets of the given items with the default gems.
+func (b *bulkSimRunner) autoGemItems(items []*proto.ItemSpec) {
+	for _, replaceItem := range items {
+		itemData := ItemsByID[replaceItem.Id]
+		if len(itemData.GemSockets) == 0 && itemData.Type != proto.ItemType_ItemTypeWaist {
+			continue
 		}
 
-		// we have reached max accuracy now
-		if newIters >= int64(iterations) {
-			break
+		sockets := make([]int32, len(itemData.GemSockets))
+		if len(sockets) < len(replaceItem.Gems) {
+			// this means the extra gem was specified, just add an extra element
+			sockets = append(sockets, 0)
+		}
+		// now copy over what we have from inputs.
+		copy(sockets, replaceItem.Gems)
+		if itemData.Type == proto.ItemType_ItemTypeWaist {
+			// Assume waist always has the eternal belt buckle and add extra red gem.
+			// TODO: is there a better way to do this?
+			// Should we have a 'prismatic' standard gem in the defaults?
+			if len(sockets) == len(itemData.GemSockets) {
+				sockets = append(sockets, b.Request.BulkSettings.DefaultRedGem)
+			} else if len(sockets) > len(itemData.GemSockets) && sockets[len(sockets)-1] == 0 {
+				sockets[len(sockets)-1] = b.Request.BulkSettings.DefaultRedGem
+			}
 		}
 
-		// Increase accuracy
-		newIters *= 2
-		newNumCombos := len(rankedResults) / 2
-		validCombos = validCombos[:newNumCombos]
-		rankedResults = rankedResults[:newNumCombos]
-		for i, comb := range rankedResults {
-			validCombos[i] = singleBulkSim{
-				req: comb.Request,
-				cl:  comb.ChangeLog,
-				eq:  comb.Substitution,
+		for i, color := range itemData.GemSockets {
+			if sockets[i] > 0 {
+				// This means gem was already specified, skip autogem
+				continue
+			}
+			if ColorIntersects(color, proto.GemColor_GemColorRed) {
+				sockets[i] = b.Request.BulkSettings.DefaultRedGem
+			} else if ColorIntersects(color, proto.GemColor_GemColorYellow) {
+				sockets[i] = b.Request.BulkSettings.DefaultYellowGem
+			} else if ColorIntersects(color, proto.GemColor_GemColorBlue) {
+				sockets[i] = b.Request.BulkSettings.DefaultBlueGem
+			} else if ColorIntersects(color, proto.GemColor_GemColorMeta) {
+				sockets[i] = b.Request.BulkSettings.DefaultMetaGem
 			}
 		}
+		replaceItem.Gems = sockets
 	}
+}
 
-	if baseResult == nil {
-		return nil, fmt.Errorf("no base result for equipped gear found in bulk sim")
+// getDistinctItemSlotCombos creates all distinct combinations of (item, slot). For example, let's
+// say the only item we want to bulk sim is a one-handed item that can be worn both as an off-hand
+// or a main-hand weapon. For each slot, we will create one itemWithSlot pair, so (item, off-hand)
+// and (item, main-hand). We verify later that we are not emitting any invalid equipment set.
+func getDistinctItemSlotCombos(items []*proto.ItemSpec) ([]*itemWithSlot, error) {
+	var distinctItemSlotCombos []*itemWithSlot
+	for index, is := range items {
+		item, ok := ItemsByID[is.Id]
+		if !ok {
+			return nil, fmt.Errorf("unknown item with id %d in bulk settings", is.Id)
+		}
+		for _, slot := range eligibleSlotsForItem(item) {
+			distinctItemSlotCombos = append(distinctItemSlotCombos, &itemWithSlot{
+				Item:  is,
+				Slot:  slot,
+				Index: index,
+			})
+		}
 	}
+	return distinctItemSlotCombos, nil
+}
 
+// newBulkSimResult converts the best ranked results into the bulk sim result proto.
+func newBulkSimResult(rankedResults []*itemSubstitutionSimResult, baseResult *itemSubstitutionSimResult, maxResults int) *proto.BulkSimResult {
 	if len(rankedResults) > maxResults {
 		rankedResults = rankedResults[:maxResults]
 	}
@@ -286,9 +275,12 @@ func (b *bulkSimRunner) Run(pctx context.Context, progress chan *proto.ProgressM
 	bum.Resources = nil
 	bum.Pets = nil
 
-	result = &proto.BulkSimResult{
+	baseLower, baseUpper := baseResult.ConfidenceInterval()
+	result := &proto.BulkSimResult{
 		EquippedGearResult: &proto.BulkComboResult{
 			UnitMetrics: bum,
+			DpsLower:    baseLower,
+			DpsUpper:    baseUpper,
 		},
 	}
 
@@ -298,23 +290,83 @@ func (b *bulkSimRunner) Run(pctx context.Context, progress chan *proto.ProgressM
 		um.Auras = nil
 		um.Resources = nil
 		um.Pets = nil
+		lower, upper := r.ConfidenceInterval()
 		result.Results = append(result.Results, &proto.BulkComboResult{
 			ItemsAdded:    r.ChangeLog.AddedItems,
 			UnitMetrics:   um,
 			TalentLoadout: r.ChangeLog.TalentLoadout,
+			DpsLower:      lower,
+			DpsUpper:      upper,
 		})
 	}
 
-	if progress != nil {
-		progress <- &proto.ProgressMetrics{
-			FinalBulkResult: result,
+	return result
+}
+
+// getRankedResults sims all combos with the same number of iterations and sorts them by score.
+func (b *bulkSimRunner) getRankedResults(pctx context.Context, validCombos []singleBulkSim, iterations int64, progress chan *proto.ProgressMetrics) ([]*itemSubstitutionSimResult, *itemSubstitutionSimResult, error) {
+	ctx, cancel := context.WithCancel(pctx)
+	defer cancel() // cancel reporter
+
+	tracker := &bulkSimProgress{}
+	go tracker.report(ctx, progress)
+
+	for _, combo := range validCombos {
+		combo.req.SimOptions.Iterations = int32(iterations)
+	}
+	rankedResults, err := b.simCombos(ctx, validCombos, tracker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sortByScore(rankedResults)
+	var baseResult *itemSubstitutionSimResult
+	for _, result := range rankedResults {
+		if result.isBaseResult() {
+			baseResult = result
 		}
 	}
+	return rankedResults, baseResult, nil
+}
 
-	return result, nil
+// bulkSimProgress tracks the progress of all sims in a bulk sim. The totals grow while combos are
+// streamed in, since the number of combos isn't known up front.
+type bulkSimProgress struct {
+	totalSims           atomic.Int64
+	completedSims       atomic.Int64
+	totalIterations     atomic.Int64
+	completedIterations atomic.Int64
 }
 
-func (b *bulkSimRunner) getRankedResults(pctx context.Context, validCombos []singleBulkSim, iterations int64, progress chan *proto.ProgressMetrics) ([]*itemSubstitutionSimResult, *itemSubstitutionSimResult, error) {
+// report sends the combined progress of all sims every second, until ctx is done.
+func (p *bulkSimProgress) report(ctx context.Context, progress chan *proto.ProgressMetrics) {
+	if progress == nil {
+		return
+	}
+	clamp := func(value int64) int32 {
+		return int32(min(value, math.MaxInt32))
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		progress <- &proto.ProgressMetrics{
+			TotalSims:           clamp(p.totalSims.Load()),
+			CompletedSims:       clamp(p.completedSims.Load()),
+			CompletedIterations: clamp(p.completedIterations.Load()),
+			TotalIterations:     clamp(p.totalIterations.Load()),
+		}
+	}
+}
+
+// simCombos runs the sims of all combos, with the iterations already set in their requests, and
+// returns the results in the same order.
+func (b *bulkSimRunner) simCombos(ctx context.Context, validCombos []singleBulkSim, tracker *bulkSimProgress) ([]*itemSubstitutionSimResult, error) {
 	concurrency := runtime.NumCPU() + 1
 	if concurrency <= 0 {
 		concurrency = 2
@@ -325,48 +377,33 @@ func (b *bulkSimRunner) getRankedResults(pctx context.Context, validCombos []sin
 		tickets <- struct{}{}
 	}
 
-	results := make(chan *itemSubstitutionSimResult, 10)
-
-	numCombinations := int32(len(validCombos))
-	totalIterationsUpperBound := int64(numCombinations) * iterations
+	type indexedResult struct {
+		index  int
+		result *itemSubstitutionSimResult
+	}
+	// Buffered for all combos, so sims finishing after a cancellation never block.
+	results := make(chan indexedResult, len(validCombos))
 
-	var totalCompletedIterations int32
-	var totalCompletedSims int32
+	for _, combo := range validCombos {
+		tracker.totalSims.Add(1)
+		tracker.totalIterations.Add(int64(combo.req.SimOptions.Iterations))
+	}
 
-	ctx, cancel := context.WithCancel(pctx)
-	// reporter for all sims combined.
+	// launcher for all combos (limited by concurrency max)
 	go func() {
-		for ctx.Err() == nil {
-			complIters := atomic.LoadInt32(&totalCompletedIterations)
-			complSims := atomic.LoadInt32(&totalCompletedSims)
-
-			// stop reporting
-			if complIters == int32(totalIterationsUpperBound) || numCombinations == complSims {
+		for i, singleCombo := range validCombos {
+			select {
+			case <-tickets:
+			case <-ctx.Done():
 				return
 			}
-
-			progress <- &proto.ProgressMetrics{
-				TotalSims:           numCombinations,
-				CompletedSims:       complSims,
-				CompletedIterations: complIters,
-				TotalIterations:     int32(totalIterationsUpperBound),
-			}
-			time.Sleep(time.Second)
-		}
-	}()
-
-	// launcher for all combos (limited by concurrency max)
-	go func() {
-		for _, singleCombo := range validCombos {
-			<-tickets
 			singleSimProgress := make(chan *proto.ProgressMetrics)
 
 			// watches this progress and pushes up to main reporter.
 			go func(prog chan *proto.ProgressMetrics) {
 				var prevDone int32
 				for p := range singleSimProgress {
-					delta := p.CompletedIterations - prevDone
-					atomic.AddInt32(&totalCompletedIterations, delta)
+					tracker.completedIterations.Add(int64(p.CompletedIterations - prevDone))
 					prevDone = p.CompletedIterations
 					if p.FinalRaidResult != nil {
 						break
@@ -374,42 +411,46 @@ func (b *bulkSimRunner) getRankedResults(pctx context.Context, validCombos []sin
 				}
 			}(singleSimProgress)
 			// actually run the sim in here.
-			go func(sub singleBulkSim) {
-				// overwrite the requests iterations with the input for this function.
-
-				sub.req.SimOptions.Iterations = int32(iterations)
-				results <- &itemSubstitutionSimResult{
-					Request:      sub.req,
-					Result:       b.SingleRaidSimRunner(sub.req, singleSimProgress, false, nil),
-					Substitution: sub.eq,
-					ChangeLog:    sub.cl,
+			go func(i int, sub singleBulkSim) {
+				results <- indexedResult{
+					index: i,
+					result: &itemSubstitutionSimResult{
+						Request:      sub.req,
+						Result:       b.SingleRaidSimRunner(ctx, sub.req, singleSimProgress, false),
+						Substitution: sub.eq,
+						ChangeLog:    sub.cl,
+					},
 				}
-				atomic.AddInt32(&totalCompletedSims, 1)
+				tracker.completedSims.Add(1)
 				tickets <- struct{}{} // when done, allow for new sim to be launched.
-			}(singleCombo)
+			}(i, singleCombo)
 		}
 	}()
 
-	rankedResults := make([]*itemSubstitutionSimResult, numCombinations)
-	var baseResult *itemSubstitutionSimResult
-
-	for i := range rankedResults {
-		result := <-results
-		if result.Result == nil || result.Result.ErrorResult != "" {
-			cancel() // cancel reporter
-			return nil, nil, errors.New("simulation failed: " + result.Result.ErrorResult)
+	simResults := make([]*itemSubstitutionSimResult, len(validCombos))
+	for range simResults {
+		var indexed indexedResult
+		select {
+		case indexed = <-results:
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
-		if !result.Substitution.HasItemReplacements() && result.ChangeLog.TalentLoadout == nil {
-			baseResult = result
+		result := indexed.result
+		if result.Result.GetCancelled() {
+			return nil, ctx.Err()
 		}
-		rankedResults[i] = result
+		if result.Result == nil || result.Result.ErrorResult != "" {
+			return nil, errors.New("simulation failed: " + result.Result.GetErrorResult())
+		}
+		simResults[indexed.index] = result
 	}
-	cancel() // cancel reporter
+	return simResults, nil
+}
 
-	sort.Slice(rankedResults, func(i, j int) bool {
-		return rankedResults[i].Score() > rankedResults[j].Score()
+func sortByScore(results []*itemSubstitutionSimResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score() > results[j].Score()
 	})
-	return rankedResults, baseResult, nil
 }
 
 // itemSubstitutionSimResult stores the request and response of a simulation, along with the used
@@ -430,6 +471,26 @@ func (r *itemSubstitutionSimResult) Score() float64 {
 	return r.Result.RaidMetrics.Dps.Avg
 }
 
+// StandardError of the score, used to tell whether two results are really different.
+func (r *itemSubstitutionSimResult) StandardError() float64 {
+	iterations := r.Request.GetSimOptions().GetIterations()
+	if r.Result == nil || r.Result.ErrorResult != "" || iterations <= 0 {
+		return 0
+	}
+	return r.Result.RaidMetrics.Dps.Stdev / math.Sqrt(float64(iterations))
+}
+
+// ConfidenceInterval returns the 95% confidence interval of the score.
+func (r *itemSubstitutionSimResult) ConfidenceInterval() (float64, float64) {
+	const z95 = 1.96
+	return r.Score() - z95*r.StandardError(), r.Score() + z95*r.StandardError()
+}
+
+// isBaseResult returns true for the result of the equipped gear and talents.
+func (r *itemSubstitutionSimResult) isBaseResult() bool {
+	return !r.Substitution.HasItemReplacements() && r.ChangeLog.TalentLoadout == nil
+}
+
 // equipmentSubstitution specifies all items to be used as replacements for the equipped gear.
 type equipmentSubstitution struct {
 	Items []*itemWithSlot
@@ -522,13 +583,24 @@ func isValidEquipment(equipment *proto.EquipmentSpec) bool {
 // given bulk sim request. Also returns the unchanged equipment ("base equipment set") set as the
 // first result. This ensures that simming over all possible equipment substitutions includes the
 // base case as well.
-func generateAllEquipmentSubstitutions(_ context.Context, baseItems []*proto.ItemSpec, combinations bool, distinctItemSlotCombos []*itemWithSlot) chan *equipmentSubstitution {
+func generateAllEquipmentSubstitutions(ctx context.Context, baseItems []*proto.ItemSpec, combinations bool, distinctItemSlotCombos []*itemWithSlot) chan *equipmentSubstitution {
 	results := make(chan *equipmentSubstitution)
+	// send returns false once the consumer is gone, so the generator stops early.
+	send := func(sub *equipmentSubstitution) bool {
+		select {
+		case results <- sub:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
 	go func() {
 		defer close(results)
 
 		// No substitutions (base case).
-		results <- &equipmentSubstitution{}
+		if !send(&equipmentSubstitution{}) {
+			return
+		}
 
 		// Organize everything by slot.
 		itemsBySlot := make([][]*proto.ItemSpec, 17)
@@ -552,8 +624,8 @@ func generateAllEquipmentSubstitutions(_ context.Context, baseItems []*proto.Ite
 					// Handle finger/trinket specially to generate combos
 					switch slotid {
 					case int(proto.ItemSlot_ItemSlotFinger1), int(proto.ItemSlot_ItemSlotTrinket1):
-						if !comboChecker.HasCombo(item.Id, baseItems[slotid+1].Id) {
-							results <- &sub
+						if !comboChecker.HasCombo(item.Id, baseItems[slotid+1].Id) && !send(&sub) {
+							return
 						}
 						// Generate extra combos
 						subslot := slotid + 1
@@ -562,15 +634,19 @@ func generateAllEquipmentSubstitutions(_ context.Context, baseItems []*proto.Ite
 								continue
 							}
 							miniCombo := createReplacement(sub, &itemWithSlot{Item: subitem, Slot: proto.ItemSlot(subslot)})
-							results <- &miniCombo
+							if !send(&miniCombo) {
+								return
+							}
 						}
 					case int(proto.ItemSlot_ItemSlotFinger2), int(proto.ItemSlot_ItemSlotTrinket2):
 						// Ensure we don't have this combo with the base equipment.
-						if !comboChecker.HasCombo(item.Id, baseItems[slotid-1].Id) {
-							results <- &sub
+						if !comboChecker.HasCombo(item.Id, baseItems[slotid-1].Id) && !send(&sub) {
+							return
 						}
 					default:
-						results <- &sub
+						if !send(&sub) {
+							return
+						}
 					}
 				}
 			}
@@ -581,7 +657,9 @@ func generateAllEquipmentSubstitutions(_ context.Context, baseItems []*proto.Ite
 		// the best set of items in your bags.
 		subComboChecker := SubstitutionComboChecker{}
 		for i := 0; i < len(itemsBySlot); i++ {
-			genSlotCombos(proto.ItemSlot(i), baseItems, equipmentSubstitution{}, itemsBySlot, subComboChecker, results)
+			if !genSlotCombos(proto.ItemSlot(i), baseItems, equipmentSubstitution{}, itemsBySlot, subComboChecker, send) {
+				return
+			}
 		}
 	}()
 
@@ -614,7 +692,7 @@ func shouldSkipCombo(baseItems []*proto.ItemSpec, item *proto.ItemSpec, slot pro
 	return false
 }
 
-func genSlotCombos(slot proto.ItemSlot, baseItems []*proto.ItemSpec, baseRepl equipmentSubstitution, replaceBySlot [][]*proto.ItemSpec, comboChecker SubstitutionComboChecker, results chan *equipmentSubstitution) {
+func genSlotCombos(slot proto.ItemSlot, baseItems []*proto.ItemSpec, baseRepl equipmentSubstitution, replaceBySlot [][]*proto.ItemSpec, comboChecker SubstitutionComboChecker, send func(*equipmentSubstitution) bool) bool {
 	// Iterate all items in this slot, add to the baseRepl, then descend to add all other item combos.
 	for _, item := range replaceBySlot[slot] {
 		// Create a new equipment substitution from the current replacements plus the new item.
@@ -622,13 +700,18 @@ func genSlotCombos(slot proto.ItemSlot, baseItems []*proto.ItemSpec, baseRepl eq
 		if comboChecker.HasCombo(combo) {
 			continue
 		}
-		results <- &combo
+		if !send(&combo) {
+			return false
+		}
 
 		// Now descend to each other slot to pair with this combo.
 		for j := slot + 1; int(j) < len(replaceBySlot); j++ {
-			genSlotCombos(j, baseItems, combo, replaceBySlot, comboChecker, results)
+			if !genSlotCombos(j, baseItems, combo, replaceBySlot, comboChecker, send) {
+				return false
+			}
 		}
 	}
+	return true
 }
 
 // itemWithSlot pairs an item with its fixed item slot.
@@ -647,15 +730,31 @@ type itemWithSlot struct {
 type raidSimRequestChangeLog struct {
 	AddedItems    []*proto.ItemSpecWithSlot
 	TalentLoadout *proto.TalentLoadout
+	// Only used in raid-wide bulk sims, where AddedItems is empty.
+	RaidMembers []*raidMemberChangeLog
+}
+
+// raidMemberChangeLog stores the items added to a single raid member.
+type raidMemberChangeLog struct {
+	PartyIndex  int32
+	PlayerIndex int32
+	AddedItems  []*proto.ItemSpecWithSlot
 }
 
 // createNewRequestWithSubstitution creates a copy of the input RaidSimRequest and applis the given
 // equipment susbstitution to the player's equipment. Copies enchant if specified and possible.
 func createNewRequestWithSubstitution(readonlyInputRequest *proto.RaidSimRequest, substitution *equipmentSubstitution, autoEnchant bool) (*proto.RaidSimRequest, *raidSimRequestChangeLog) {
 	request := goproto.Clone(readonlyInputRequest).(*proto.RaidSimRequest)
-	changeLog := &raidSimRequestChangeLog{}
-	player := request.Raid.Parties[0].Players[0]
-	equipment := player.Equipment
+	changeLog := &raidSimRequestChangeLog{
+		AddedItems: applySubstitution(request.Raid.Parties[0].Players[0].Equipment, substitution, autoEnchant),
+	}
+	return request, changeLog
+}
+
+// applySubstitution replaces the items of equipment with the given substitution and returns the
+// added items. Copies enchant if specified and possible.
+func applySubstitution(equipment *proto.EquipmentSpec, substitution *equipmentSubstitution, autoEnchant bool) []*proto.ItemSpecWithSlot {
+	var addedItems []*proto.ItemSpecWithSlot
 	for _, is := range substitution.Items {
 		oldItem := equipment.Items[is.Slot]
 		if autoEnchant && oldItem.Enchant > 0 && is.Item.Enchant == 0 {
@@ -666,19 +765,19 @@ func createNewRequestWithSubstitution(readonlyInputRequest *proto.RaidSimRequest
 			// Main/One hand shouldn't get staff enchant
 			// Later: replace normal enchant if replacement is staff.
 
-			changeLog.AddedItems = append(changeLog.AddedItems, &proto.ItemSpecWithSlot{
+			addedItems = append(addedItems, &proto.ItemSpecWithSlot{
 				Item: equipment.Items[is.Slot],
 				Slot: is.Slot,
 			})
 		} else {
 			equipment.Items[is.Slot] = is.Item
-			changeLog.AddedItems = append(changeLog.AddedItems, &proto.ItemSpecWithSlot{
+			addedItems = append(addedItems, &proto.ItemSpecWithSlot{
 				Item: is.Item,
 				Slot: is.Slot,
 			})
 		}
 	}
-	return request, changeLog
+	return addedItems
 }
 
 type ItemComboChecker map[int64]struct{}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+const (
+	// Width of the confidence intervals used to drop combos from the race, in standard errors.
+	bulkRaceZScore = 3.0
+
+	// Maximum number of combos kept in the race. When there are more, the contenders are refined
+	// before more combos are streamed in, which keeps memory bounded for huge numbers of combos.
+	maxBulkContenders = 1000
+
+	// Number of combos simmed per batch, per concurrent sim.
+	bulkRaceBatchesPerSim = 4
+)
+
+// rankCombos races the combos streamed in from combos and returns the best ones sorted by score,
+// along with the result of the equipped gear.
+//
+// Combos are simmed in batches as they are generated, with few iterations in fast mode. After each
+// batch, combos whose confidence interval falls below the one of the last combo that would make the
+// results are dropped. Only the remaining contenders get more iterations, doubling each round until
+// the configured iterations are reached.
+func (b *bulkSimRunner) rankCombos(pctx context.Context, combos <-chan singleBulkSim, progress chan *proto.ProgressMetrics) ([]*itemSubstitutionSimResult, *itemSubstitutionSimResult, error) {
+	ctx, cancel := context.WithCancel(pctx)
+	defer cancel() // cancel reporter
+
+	iterations := int64(b.Request.GetBulkSettings().GetIterationsPerCombo())
+	if iterations <= 0 {
+		iterations = defaultIterationsPerCombo
+	}
+	startIterations := iterations
+	if b.Request.BulkSettings.FastMode {
+		// In fast mode try to keep starting iterations between 50 and 1000.
+		startIterations = min(max(iterations/100, 50), 1000, iterations)
+	}
+
+	tracker := &bulkSimProgress{}
+	go tracker.report(ctx, progress)
+
+	batchSize := (runtime.NumCPU() + 1) * bulkRaceBatchesPerSim
+	batch := make([]singleBulkSim, 0, batchSize)
+	var contenders []*itemSubstitutionSimResult
+
+	simBatch := func() error {
+		for _, combo := range batch {
+			combo.req.SimOptions.Iterations = int32(startIterations)
+		}
+		results, err := b.simCombos(ctx, batch, tracker)
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+
+		contenders = pruneContenders(append(contenders, results...), iterations)
+		for len(contenders) > maxBulkContenders {
+			refined, err := b.refineContenders(ctx, contenders, iterations, tracker)
+			if err != nil {
+				return err
+			}
+			contenders = pruneContenders(contenders, iterations)
+			if !refined {
+				break
+			}
+		}
+		return nil
+	}
+
+	for streaming := true; streaming; {
+		select {
+		case combo, ok := <-combos:
+			if ok {
+				batch = append(batch, combo)
+			} else {
+				streaming = false
+			}
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		if len(batch) == batchSize || (!streaming && len(batch) > 0) {
+			if err := simBatch(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Race the remaining contenders until all of them have the full iterations.
+	for {
+		refined, err := b.refineContenders(ctx, contenders, iterations, tracker)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !refined {
+			break
+		}
+		contenders = pruneContenders(contenders, iterations)
+	}
+
+	var baseResult *itemSubstitutionSimResult
+	for _, result := range contenders {
+		if result.isBaseResult() {
+			baseResult = result
+		}
+	}
+	if baseResult == nil {
+		return nil, nil, fmt.Errorf("no base result for equipped gear found in bulk sim")
+	}
+	return contenders, baseResult, nil
+}
+
+// refineContenders doubles the iterations of every contender that doesn't have the full iterations
+// yet. Only the extra iterations are simmed, continuing from the seed where the previous ones
+// stopped, and merged into the contender's result. Returns false if no contender needed more
+// iterations.
+func (b *bulkSimRunner) refineContenders(ctx context.Context, contenders []*itemSubstitutionSimResult, iterations int64, tracker *bulkSimProgress) (bool, error) {
+	var indices []int
+	var toSim []singleBulkSim
+	for i, contender := range contenders {
+		current := int64(contender.Request.SimOptions.Iterations)
+		if current >= iterations {
+			continue
+		}
+		request := goproto.Clone(contender.Request).(*proto.RaidSimRequest)
+		request.SimOptions.Iterations = int32(min(current*2, iterations) - current)
+		if request.SimOptions.RandomSeed != 0 {
+			// Sims increment their seed each iteration.
+			request.SimOptions.RandomSeed += current
+		}
+		request.SimOptions.DebugFirstIteration = false
+		request.SimOptions.AplTrace = false
+		indices = append(indices, i)
+		toSim = append(toSim, singleBulkSim{
+			req: request,
+			cl:  contender.ChangeLog,
+			eq:  contender.Substitution,
+		})
+	}
+	if len(toSim) == 0 {
+		return false, nil
+	}
+
+	results, err := b.simCombos(ctx, toSim, tracker)
+	if err != nil {
+		return false, err
+	}
+	for i, result := range results {
+		contender := contenders[indices[i]]
+		current := contender.Request.SimOptions.Iterations
+		total := current + result.Request.SimOptions.Iterations
+
+		rsrc := raidSimResultCombiner{}
+		rsrc.setBaseResult(contender.Result)
+		rsrc.addResult(contender.Result, false, float64(current)/float64(total))
+		rsrc.addResult(result.Result, true, float64(result.Request.SimOptions.Iterations)/float64(total))
+		contender.Result = rsrc.Combined
+		contender.Request.SimOptions.Iterations = total
+	}
+	return true, nil
+}
+
+// pruneContenders sorts the contenders by score and drops the ones that can't make it into the
+// results anymore: combos with the full iterations that are outside of the results, and combos
+// whose confidence interval is entirely below the one of the last combo in the results. The result
+// of the equipped gear is always kept, since every bulk sim result is compared against it.
+func pruneContenders(contenders []*itemSubstitutionSimResult, iterations int64) []*itemSubstitutionSimResult {
+	sortByScore(contenders)
+	if len(contenders) <= maxBulkResults {
+		return contenders
+	}
+
+	cutoff := contenders[maxBulkResults-1]
+	threshold := cutoff.Score() - bulkRaceZScore*cutoff.StandardError()
+
+	kept := contenders[:maxBulkResults]
+	for _, contender := range contenders[maxBulkResults:] {
+		canImprove := int64(contender.Request.SimOptions.Iterations) < iterations &&
+			contender.Score()+bulkRaceZScore*contender.StandardError() >= threshold
+		if canImprove || contender.isBaseResult() {
+			kept = append(kept, contender)
+		}
+	}
+	// Clear the dropped results so they can be garbage collected.
+	clear(contenders[len(kept):])
+	return kept
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+const maxRaidBulkCombos = 1000000
+
+// raidMemberSubstitution is an equipment substitution for a single raid member.
+type raidMemberSubstitution struct {
+	PartyIndex   int32
+	PlayerIndex  int32
+	Substitution *equipmentSubstitution
+}
+
+// raidPlayer returns the player at the given position in the raid, or nil if there is none.
+func raidPlayer(raid *proto.Raid, partyIndex int32, playerIndex int32) *proto.Player {
+	parties := raid.GetParties()
+	if partyIndex < 0 || int(partyIndex) >= len(parties) {
+		return nil
+	}
+	players := parties[partyIndex].GetPlayers()
+	if playerIndex < 0 || int(playerIndex) >= len(players) {
+		return nil
+	}
+	return players[playerIndex]
+}
+
+// runRaidWide bulk sims item substitutions for several raid members at once. The whole raid is
+// kept, so buffs between raid members are part of the results, and combos are ranked by raid DPS.
+func (b *bulkSimRunner) runRaidWide(ctx context.Context, progress chan *proto.ProgressMetrics) (*proto.BulkSimResult, error) {
+	settings := b.Request.BulkSettings
+	if settings.GetGearOptimizer().GetEnabled() || settings.GetTalentSearch().GetEnabled() || settings.SimTalents {
+		return nil, errors.New("bulksim: gear optimizer, talent search and talent sims are not supported with raid items")
+	}
+
+	raid := b.Request.GetBaseSettings().GetRaid()
+	for _, party := range raid.GetParties() {
+		for _, player := range party.GetPlayers() {
+			if player.GetDatabase() != nil {
+				addToDatabase(player.GetDatabase())
+			}
+			// clean to reduce memory
+			player.Database = nil
+		}
+	}
+
+	// Substitutions for each raid member, without the base case.
+	var memberSubstitutions [][]*raidMemberSubstitution
+	seenMembers := make(map[[2]int32]bool)
+	for _, memberItems := range settings.RaidItems {
+		player := raidPlayer(raid, memberItems.PartyIndex, memberItems.PlayerIndex)
+		if player == nil || player.Name == "" {
+			return nil, fmt.Errorf("bulksim: no player at party %d, position %d", memberItems.PartyIndex, memberItems.PlayerIndex)
+		}
+		key := [2]int32{memberItems.PartyIndex, memberItems.PlayerIndex}
+		if seenMembers[key] {
+			return nil, fmt.Errorf("bulksim: player at party %d, position %d has more than one item list", memberItems.PartyIndex, memberItems.PlayerIndex)
+		}
+		seenMembers[key] = true
+
+		if settings.AutoGem {
+			b.autoGemItems(memberItems.Items)
+		}
+		distinctItemSlotCombos, err := getDistinctItemSlotCombos(memberItems.Items)
+		if err != nil {
+			return nil, err
+		}
+
+		var substitutions []*raidMemberSubstitution
+		for sub := range generateAllEquipmentSubstitutions(ctx, player.Equipment.Items, settings.Combinations, distinctItemSlotCombos) {
+			if !sub.HasItemReplacements() {
+				continue
+			}
+			equipment := goproto.Clone(player.Equipment).(*proto.EquipmentSpec)
+			applySubstitution(equipment, sub, settings.AutoEnchant)
+			if !isValidEquipment(equipment) {
+				continue
+			}
+			substitutions = append(substitutions, &raidMemberSubstitution{
+				PartyIndex:   memberItems.PartyIndex,
+				PlayerIndex:  memberItems.PlayerIndex,
+				Substitution: sub,
+			})
+		}
+		memberSubstitutions = append(memberSubstitutions, substitutions)
+	}
+
+	if _, err := countRaidCombos(memberSubstitutions, settings.Combinations); err != nil {
+		return nil, err
+	}
+
+	// Combos and their requests are only created once the race asks for them.
+	combos := make(chan singleBulkSim)
+	go func() {
+		defer close(combos)
+		forEachRaidCombo(memberSubstitutions, settings.Combinations, func(raidCombo []*raidMemberSubstitution) bool {
+			select {
+			case combos <- b.newRaidBulkSim(raidCombo):
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	rankedResults, baseResult, err := b.rankCombos(ctx, combos, progress)
+	if err != nil {
+		return nil, err
+	}
+	return newRaidBulkSimResult(raid, rankedResults, baseResult, maxBulkResults), nil
+}
+
+// countRaidCombos returns the number of raid-wide combos forEachRaidCombo generates, or an error
+// if there are too many to sim.
+func countRaidCombos(memberSubstitutions [][]*raidMemberSubstitution, combinations bool) (int, error) {
+	numCombos := 1
+	for _, substitutions := range memberSubstitutions {
+		if combinations {
+			// Keeping the equipped gear of this raid member is a combo as well.
+			numCombos *= len(substitutions) + 1
+		} else {
+			numCombos += len(substitutions)
+		}
+		if numCombos > maxRaidBulkCombos {
+			return 0, fmt.Errorf("bulksim: over %d raid combos, abandoning attempt", maxRaidBulkCombos)
+		}
+	}
+	return numCombos, nil
+}
+
+// forEachRaidCombo calls fn with the substitutions to sim for the whole raid, starting with the
+// base case, until fn returns false. Without combinations only one raid member is changed at a
+// time, otherwise every combination of the raid members' substitutions is generated, one at a time.
+func forEachRaidCombo(memberSubstitutions [][]*raidMemberSubstitution, combinations bool, fn func(raidCombo []*raidMemberSubstitution) bool) {
+	if !fn([]*raidMemberSubstitution{}) {
+		return
+	}
+	if !combinations {
+		for _, substitutions := range memberSubstitutions {
+			for _, sub := range substitutions {
+				if !fn([]*raidMemberSubstitution{sub}) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	// Mixed-radix counter over the raid members, where digit 0 keeps the member's equipped gear
+	// and digit i picks their substitution i-1. The last member changes fastest.
+	digits := make([]int, len(memberSubstitutions))
+	for {
+		i := len(digits) - 1
+		for ; i >= 0; i-- {
+			digits[i]++
+			if digits[i] <= len(memberSubstitutions[i]) {
+				break
+			}
+			digits[i] = 0
+		}
+		if i < 0 {
+			return
+		}
+
+		var raidCombo []*raidMemberSubstitution
+		for member, digit := range digits {
+			if digit > 0 {
+				raidCombo = append(raidCombo, memberSubstitutions[member][digit-1])
+			}
+		}
+		if !fn(raidCombo) {
+			return
+		}
+	}
+}
+
+// newRaidBulkSim creates the request for a raid-wide combo. The combined item substitution is only
+// used to tell the base case apart, since slots of different raid members overlap.
+func (b *bulkSimRunner) newRaidBulkSim(raidCombo []*raidMemberSubstitution) singleBulkSim {
+	request := goproto.Clone(b.Request.BaseSettings).(*proto.RaidSimRequest)
+	changeLog := &raidSimRequestChangeLog{}
+	combined := &equipmentSubstitution{}
+	for _, member := range raidCombo {
+		player := raidPlayer(request.Raid, member.PartyIndex, member.PlayerIndex)
+		changeLog.RaidMembers = append(changeLog.RaidMembers, &raidMemberChangeLog{
+			PartyIndex:  member.PartyIndex,
+			PlayerIndex: member.PlayerIndex,
+			AddedItems:  applySubstitution(player.Equipment, member.Substitution, b.Request.BulkSettings.AutoEnchant),
+		})
+		combined.Items = append(combined.Items, member.Substitution.Items...)
+	}
+	return singleBulkSim{req: request, cl: changeLog, eq: combined}
+}
+
+// newRaidBulkSimResult converts the best ranked raid-wide results into the bulk sim result proto,
+// with the DPS deltas of the raid and every raid member compared to the equipped gear.
+func newRaidBulkSimResult(raid *proto.Raid, rankedResults []*itemSubstitutionSimResult, baseResult *itemSubstitutionSimResult, maxResults int) *proto.BulkSimResult {
+	if len(rankedResults) > maxResults {
+		rankedResults = rankedResults[:maxResults]
+	}
+
+	result := &proto.BulkSimResult{
+		EquippedGearResult: newRaidBulkComboResult(raid, baseResult, baseResult),
+	}
+	for _, r := range rankedResults {
+		result.Results = append(result.Results, newRaidBulkComboResult(raid, r, baseResult))
+	}
+	return result
+}
+
+func newRaidBulkComboResult(raid *proto.Raid, r *itemSubstitutionSimResult, baseResult *itemSubstitutionSimResult) *proto.BulkComboResult {
+	lower, upper := r.ConfidenceInterval()
+	comboResult := &proto.BulkComboResult{
+		RaidDps:      r.Result.GetRaidMetrics().GetDps(),
+		RaidDpsDelta: r.Score() - baseResult.Score(),
+		DpsLower:     lower,
+		DpsUpper:     upper,
+	}
+
+	for partyIndex, party := range raid.GetParties() {
+		for playerIndex, player := range party.GetPlayers() {
+			if player.Name == "" {
+				continue
+			}
+			memberResult := &proto.BulkRaidMemberResult{
+				PartyIndex:  int32(partyIndex),
+				PlayerIndex: int32(playerIndex),
+				UnitMetrics: raidMemberMetrics(r.Result, partyIndex, playerIndex),
+			}
+			memberResult.DpsDelta = memberResult.UnitMetrics.GetDps().GetAvg() - raidMemberMetrics(baseResult.Result, partyIndex, playerIndex).GetDps().GetAvg()
+			for _, changes := range r.ChangeLog.RaidMembers {
+				if changes.PartyIndex == int32(partyIndex) && changes.PlayerIndex == int32(playerIndex) {
+					memberResult.ItemsAdded = changes.AddedItems
+				}
+			}
+			comboResult.RaidMemberResults = append(comboResult.RaidMemberResults, memberResult)
+		}
+	}
+	return comboResult
+}
+
+// raidMemberMetrics returns the metrics of a raid member without the per-action details.
+func raidMemberMetrics(result *proto.RaidSimResult, partyIndex int, playerIndex int) *proto.UnitMetrics {
+	parties := result.GetRaidMetrics().GetParties()
+	if partyIndex >= len(parties) || playerIndex >= len(parties[partyIndex].GetPlayers()) {
+		return nil
+	}
+	um := goproto.Clone(parties[partyIndex].Players[playerIndex]).(*proto.UnitMetrics)
+	um.Actions = nil
+	um.Auras = nil
+	um.Resources = nil
+	um.Pets = nil
+	return um
+}
@@ -2,6 +2,10 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -91,7 +95,7 @@ func createEquipmentFromItems(items ...*itemWithSlot) *proto.EquipmentSpec {
 func TestBulkSim(t *testing.T) {
 	t.Skip("TODO: Implement")
 
-	fakeRunSim := func(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool, quitChan chan bool) *proto.RaidSimResult {
+	fakeRunSim := func(ctx context.Context, rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool) *proto.RaidSimResult {
 		return &proto.RaidSimResult{}
 	}
 
@@ -113,6 +117,251 @@ func TestBulkSim(t *testing.T) {
 	}
 }
 
+func TestBulkSimConflictingModes(t *testing.T) {
+	bulk := &bulkSimRunner{
+		Request: &proto.BulkSimRequest{
+			BulkSettings: &proto.BulkSettings{
+				GearOptimizer: &proto.GearOptimizerSettings{Enabled: true},
+				TalentSearch:  &proto.TalentSearchSettings{Enabled: true},
+			},
+		},
+	}
+
+	if _, err := bulk.Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "can't be enabled together") {
+		t.Fatalf("expected an error for enabling the gear optimizer and talent search together, got %v", err)
+	}
+}
+
+func TestRaidWideBulkSim(t *testing.T) {
+	addToDatabase(tinyItemDatabase)
+
+	equippedDps := func(player *proto.Player) float64 {
+		dps := 0.0
+		for _, item := range player.Equipment.Items {
+			dps += float64(item.Id) / 1000
+		}
+		return dps
+	}
+	// The second player gains DPS from the first one wearing the two-hander, like a buff between players would.
+	fakeRunSim := func(ctx context.Context, rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool) *proto.RaidSimResult {
+		first := rsr.Raid.Parties[0].Players[0]
+		second := rsr.Raid.Parties[1].Players[0]
+		firstDps := equippedDps(first)
+		secondDps := equippedDps(second)
+		if first.Equipment.Items[proto.ItemSlot_ItemSlotMainHand].Id == itemPillarOfFortitude {
+			secondDps += 10
+		}
+		return &proto.RaidSimResult{
+			RaidMetrics: &proto.RaidMetrics{
+				Dps: &proto.DistributionMetrics{Avg: firstDps + secondDps},
+				Parties: []*proto.PartyMetrics{
+					{Players: []*proto.UnitMetrics{{Dps: &proto.DistributionMetrics{Avg: firstDps}}}},
+					{Players: []*proto.UnitMetrics{{Dps: &proto.DistributionMetrics{Avg: secondDps}}}},
+				},
+			},
+		}
+	}
+
+	newPlayer := func(name string) *proto.Player {
+		return &proto.Player{Name: name, Equipment: createEquipmentFromItems(starshardEdge1)}
+	}
+	bulk := &bulkSimRunner{
+		SingleRaidSimRunner: fakeRunSim,
+		Request: &proto.BulkSimRequest{
+			BaseSettings: &proto.RaidSimRequest{
+				Raid: &proto.Raid{
+					Parties: []*proto.Party{
+						{Players: []*proto.Player{newPlayer("first")}},
+						{Players: []*proto.Player{newPlayer("second")}},
+					},
+				},
+				SimOptions: &proto.SimOptions{},
+			},
+			BulkSettings: &proto.BulkSettings{
+				Combinations:       true,
+				IterationsPerCombo: 1,
+				RaidItems: []*proto.BulkRaidMemberItems{
+					{PartyIndex: 0, PlayerIndex: 0, Items: []*proto.ItemSpec{{Id: itemPillarOfFortitude}}},
+					{PartyIndex: 1, PlayerIndex: 0, Items: []*proto.ItemSpec{{Id: itemIronmender}}},
+				},
+			},
+		},
+	}
+
+	got, err := bulk.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+	if len(got.Results) != 4 {
+		t.Fatalf("Run() returned %d results, want 4", len(got.Results))
+	}
+
+	best := got.Results[0]
+	if len(best.RaidMemberResults) != 2 {
+		t.Fatalf("best result has %d raid member results, want 2", len(best.RaidMemberResults))
+	}
+	for i, tc := range []struct {
+		itemAdded int32
+		dpsDelta  float64
+	}{
+		{itemAdded: itemPillarOfFortitude, dpsDelta: float64(itemPillarOfFortitude-itemStarshardEdge) / 1000},
+		{itemAdded: itemIronmender, dpsDelta: float64(itemIronmender)/1000 + 10},
+	} {
+		member := best.RaidMemberResults[i]
+		if len(member.ItemsAdded) != 1 || member.ItemsAdded[0].Item.Id != tc.itemAdded {
+			t.Errorf("raid member %d added items %v, want item %d", i, member.ItemsAdded, tc.itemAdded)
+		}
+		if math.Abs(member.DpsDelta-tc.dpsDelta) > 1e-9 {
+			t.Errorf("raid member %d has DPS delta %0.3f, want %0.3f", i, member.DpsDelta, tc.dpsDelta)
+		}
+	}
+	wantRaidDelta := best.RaidMemberResults[0].DpsDelta + best.RaidMemberResults[1].DpsDelta
+	if math.Abs(best.RaidDpsDelta-wantRaidDelta) > 1e-9 {
+		t.Errorf("best result has raid DPS delta %0.3f, want %0.3f", best.RaidDpsDelta, wantRaidDelta)
+	}
+	if got.EquippedGearResult.RaidDpsDelta != 0 {
+		t.Errorf("equipped gear result has raid DPS delta %0.3f, want 0", got.EquippedGearResult.RaidDpsDelta)
+	}
+}
+
+func TestForEachRaidCombo(t *testing.T) {
+	newSubs := func(partyIndex int32, n int) []*raidMemberSubstitution {
+		subs := make([]*raidMemberSubstitution, n)
+		for i := range subs {
+			subs[i] = &raidMemberSubstitution{PartyIndex: partyIndex, PlayerIndex: int32(i)}
+		}
+		return subs
+	}
+	memberSubstitutions := [][]*raidMemberSubstitution{newSubs(0, 2), newSubs(1, 1), newSubs(2, 3)}
+
+	for _, combinations := range []bool{false, true} {
+		want, err := countRaidCombos(memberSubstitutions, combinations)
+		if err != nil {
+			t.Fatalf("countRaidCombos(%v) returned error: %v", combinations, err)
+		}
+
+		seen := make(map[string]bool)
+		forEachRaidCombo(memberSubstitutions, combinations, func(raidCombo []*raidMemberSubstitution) bool {
+			key := ""
+			for _, sub := range raidCombo {
+				key += fmt.Sprintf("%d:%d,", sub.PartyIndex, sub.PlayerIndex)
+			}
+			if len(seen) == 0 && key != "" {
+				t.Errorf("first combo is %q, want the base case", key)
+			}
+			if seen[key] {
+				t.Errorf("combo %q generated twice", key)
+			}
+			seen[key] = true
+			return true
+		})
+		if len(seen) != want {
+			t.Errorf("forEachRaidCombo(%v) generated %d combos, want %d", combinations, len(seen), want)
+		}
+	}
+	if got, _ := countRaidCombos(memberSubstitutions, true); got != 3*2*4 {
+		t.Errorf("countRaidCombos(true) = %d, want %d", got, 3*2*4)
+	}
+
+	// Stops as soon as fn returns false.
+	calls := 0
+	forEachRaidCombo(memberSubstitutions, true, func([]*raidMemberSubstitution) bool {
+		calls++
+		return calls < 5
+	})
+	if calls != 5 {
+		t.Errorf("forEachRaidCombo() called fn %d times after stopping at 5", calls)
+	}
+}
+
+func TestBulkSimRacing(t *testing.T) {
+	const (
+		firstRaceItem = 980001
+		numRaceItems  = 200
+		iterations    = 1000
+	)
+	database := &proto.SimDatabase{}
+	var items []*proto.ItemSpec
+	for id := int32(firstRaceItem); id < firstRaceItem+numRaceItems; id++ {
+		database.Items = append(database.Items, &proto.SimItem{Id: id, Type: proto.ItemType_ItemTypeHead})
+		items = append(items, &proto.ItemSpec{Id: id})
+	}
+	addToDatabase(database)
+
+	// Every item is worth 10 DPS more than the previous one, with a lot of noise.
+	var mu sync.Mutex
+	simmedIterations := map[int32]int32{}
+	fakeRunSim := func(ctx context.Context, rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool) *proto.RaidSimResult {
+		head := rsr.Raid.Parties[0].Players[0].Equipment.Items[proto.ItemSlot_ItemSlotHead].Id
+		mu.Lock()
+		simmedIterations[head] += rsr.SimOptions.Iterations
+		mu.Unlock()
+		// Refined contenders merge their results, so these need every metric a real sim reports.
+		n := rsr.SimOptions.Iterations
+		dist := func(avg float64, stdev float64) *proto.DistributionMetrics {
+			return &proto.DistributionMetrics{Avg: avg, Stdev: stdev, AggregatorData: &proto.AggregatorData{N: n, SumSq: float64(n) * (stdev*stdev + avg*avg)}}
+		}
+		dps := float64(head-firstRaceItem) * 10
+		return &proto.RaidSimResult{
+			RaidMetrics: &proto.RaidMetrics{
+				Dps: dist(dps, 200),
+				Hps: dist(0, 0),
+				Parties: []*proto.PartyMetrics{{
+					Dps: dist(dps, 200),
+					Hps: dist(0, 0),
+					Players: []*proto.UnitMetrics{{
+						Dps: dist(dps, 200), Dpasp: dist(0, 0), Threat: dist(0, 0), Dtps: dist(0, 0), Tmi: dist(0, 0), Hps: dist(0, 0), Tto: dist(0, 0),
+					}},
+				}},
+			},
+			EncounterMetrics: &proto.EncounterMetrics{},
+		}
+	}
+
+	bulk := &bulkSimRunner{
+		SingleRaidSimRunner: fakeRunSim,
+		Request: &proto.BulkSimRequest{
+			BaseSettings: &proto.RaidSimRequest{
+				Raid: &proto.Raid{
+					Parties: []*proto.Party{{Players: []*proto.Player{{Name: "player", Equipment: createEquipmentFromItems()}}}},
+				},
+				SimOptions: &proto.SimOptions{},
+			},
+			BulkSettings: &proto.BulkSettings{
+				Items:              items,
+				FastMode:           true,
+				IterationsPerCombo: iterations,
+			},
+		},
+	}
+
+	got, err := bulk.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+	if len(got.Results) != maxBulkResults {
+		t.Fatalf("Run() returned %d results, want %d", len(got.Results), maxBulkResults)
+	}
+	for i, result := range got.Results {
+		wantItem := int32(firstRaceItem + numRaceItems - 1 - i)
+		if gotItem := result.ItemsAdded[0].Item.Id; gotItem != wantItem {
+			t.Fatalf("result %d has item %d, want %d", i, gotItem, wantItem)
+		}
+	}
+
+	// The best items are simmed with the full iterations, clearly worse ones are dropped early.
+	// Refining only sims the extra iterations, so no iterations are simmed twice.
+	if simmedIterations[firstRaceItem+numRaceItems-1] != iterations {
+		t.Errorf("best item was simmed with %d iterations, want %d", simmedIterations[firstRaceItem+numRaceItems-1], iterations)
+	}
+	if dps := got.Results[0].UnitMetrics.Dps.Avg; math.Abs(dps-(numRaceItems-1)*10) > 1e-9 {
+		t.Errorf("best result has merged DPS %0.3f, want %0.3f", dps, float64(numRaceItems-1)*10)
+	}
+	if simmedIterations[firstRaceItem] >= iterations {
+		t.Errorf("worst item was simmed with %d iterations, want less than %d", simmedIterations[firstRaceItem], iterations)
+	}
+}
+
 func TestGenerateAllEquipmentSubstitutions(t *testing.T) {
 	baseItems := make([]*proto.ItemSpec, len(proto.ItemSlot_name))
 	for i := range baseItems {
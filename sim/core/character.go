@@ -131,6 +131,11 @@ func NewCharacter(party *Party, partyIndex int, player *proto.Player) Character
 	character.GCD = character.NewTimer()
 	character.RotationTimer = character.NewTimer()
 
+	if player.Position != nil {
+		startPosition := Vector2FromProto(player.Position)
+		character.startPosition = &startPosition
+	}
+
 	character.Label = fmt.Sprintf("%s (#%d)", character.Name, character.Index+1)
 
 	if player.Glyphs != nil {
@@ -502,6 +507,7 @@ func (character *Character) reset(sim *Simulation, agent Agent) {
 	character.Unit.reset(sim, agent)
 	character.majorCooldownManager.reset(sim)
 	character.CurrentTarget = character.defaultTarget
+	character.resetPosition()
 
 	agent.Reset(sim)
 
@@ -639,6 +645,7 @@ func (character *Character) GetMetricsProto() *proto.UnitMetrics {
 	metrics.Name = character.Name
 	metrics.UnitIndex = character.UnitIndex
 	metrics.Auras = character.auraTracker.GetMetricsProto()
+	metrics.AplItems = character.Rotation.getItemMetricsProto()
 
 	metrics.Pets = make([]*proto.UnitMetrics, len(character.Pets))
 	for i, pet := range character.Pets {
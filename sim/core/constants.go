@@ -10,6 +10,7 @@ const GCDMin = time.Second * 1
 const GCDDefault = time.Millisecond * 1500
 const MaxSpellQueueWindow = time.Millisecond * 400
 const MaxMeleeRange = 5.0 // in yards
+const MaxCastRange = 40.0 // in yards
 
 const DefaultAttackPowerPerDPS = 14.0
 
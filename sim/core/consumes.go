@@ -858,8 +858,9 @@ func registerExplosivesCD(agent Agent, consumes *proto.Consumes) {
 			ThreatMultiplier: 1,
 
 			ApplyEffects: func(sim *Simulation, target *Unit, spell *Spell) {
-				baseDamage := 5006 * sim.Encounter.AOECapMultiplier()
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				targets := sim.Encounter.TargetUnitsInRange(target.Position, 8)
+				baseDamage := 5006 * AOECapMultiplierForTargets(len(targets))
+				for _, aoeTarget := range targets {
 					spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 				}
 			},
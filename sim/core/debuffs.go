@@ -98,8 +98,7 @@ func applyDebuffEffects(target *Unit, targetIdx int, debuffs *proto.Debuffs, rai
 			TickImmediately: true,
 			Priority:        ActionPriorityDOT, // High prio so it comes before actual warrior sunders.
 			OnAction: func(sim *Simulation) {
-				aura.Activate(sim)
-				if aura.IsActive() {
+				if aura.Activate(sim) {
 					aura.AddStack(sim)
 				}
 			},
@@ -114,8 +113,7 @@ func applyDebuffEffects(target *Unit, targetIdx int, debuffs *proto.Debuffs, rai
 			TickImmediately: true,
 			Priority:        ActionPriorityDOT,
 			OnAction: func(sim *Simulation) {
-				aura.Activate(sim)
-				if aura.IsActive() {
+				if aura.Activate(sim) {
 					aura.AddStack(sim)
 				}
 			},
@@ -130,8 +128,7 @@ func applyDebuffEffects(target *Unit, targetIdx int, debuffs *proto.Debuffs, rai
 			TickImmediately: true,
 			Priority:        ActionPriorityDOT,
 			OnAction: func(sim *Simulation) {
-				aura.Activate(sim)
-				if aura.IsActive() {
+				if aura.Activate(sim) {
 					aura.AddStack(sim)
 				}
 			},
@@ -648,6 +645,7 @@ func ThunderClapAura(target *Unit) *Aura {
 		Label:    "ThunderClap",
 		ActionID: ActionID{SpellID: 6343},
 		Duration: time.Second * 30,
+		Mechanic: MechanicSlow,
 	})
 	AtkSpeedReductionEffect(aura, 1.2)
 	return aura
@@ -658,6 +656,7 @@ func InfectedWoundsAura(target *Unit, points int32) *Aura {
 		Label:    "InfectedWounds-" + strconv.Itoa(int(points)),
 		ActionID: ActionID{SpellID: 48485},
 		Duration: time.Second * 12,
+		Mechanic: MechanicSlow,
 	})
 	AtkSpeedReductionEffect(aura, 1+0.1*float64(points))
 	return aura
@@ -670,6 +669,7 @@ func JudgementsOfTheJustAura(target *Unit, points int32) *Aura {
 		Label:    "JudgementsOfTheJust-" + strconv.Itoa(int(points)),
 		ActionID: ActionID{SpellID: 53696},
 		Duration: time.Second * 30,
+		Mechanic: MechanicSlow,
 	})
 	AtkSpeedReductionEffect(aura, 1.0+0.1*float64(points))
 	return aura
@@ -680,21 +680,35 @@ func DustCloud(target *Unit) *Aura {
 		Label:    "Dust Cloud",
 		ActionID: ActionID{SpellID: 50285},
 		Duration: time.Second * 30,
+		Mechanic: MechanicSlow,
 	})
 	AtkSpeedReductionEffect(aura, 1.2)
 	return aura
 }
 
 func FrostFeverAura(target *Unit, britleBones int32) *Aura {
+	if britleBones == 0 {
+		aura := target.GetOrRegisterAura(Aura{
+			Label:    "FrostFeverDebuff",
+			ActionID: ActionID{SpellID: 55095},
+			Duration: NeverExpires,
+			Mechanic: MechanicSlow,
+		})
+		AtkSpeedReductionEffect(aura, 1.2)
+		return aura
+	}
+
+	// Brittle Bones still applies to targets immune to slows, only the slow is
+	// dropped.
 	aura := target.GetOrRegisterAura(Aura{
 		Label:    "FrostFeverDebuff",
 		ActionID: ActionID{SpellID: 55095},
 		Duration: NeverExpires,
 	})
-	AtkSpeedReductionEffect(aura, 1.2)
-	if britleBones > 0 {
-		PhysDamageTakenEffect(aura, 1+0.02*float64(britleBones))
+	if !target.IsImmuneToMechanic(MechanicSlow) {
+		AtkSpeedReductionEffect(aura, 1.2)
 	}
+	PhysDamageTakenEffect(aura, 1+0.02*float64(britleBones))
 	return aura
 }
 
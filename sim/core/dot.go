@@ -365,6 +365,9 @@ func (spell *Spell) createDots(config DotConfig, isHot bool) {
 	if auraConfig.ActionID.IsEmptyAction() {
 		auraConfig.ActionID = dot.Spell.ActionID
 	}
+	if auraConfig.Mechanic == MechanicNone {
+		auraConfig.Mechanic = dot.Spell.Mechanic
+	}
 
 	caster := dot.Spell.Unit
 	if config.IsAOE || config.SelfOnly {
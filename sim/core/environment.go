@@ -51,6 +51,7 @@ func NewEnvironment(raidProto *proto.Raid, encounterProto *proto.Encounter, runF
 		State: Created,
 	}
 
+	encounterProto = applyPresetTargetInputs(encounterProto)
 	env.construct(raidProto, encounterProto)
 	raidStats := env.initialize(raidProto, encounterProto)
 	env.finalize(raidProto, encounterProto, raidStats, runFakePrepull)
@@ -127,6 +128,15 @@ func (env *Environment) initialize(raidProto *proto.Raid, encounterProto *proto.
 			target.initialize(nil)
 		}
 	}
+	env.Encounter.initializeRaidDamageEvents(encounterProto.RaidDamageEvents)
+
+	var tanks []*Unit
+	for _, tankRef := range raidProto.Tanks {
+		if tank := env.GetUnit(tankRef, nil); tank != nil {
+			tanks = append(tanks, tank)
+		}
+	}
+	env.Encounter.initializeTargetGroups(tanks)
 
 	for _, party := range env.Raid.Parties {
 		for _, playerOrPet := range party.PlayersAndPets {
@@ -235,16 +245,14 @@ func (env *Environment) IsFinalized() bool {
 }
 
 func (env *Environment) reset(sim *Simulation) {
-	// Reset primary targets damage taken for tracking health fights.
-	env.Encounter.DamageTaken = 0
-
 	// Targets need to be reset before the raid, so that players can check for
 	// the presence of permanent target auras in their Reset handlers.
-	for _, target := range env.Encounter.Targets {
-		target.Reset(sim)
-	}
+	env.Encounter.reset(sim)
 
 	env.Raid.reset(sim)
+
+	// Targets which spawn later in the fight can't be attacked until then.
+	env.Encounter.retargetRaid(sim)
 }
 
 // The maximum possible duration for any iteration.
@@ -119,6 +119,9 @@ const (
 	OutcomePartial2
 	OutcomePartial4
 	OutcomePartial8
+
+	// Set instead of a hit roll when the target is immune to the spell.
+	OutcomeImmune
 )
 
 const (
@@ -131,7 +134,9 @@ var (
 )
 
 func (ho HitOutcome) String() string {
-	if ho.Matches(OutcomeMiss) {
+	if ho.Matches(OutcomeImmune) {
+		return "Immune"
+	} else if ho.Matches(OutcomeMiss) {
 		return "Miss"
 	} else if ho.Matches(OutcomeDodge) {
 		return "Dodge"
@@ -203,6 +208,7 @@ const (
 	SpellFlagCombatPotion                                   // Indicates this spell is the combat potion.
 	SpellFlagNoSpellMods                                    // Indicates that no spell mods should be applied to this spell
 	SpellFlagCanCastWhileMoving                             // Allows the cast to be casted while moving
+	SpellFlagInterruptible                                  // Casts of this spell can be interrupted by abilities like Kick
 
 	// Used to let agents categorize their spells.
 	SpellFlagAgentReserved1
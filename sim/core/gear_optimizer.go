@@ -0,0 +1,489 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+const (
+	defaultGearOptimizerCandidates = 40
+	defaultGearOptimizerResults    = 10
+
+	// Score penalty per gem missing to activate the meta gem. Large enough that
+	// any loadout with an active meta gem outranks one without.
+	metaGemMissingPenalty = 1e6
+)
+
+// gearOptimizerSlot holds every choice the gear optimizer may make for one
+// equipped item. The currently equipped choice is always included.
+type gearOptimizerSlot struct {
+	Slot     proto.ItemSlot
+	Reforges []int32
+	Enchants []int32
+	// Candidate gems for each socket, including extra sockets (e.g. belt buckle).
+	Gems [][]int32
+}
+
+// gearOptimizer searches reforges, gems and enchants of a fixed equipment set,
+// ranking loadouts by stat weights. Stats above their cap are valued at 0 and
+// loadouts whose meta gem would be inactive are rejected.
+type gearOptimizer struct {
+	BaseItems []*proto.ItemSpec
+	Slots     []*gearOptimizerSlot
+
+	Weights stats.Stats
+	// Rating caps per stat, 0 if the stat is uncapped.
+	Caps stats.Stats
+	// Character stats which don't come from equipment, used to check caps.
+	StatOffset stats.Stats
+}
+
+type gearLoadout struct {
+	Items []*proto.ItemSpec
+	Score float64
+	Valid bool
+}
+
+func (loadout *gearLoadout) key() string {
+	parts := make([]string, len(loadout.Items))
+	for i, spec := range loadout.Items {
+		gems := MapSlice(spec.Gems, func(gem int32) string { return strconv.Itoa(int(gem)) })
+		parts[i] = fmt.Sprintf("%d/%d/%d/%s", spec.Id, spec.Reforging, spec.Enchant, strings.Join(gems, ","))
+	}
+	return strings.Join(parts, ":")
+}
+
+func newGearOptimizer(settings *proto.BulkSettings, baseItems []*proto.ItemSpec) (*gearOptimizer, error) {
+	optSettings := settings.GearOptimizer
+
+	gemIDs := optSettings.Gems
+	if len(gemIDs) == 0 {
+		for _, gemID := range []int32{settings.DefaultRedGem, settings.DefaultYellowGem, settings.DefaultBlueGem, settings.DefaultMetaGem} {
+			if gemID != 0 {
+				gemIDs = append(gemIDs, gemID)
+			}
+		}
+	}
+	var metaGems, cogwheelGems, regularGems []int32
+	for _, gemID := range gemIDs {
+		gem, ok := GemsByID[gemID]
+		if !ok {
+			return nil, fmt.Errorf("unknown gem with id %d in gear optimizer settings", gemID)
+		}
+		switch gem.Color {
+		case proto.GemColor_GemColorMeta:
+			if _, ok := GetMetaGemCondition(gemID); !ok {
+				return nil, fmt.Errorf("no activation condition known for meta gem with id %d", gemID)
+			}
+			metaGems = append(metaGems, gemID)
+		case proto.GemColor_GemColorCogwheel:
+			cogwheelGems = append(cogwheelGems, gemID)
+		default:
+			regularGems = append(regularGems, gemID)
+		}
+	}
+
+	reforgeIDs := make([]int32, 0, len(ReforgeStatsByID))
+	for id := range ReforgeStatsByID {
+		reforgeIDs = append(reforgeIDs, id)
+	}
+	slices.Sort(reforgeIDs)
+
+	optimizer := &gearOptimizer{}
+	for slotIdx, spec := range baseItems {
+		optimizer.BaseItems = append(optimizer.BaseItems, goproto.Clone(spec).(*proto.ItemSpec))
+		if spec.Id == 0 {
+			continue
+		}
+		if _, ok := ItemsByID[spec.Id]; !ok {
+			return nil, fmt.Errorf("unknown item with id %d in equipment", spec.Id)
+		}
+		for _, gemID := range spec.Gems {
+			if gem, ok := GemsByID[gemID]; ok && gem.Color == proto.GemColor_GemColorMeta {
+				if _, ok := GetMetaGemCondition(gemID); !ok {
+					return nil, fmt.Errorf("no activation condition known for meta gem with id %d in equipment", gemID)
+				}
+			}
+		}
+
+		slot := &gearOptimizerSlot{
+			Slot:     proto.ItemSlot(slotIdx),
+			Reforges: []int32{spec.Reforging},
+			Enchants: []int32{spec.Enchant},
+		}
+
+		if optSettings.OptimizeReforges {
+			item := NewItem(ItemSpec{ID: spec.Id, RandomSuffix: spec.RandomSuffix})
+			slot.Reforges = []int32{0}
+			for _, id := range reforgeIDs {
+				if validateReforging(&item, ReforgeStatsByID[id]) {
+					slot.Reforges = append(slot.Reforges, id)
+				}
+			}
+			if !slices.Contains(slot.Reforges, spec.Reforging) {
+				slot.Reforges = append(slot.Reforges, spec.Reforging)
+			}
+		}
+
+		if optSettings.OptimizeEnchants {
+			for _, slotEnchants := range optSettings.Enchants {
+				if slotEnchants.Slot != slot.Slot {
+					continue
+				}
+				for _, enchantID := range slotEnchants.EnchantIds {
+					if !slices.Contains(slot.Enchants, enchantID) {
+						slot.Enchants = append(slot.Enchants, enchantID)
+					}
+				}
+			}
+		}
+
+		sockets := slices.Clone(ItemsByID[spec.Id].GemSockets)
+		// Extra sockets (belt buckle, blacksmithing) accept any regular gem.
+		for len(sockets) < len(spec.Gems) {
+			sockets = append(sockets, proto.GemColor_GemColorPrismatic)
+		}
+		slot.Gems = make([][]int32, len(sockets))
+		for i, color := range sockets {
+			current := int32(0)
+			if i < len(spec.Gems) {
+				current = spec.Gems[i]
+			}
+			slot.Gems[i] = []int32{current}
+			if !optSettings.OptimizeGems {
+				continue
+			}
+
+			candidates := regularGems
+			switch color {
+			case proto.GemColor_GemColorMeta:
+				candidates = metaGems
+			case proto.GemColor_GemColorCogwheel:
+				candidates = cogwheelGems
+			}
+			for _, gemID := range candidates {
+				if !slices.Contains(slot.Gems[i], gemID) {
+					slot.Gems[i] = append(slot.Gems[i], gemID)
+				}
+			}
+		}
+
+		optimizer.Slots = append(optimizer.Slots, slot)
+	}
+
+	for _, statCap := range optSettings.StatCaps {
+		optimizer.Caps[statCap.Stat] = statCap.Value
+	}
+	if optSettings.StatWeights != nil {
+		optimizer.Weights = stats.FromFloatArray(optSettings.StatWeights.Stats)
+	}
+
+	return optimizer, nil
+}
+
+// weighedStats returns all stats which can be changed by the optimizer.
+func (o *gearOptimizer) weighedStats() []proto.Stat {
+	var changed stats.Stats
+	addStats := func(s stats.Stats) {
+		for i, v := range s {
+			if v != 0 {
+				changed[i] = 1
+			}
+		}
+	}
+	for _, slot := range o.Slots {
+		spec := o.BaseItems[slot.Slot]
+		if len(slot.Reforges) > 1 {
+			item := NewItem(ItemSpec{ID: spec.Id, RandomSuffix: spec.RandomSuffix})
+			addStats(ItemEquipmentStats(item))
+			for _, id := range slot.Reforges {
+				for _, toStat := range ReforgeStatsByID[id].ToStat {
+					changed[toStat] = 1
+				}
+			}
+		}
+		for _, id := range slot.Enchants {
+			addStats(EnchantsByEffectID[id].Stats)
+		}
+		for _, socket := range slot.Gems {
+			for _, id := range socket {
+				addStats(GemsByID[id].Stats)
+			}
+		}
+		addStats(ItemsByID[spec.Id].SocketBonus)
+	}
+
+	var result []proto.Stat
+	for i, v := range changed {
+		if v != 0 {
+			result = append(result, proto.Stat(i))
+		}
+	}
+	return result
+}
+
+func (o *gearOptimizer) evaluate(loadout *gearLoadout) {
+	total := o.StatOffset
+	var colorCounts GemColorCounts
+	var metaGem Gem
+	for _, spec := range loadout.Items {
+		if spec.Id == 0 {
+			continue
+		}
+		item := NewItem(ItemSpec{
+			ID:           spec.Id,
+			RandomSuffix: spec.RandomSuffix,
+			Enchant:      spec.Enchant,
+			Gems:         spec.Gems,
+			Reforging:    spec.Reforging,
+		})
+		total = total.Add(ItemEquipmentStats(item))
+		for _, gem := range item.Gems {
+			if gem.Color == proto.GemColor_GemColorMeta {
+				metaGem = gem
+			}
+			colorCounts.AddGem(gem)
+		}
+	}
+
+	loadout.Score = 0
+	for i, weight := range o.Weights {
+		value := total[i]
+		if o.Caps[i] > 0 && value > o.Caps[i] {
+			value = o.Caps[i]
+		}
+		loadout.Score += value * weight
+	}
+
+	loadout.Valid = true
+	if metaGem.ID != 0 {
+		// Meta gems are checked for a known condition in newGearOptimizer.
+		condition, _ := GetMetaGemCondition(metaGem.ID)
+		if missing := condition.MissingGems(colorCounts); missing > 0 {
+			loadout.Score -= float64(missing) * metaGemMissingPenalty
+			loadout.Valid = false
+		}
+	}
+}
+
+// neighbors returns every loadout which differs from the given one by a
+// single reforge, gem or enchant.
+func (o *gearOptimizer) neighbors(loadout *gearLoadout) []*gearLoadout {
+	var result []*gearLoadout
+	withChange := func(slot proto.ItemSlot, change func(spec *proto.ItemSpec)) {
+		items := slices.Clone(loadout.Items)
+		items[slot] = goproto.Clone(items[slot]).(*proto.ItemSpec)
+		change(items[slot])
+		neighbor := &gearLoadout{Items: items}
+		o.evaluate(neighbor)
+		result = append(result, neighbor)
+	}
+
+	for _, slot := range o.Slots {
+		current := loadout.Items[slot.Slot]
+		for _, reforge := range slot.Reforges {
+			if reforge != current.Reforging {
+				withChange(slot.Slot, func(spec *proto.ItemSpec) { spec.Reforging = reforge })
+			}
+		}
+		for _, enchant := range slot.Enchants {
+			if enchant != current.Enchant {
+				withChange(slot.Slot, func(spec *proto.ItemSpec) { spec.Enchant = enchant })
+			}
+		}
+		for socketIdx, gems := range slot.Gems {
+			for _, gem := range gems {
+				if socketIdx < len(current.Gems) && gem == current.Gems[socketIdx] {
+					continue
+				}
+				withChange(slot.Slot, func(spec *proto.ItemSpec) {
+					for len(spec.Gems) <= socketIdx {
+						spec.Gems = append(spec.Gems, 0)
+					}
+					spec.Gems[socketIdx] = gem
+				})
+			}
+		}
+	}
+	return result
+}
+
+// search hill-climbs from a few starting loadouts and returns the best valid
+// loadouts seen along the way, ordered by score.
+func (o *gearOptimizer) search(numCandidates int) []*gearLoadout {
+	seen := map[string]*gearLoadout{}
+	remember := func(loadout *gearLoadout) {
+		if loadout.Valid {
+			seen[loadout.key()] = loadout
+		}
+	}
+
+	starts := []*gearLoadout{{Items: o.BaseItems}}
+	// Also start from a loadout without reforges, so a bad existing reforge
+	// setup doesn't trap the search around a cap.
+	unreforged := &gearLoadout{Items: make([]*proto.ItemSpec, len(o.BaseItems))}
+	for i, spec := range o.BaseItems {
+		unreforged.Items[i] = spec
+		if slot := o.slotFor(proto.ItemSlot(i)); slot != nil && spec.Reforging != 0 && slices.Contains(slot.Reforges, 0) {
+			unreforged.Items[i] = goproto.Clone(spec).(*proto.ItemSpec)
+			unreforged.Items[i].Reforging = 0
+		}
+	}
+	starts = append(starts, unreforged)
+
+	for _, current := range starts {
+		o.evaluate(current)
+		remember(current)
+		for {
+			var best *gearLoadout
+			for _, neighbor := range o.neighbors(current) {
+				remember(neighbor)
+				if best == nil || neighbor.Score > best.Score {
+					best = neighbor
+				}
+			}
+			if best == nil || best.Score <= current.Score {
+				break
+			}
+			current = best
+		}
+	}
+
+	candidates := make([]*gearLoadout, 0, len(seen))
+	for _, loadout := range seen {
+		candidates = append(candidates, loadout)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].key() < candidates[j].key()
+	})
+	if len(candidates) > numCandidates {
+		candidates = candidates[:numCandidates]
+	}
+	return candidates
+}
+
+func (o *gearOptimizer) slotFor(slot proto.ItemSlot) *gearOptimizerSlot {
+	for _, s := range o.Slots {
+		if s.Slot == slot {
+			return s
+		}
+	}
+	return nil
+}
+
+// optimizeGear runs the gear optimizer mode of the bulk sim: candidate loadouts
+// are ranked by stat weights and the best ones are then fully simmed.
+func (b *bulkSimRunner) optimizeGear(ctx context.Context, player *proto.Player, progress chan *proto.ProgressMetrics) (*proto.BulkSimResult, error) {
+	settings := b.Request.BulkSettings
+	optSettings := settings.GearOptimizer
+	if !optSettings.OptimizeReforges && !optSettings.OptimizeGems && !optSettings.OptimizeEnchants {
+		return nil, fmt.Errorf("gear optimizer: nothing to optimize")
+	}
+
+	optimizer, err := newGearOptimizer(settings, player.Equipment.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	statsResult := ComputeStats(&proto.ComputeStatsRequest{
+		Raid:      b.Request.BaseSettings.Raid,
+		Encounter: b.Request.BaseSettings.Encounter,
+	})
+	if statsResult.ErrorResult != "" {
+		return nil, fmt.Errorf("gear optimizer: %s", statsResult.ErrorResult)
+	}
+	finalStats := stats.FromFloatArray(statsResult.RaidStats.Parties[0].Players[0].FinalStats.Stats)
+	equipment := ProtoToEquipment(player.Equipment)
+	optimizer.StatOffset = finalStats.Subtract(equipment.Stats())
+
+	if optimizer.Weights.Equals(stats.Stats{}) {
+		weighedStats := optimizer.weighedStats()
+		if len(weighedStats) == 0 {
+			return nil, fmt.Errorf("gear optimizer: no stats can be changed")
+		}
+		raid := b.Request.BaseSettings.Raid
+		weightsRequest := &proto.StatWeightsRequest{
+			Player:          goproto.Clone(player).(*proto.Player),
+			RaidBuffs:       raid.Buffs,
+			PartyBuffs:      raid.Parties[0].Buffs,
+			Debuffs:         raid.Debuffs,
+			Encounter:       b.Request.BaseSettings.Encounter,
+			SimOptions:      goproto.Clone(b.Request.BaseSettings.SimOptions).(*proto.SimOptions),
+			Tanks:           raid.Tanks,
+			StatsToWeigh:    weighedStats,
+			EpReferenceStat: weighedStats[0],
+		}
+		weightsResult := CalcStatWeight(ctx, weightsRequest, stats.Stat(weighedStats[0]), nil)
+		if weightsResult.Cancelled {
+			return nil, ctx.Err()
+		}
+		optimizer.Weights = weightsResult.Dps.Weights.Stats
+	}
+
+	numCandidates := int(optSettings.CandidatesToSim)
+	if numCandidates <= 0 {
+		numCandidates = defaultGearOptimizerCandidates
+	}
+	loadouts := optimizer.search(numCandidates)
+
+	validCombos := []singleBulkSim{{
+		req: goproto.Clone(b.Request.BaseSettings).(*proto.RaidSimRequest),
+		cl:  &raidSimRequestChangeLog{},
+		eq:  &equipmentSubstitution{},
+	}}
+	baseKey := (&gearLoadout{Items: optimizer.BaseItems}).key()
+	for _, loadout := range loadouts {
+		if loadout.key() == baseKey {
+			continue
+		}
+		request := goproto.Clone(b.Request.BaseSettings).(*proto.RaidSimRequest)
+		request.Raid.Parties[0].Players[0].Equipment.Items = MapSlice(loadout.Items, func(spec *proto.ItemSpec) *proto.ItemSpec {
+			return goproto.Clone(spec).(*proto.ItemSpec)
+		})
+		sub := &equipmentSubstitution{}
+		changeLog := &raidSimRequestChangeLog{}
+		for i, spec := range loadout.Items {
+			if goproto.Equal(spec, optimizer.BaseItems[i]) {
+				continue
+			}
+			sub.Items = append(sub.Items, &itemWithSlot{Item: spec, Slot: proto.ItemSlot(i), Index: i})
+			changeLog.AddedItems = append(changeLog.AddedItems, &proto.ItemSpecWithSlot{Item: spec, Slot: proto.ItemSlot(i)})
+		}
+		validCombos = append(validCombos, singleBulkSim{req: request, cl: changeLog, eq: sub})
+	}
+
+	iterations := settings.IterationsPerCombo
+	if iterations <= 0 {
+		iterations = defaultIterationsPerCombo
+	}
+	if int64(iterations)*int64(len(validCombos)) > math.MaxInt32 {
+		return nil, fmt.Errorf("number of total iterations %d too large", int64(iterations)*int64(len(validCombos)))
+	}
+
+	rankedResults, baseResult, err := b.getRankedResults(ctx, validCombos, int64(iterations), progress)
+	if err != nil {
+		return nil, err
+	}
+	if baseResult == nil {
+		return nil, fmt.Errorf("no base result for equipped gear found in gear optimizer")
+	}
+
+	numResults := int(optSettings.NumResults)
+	if numResults <= 0 {
+		numResults = defaultGearOptimizerResults
+	}
+	return newBulkSimResult(rankedResults, baseResult, numResults), nil
+}
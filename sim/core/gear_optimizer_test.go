@@ -0,0 +1,165 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+const (
+	itemOptimizerRing   = 990001
+	itemOptimizerHelm   = 990002
+	gemOptimizerMeta    = 990100
+	gemOptimizerRed     = 990101
+	gemOptimizerYellow  = 990102
+	gemOptimizerBlue    = 990103
+	gemOptimizerGreen   = 990104
+	reforgeHitToHaste   = 9001
+	reforgeCritToHaste  = 9002
+	reforgeHasteToCrit  = 9003
+	optimizerStatAmount = 100
+)
+
+func init() {
+	ringStats := stats.Stats{stats.MeleeHit: optimizerStatAmount, stats.MeleeCrit: optimizerStatAmount}
+	addToDatabase(&proto.SimDatabase{
+		Items: []*proto.SimItem{
+			{Id: itemOptimizerRing, Type: proto.ItemType_ItemTypeFinger, Stats: ringStats.ToFloatArray()},
+			{Id: itemOptimizerHelm, Type: proto.ItemType_ItemTypeHead, GemSockets: []proto.GemColor{proto.GemColor_GemColorMeta, proto.GemColor_GemColorRed, proto.GemColor_GemColorBlue}},
+		},
+		Gems: []*proto.SimGem{
+			{Id: gemOptimizerMeta, Color: proto.GemColor_GemColorMeta, Stats: stats.Stats{stats.Agility: 10}.ToFloatArray()},
+			{Id: gemOptimizerRed, Color: proto.GemColor_GemColorRed, Stats: stats.Stats{stats.Agility: 40}.ToFloatArray()},
+			{Id: gemOptimizerYellow, Color: proto.GemColor_GemColorYellow, Stats: stats.Stats{stats.MeleeCrit: 40}.ToFloatArray()},
+			{Id: gemOptimizerBlue, Color: proto.GemColor_GemColorBlue, Stats: stats.Stats{stats.Stamina: 40}.ToFloatArray()},
+			{Id: gemOptimizerGreen, Color: proto.GemColor_GemColorGreen, Stats: stats.Stats{stats.MeleeCrit: 20, stats.Stamina: 30}.ToFloatArray()},
+		},
+		ReforgeStats: []*proto.ReforgeStat{
+			{Id: reforgeHitToHaste, FromStat: []proto.Stat{proto.Stat_StatMeleeHit}, ToStat: []proto.Stat{proto.Stat_StatMeleeHaste}, Multiplier: 0.4},
+			{Id: reforgeCritToHaste, FromStat: []proto.Stat{proto.Stat_StatMeleeCrit}, ToStat: []proto.Stat{proto.Stat_StatMeleeHaste}, Multiplier: 0.4},
+			{Id: reforgeHasteToCrit, FromStat: []proto.Stat{proto.Stat_StatMeleeHaste}, ToStat: []proto.Stat{proto.Stat_StatMeleeCrit}, Multiplier: 0.4},
+		},
+	})
+	metaGemConditions[gemOptimizerMeta] = MetaGemCondition{MinYellow: 1, MinBlue: 1}
+}
+
+func optimizerEquipment(items ...*itemWithSlot) []*proto.ItemSpec {
+	return createEquipmentFromItems(items...).Items
+}
+
+func loadoutStats(loadout *gearLoadout) stats.Stats {
+	equipment := ProtoToEquipment(&proto.EquipmentSpec{Items: loadout.Items})
+	return equipment.Stats()
+}
+
+func TestGearOptimizerHonoursCaps(t *testing.T) {
+	baseItems := optimizerEquipment(&itemWithSlot{Item: &proto.ItemSpec{Id: itemOptimizerRing}, Slot: proto.ItemSlot_ItemSlotFinger1})
+	weights := stats.Stats{stats.MeleeHit: 3, stats.MeleeCrit: 1, stats.MeleeHaste: 2}
+
+	for _, tc := range []struct {
+		comment  string
+		hitCap   float64
+		wantHit  float64
+		wantCrit float64
+	}{
+		{
+			comment:  "without a cap, hit is kept and crit is reforged",
+			wantHit:  optimizerStatAmount,
+			wantCrit: optimizerStatAmount * 0.6,
+		},
+		{
+			comment:  "hit above the cap is reforged away",
+			hitCap:   70,
+			wantHit:  optimizerStatAmount * 0.6,
+			wantCrit: optimizerStatAmount,
+		},
+	} {
+		optimizer, err := newGearOptimizer(&proto.BulkSettings{
+			GearOptimizer: &proto.GearOptimizerSettings{
+				Enabled:          true,
+				OptimizeReforges: true,
+				StatWeights:      &proto.UnitStats{Stats: weights.ToFloatArray()},
+				StatCaps:         []*proto.StatCap{{Stat: proto.Stat_StatMeleeHit, Value: tc.hitCap}},
+			},
+		}, baseItems)
+		if err != nil {
+			t.Fatalf("%s: newGearOptimizer() returned error: %v", tc.comment, err)
+		}
+		// Only consider the test reforges, in case the real database is loaded.
+		for _, slot := range optimizer.Slots {
+			slot.Reforges = slices.DeleteFunc(slot.Reforges, func(id int32) bool {
+				return id != 0 && id < reforgeHitToHaste
+			})
+		}
+
+		candidates := optimizer.search(5)
+		if len(candidates) == 0 {
+			t.Fatalf("%s: search() returned no candidates", tc.comment)
+		}
+		got := loadoutStats(candidates[0])
+		if got[stats.MeleeHit] != tc.wantHit || got[stats.MeleeCrit] != tc.wantCrit {
+			t.Fatalf("%s: best loadout has hit %0.0f and crit %0.0f, want hit %0.0f and crit %0.0f", tc.comment, got[stats.MeleeHit], got[stats.MeleeCrit], tc.wantHit, tc.wantCrit)
+		}
+	}
+}
+
+func TestGearOptimizerMetaGemRequirement(t *testing.T) {
+	baseItems := optimizerEquipment(&itemWithSlot{
+		Item: &proto.ItemSpec{Id: itemOptimizerHelm, Gems: []int32{gemOptimizerMeta, gemOptimizerRed, gemOptimizerRed}},
+		Slot: proto.ItemSlot_ItemSlotHead,
+	})
+	weights := stats.Stats{stats.Agility: 2, stats.MeleeCrit: 1, stats.Stamina: 0.1}
+
+	optimizer, err := newGearOptimizer(&proto.BulkSettings{
+		GearOptimizer: &proto.GearOptimizerSettings{
+			Enabled:      true,
+			OptimizeGems: true,
+			StatWeights:  &proto.UnitStats{Stats: weights.ToFloatArray()},
+			Gems:         []int32{gemOptimizerMeta, gemOptimizerRed, gemOptimizerYellow, gemOptimizerBlue, gemOptimizerGreen},
+		},
+	}, baseItems)
+	if err != nil {
+		t.Fatalf("newGearOptimizer() returned error: %v", err)
+	}
+
+	candidates := optimizer.search(5)
+	if len(candidates) == 0 {
+		t.Fatalf("search() returned no candidates")
+	}
+	for _, candidate := range candidates {
+		if !candidate.Valid {
+			t.Fatalf("search() returned loadout with inactive meta gem: %s", candidate.key())
+		}
+	}
+
+	// A green gem satisfies both the yellow and blue requirement, leaving the other socket for a red gem.
+	gotGems := candidates[0].Items[proto.ItemSlot_ItemSlotHead].Gems
+	wantGems := []int32{gemOptimizerMeta, gemOptimizerRed, gemOptimizerGreen}
+	for i := range wantGems {
+		if i >= len(gotGems) || gotGems[i] != wantGems[i] {
+			t.Fatalf("best loadout has gems %v, want %v", gotGems, wantGems)
+		}
+	}
+}
+
+func TestGearOptimizerUnknownMetaGem(t *testing.T) {
+	delete(metaGemConditions, gemOptimizerMeta)
+	defer func() { metaGemConditions[gemOptimizerMeta] = MetaGemCondition{MinYellow: 1, MinBlue: 1} }()
+
+	baseItems := optimizerEquipment(&itemWithSlot{
+		Item: &proto.ItemSpec{Id: itemOptimizerHelm, Gems: []int32{gemOptimizerMeta}},
+		Slot: proto.ItemSlot_ItemSlotHead,
+	})
+	_, err := newGearOptimizer(&proto.BulkSettings{
+		GearOptimizer: &proto.GearOptimizerSettings{
+			Enabled:      true,
+			OptimizeGems: true,
+			Gems:         []int32{gemOptimizerRed},
+		},
+	}, baseItems)
+	if err == nil {
+		t.Fatalf("expected an error for a meta gem without a known condition")
+	}
+}
@@ -101,7 +101,7 @@ func (hb *healthBar) UpdateMaxHealth(sim *Simulation, bonusHealth float64, metri
 var ChanceOfDeathAuraLabel = "Chance of Death"
 
 func (character *Character) trackChanceOfDeath(healingModel *proto.HealingModel) {
-	character.Unit.Metrics.isTanking = false
+	character.Unit.Metrics.isTanking = character.Env.Encounter.isSwapTank(&character.Unit)
 	for _, target := range character.Env.Encounter.TargetUnits {
 		if target.CurrentTarget == &character.Unit {
 			character.Unit.Metrics.isTanking = true
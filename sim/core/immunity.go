@@ -0,0 +1,92 @@
+package core
+
+import (
+	"slices"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Effect mechanics which a unit can be immune to.
+type SpellMechanic byte
+
+const (
+	MechanicNone  SpellMechanic = 0
+	MechanicBleed SpellMechanic = 1 << iota
+	MechanicSlow
+	MechanicStun
+)
+
+// Returns whether there is any overlap between the given masks.
+func (sm SpellMechanic) Matches(other SpellMechanic) bool {
+	return (sm & other) != 0
+}
+
+// Schools, mechanics and exclusive effect categories which a unit is immune to.
+// Spells against an immune unit have an OutcomeImmune result, and auras don't
+// activate on it.
+type Immunities struct {
+	Schools    SpellSchool
+	Mechanics  SpellMechanic
+	Categories []string
+}
+
+func ImmunitiesFromProto(options *proto.Target) Immunities {
+	immunities := Immunities{
+		Categories: slices.Clone(options.ImmuneDebuffCategories),
+	}
+	for _, school := range options.ImmuneSchools {
+		immunities.Schools |= SpellSchoolFromProto(school)
+	}
+
+	// Debuffs which only affect a mechanic are useless on targets immune to it.
+	if options.ImmuneToBleeds {
+		immunities.Mechanics |= MechanicBleed
+		immunities.Categories = append(immunities.Categories, BleedEffectCategory)
+	}
+	if options.ImmuneToSlows {
+		immunities.Mechanics |= MechanicSlow
+	}
+	if options.ImmuneToStuns {
+		immunities.Mechanics |= MechanicStun
+	}
+	return immunities
+}
+
+func (unit *Unit) IsImmuneToMechanic(mechanic SpellMechanic) bool {
+	return unit.Immunities.Mechanics.Matches(mechanic)
+}
+
+// Returns whether none of the schools of the spell can hit this unit.
+func (unit *Unit) IsImmuneToSchool(school SpellSchool) bool {
+	return school != SpellSchoolNone && school&^unit.Immunities.Schools == 0
+}
+
+func (unit *Unit) IsImmuneToCategory(categoryName string) bool {
+	return slices.Contains(unit.Immunities.Categories, categoryName)
+}
+
+func (unit *Unit) IsImmuneToSpell(spell *Spell) bool {
+	return unit.IsImmuneToMechanic(spell.Mechanic) || unit.IsImmuneToSchool(spell.SpellSchool)
+}
+
+func (unit *Unit) IsImmuneToAura(aura *Aura) bool {
+	if unit.IsImmuneToMechanic(aura.Mechanic) {
+		return true
+	}
+	if len(unit.Immunities.Categories) == 0 {
+		return false
+	}
+	for _, ee := range aura.ExclusiveEffects {
+		if unit.IsImmuneToCategory(ee.Category.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replaces the hit roll of a spell against an immune target.
+func (spell *Spell) applyImmuneOutcome(result *SpellResult) {
+	result.Outcome = OutcomeImmune
+	result.Damage = 0
+	spell.SpellMetrics[result.Target.UnitIndex].Immunes++
+}
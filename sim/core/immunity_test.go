@@ -0,0 +1,147 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	goproto "google.golang.org/protobuf/proto"
+)
+
+// Sums the metrics of the player's uses of an action against all targets.
+func playerActionMetrics(result *proto.RaidSimResult, actionID core.ActionID) *proto.TargetedActionMetrics {
+	total := &proto.TargetedActionMetrics{}
+	for _, action := range result.RaidMetrics.Parties[0].Players[0].Actions {
+		if core.ProtoToActionID(action.Id) != actionID {
+			continue
+		}
+		for _, target := range action.Targets {
+			total.Casts += target.Casts
+			total.Immunes += target.Immunes
+			total.Damage += target.Damage
+		}
+	}
+	return total
+}
+
+func targetAuraUptime(result *proto.RaidSimResult, targetIndex int, spellID int32) float64 {
+	for _, aura := range result.EncounterMetrics.Targets[targetIndex].Auras {
+		if aura.Id.GetSpellId() == spellID {
+			return aura.UptimeSecondsAvg
+		}
+	}
+	return 0
+}
+
+func runImmunityTest(t *testing.T, player *proto.Player, target *proto.Target) *proto.RaidSimResult {
+	rsr := makeTestCase(player)
+	rsr.SimOptions.Iterations = 5
+	rsr.Encounter.Targets = []*proto.Target{target}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	return result
+}
+
+func TestBleedImmunity(t *testing.T) {
+	const mangleID = 33876
+	rake := core.ActionID{SpellID: 1822}
+	rip := core.ActionID{SpellID: 1079}
+
+	result := runImmunityTest(t, getTestPlayerFeralCat(), newTestTarget())
+	if playerActionMetrics(result, rake).Casts == 0 || playerActionMetrics(result, rip).Casts == 0 {
+		t.Fatalf("expected the cat to use its bleeds against a regular target")
+	}
+
+	target := newTestTarget()
+	target.ImmuneToBleeds = true
+	result = runImmunityTest(t, getTestPlayerFeralCat(), target)
+	for _, bleed := range []core.ActionID{rake, rip} {
+		if metrics := playerActionMetrics(result, bleed); metrics.Casts != 0 || metrics.Damage != 0 {
+			t.Fatalf("expected no casts of %s against an immune target, got %d casts for %0.0f damage", bleed, metrics.Casts, metrics.Damage)
+		}
+	}
+	if uptime := targetAuraUptime(result, 0, mangleID); uptime != 0 {
+		t.Fatalf("expected the Mangle debuff to be rejected, got %0.1fs uptime", uptime)
+	}
+	if targetDamageTaken(result, 0) == 0 {
+		t.Fatalf("expected the cat to keep attacking an immune target")
+	}
+}
+
+func TestSchoolImmunity(t *testing.T) {
+	autoShot := core.ActionID{OtherID: proto.OtherAction_OtherActionShoot}
+	steadyShot := core.ActionID{SpellID: 56641}
+	arcaneShot := core.ActionID{SpellID: 3044}
+
+	// Stand at range, so that Steady Shot is only skipped for the immunity.
+	player := getTestPlayerMM()
+	player.DistanceFromTarget = 20
+	result := runImmunityTest(t, player, newTestTarget())
+	if metrics := playerActionMetrics(result, steadyShot); metrics.Casts == 0 {
+		t.Fatalf("expected Steady Shots against a regular target")
+	}
+
+	target := newTestTarget()
+	target.ImmuneSchools = []proto.SpellSchool{proto.SpellSchool_SpellSchoolPhysical}
+	result = runImmunityTest(t, player, target)
+
+	// Auto attacks don't check for immunities, but the rotation does.
+	if metrics := playerActionMetrics(result, autoShot); metrics.Immunes == 0 || metrics.Damage != 0 {
+		t.Fatalf("expected the target to be immune to Auto Shot, got %d immunes for %0.0f damage", metrics.Immunes, metrics.Damage)
+	}
+	if metrics := playerActionMetrics(result, steadyShot); metrics.Casts != 0 {
+		t.Fatalf("expected no Steady Shots against an immune target, got %d casts", metrics.Casts)
+	}
+	if metrics := playerActionMetrics(result, arcaneShot); metrics.Immunes != 0 || metrics.Damage == 0 {
+		t.Fatalf("expected Arcane Shot to hit, got %d immunes for %0.0f damage", metrics.Immunes, metrics.Damage)
+	}
+}
+
+func TestDebuffCategoryImmunity(t *testing.T) {
+	const sunderArmorID = 58567
+
+	result := runImmunityTest(t, getTestPlayerMM(), newTestTarget())
+	if targetAuraUptime(result, 0, sunderArmorID) == 0 {
+		t.Fatalf("expected Sunder Armor on a regular target")
+	}
+
+	target := newTestTarget()
+	target.ImmuneDebuffCategories = []string{"MajorArmorReduction"}
+	result = runImmunityTest(t, getTestPlayerMM(), target)
+	if uptime := targetAuraUptime(result, 0, sunderArmorID); uptime != 0 {
+		t.Fatalf("expected Sunder Armor to be rejected, got %0.1fs uptime", uptime)
+	}
+}
+
+func TestSlowImmunity(t *testing.T) {
+	const thunderClapID = 6343
+	const frostFeverID = 55095
+
+	player := getTestPlayerMM()
+	result := runImmunityTest(t, player, newTestTarget())
+	if targetAuraUptime(result, 0, thunderClapID) == 0 {
+		t.Fatalf("expected Thunder Clap on a regular target")
+	}
+
+	// Brittle Bones isn't a slow, so its Frost Fever debuff stays without the slow.
+	rsr := makeTestCase(player)
+	rsr.SimOptions.Iterations = 5
+	rsr.Raid.Debuffs = goproto.Clone(rsr.Raid.Debuffs).(*proto.Debuffs)
+	rsr.Raid.Debuffs.BrittleBones = true
+	target := newTestTarget()
+	target.ImmuneToSlows = true
+	rsr.Encounter.Targets = []*proto.Target{target}
+	result = core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	if uptime := targetAuraUptime(result, 0, thunderClapID); uptime != 0 {
+		t.Fatalf("expected Thunder Clap to be rejected, got %0.1fs uptime", uptime)
+	}
+	if targetAuraUptime(result, 0, frostFeverID) == 0 {
+		t.Fatalf("expected Brittle Bones on a target immune to slows")
+	}
+}
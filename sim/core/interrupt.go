@@ -0,0 +1,61 @@
+package core
+
+// Returns whether the unit is in the middle of a cast which can be interrupted.
+func (unit *Unit) IsCastingInterruptible(sim *Simulation) bool {
+	if unit.Hardcast.Expires <= sim.CurrentTime {
+		return false
+	}
+	spell := unit.GetSpell(unit.Hardcast.ActionID)
+	return spell != nil && spell.Flags.Matches(SpellFlagInterruptible)
+}
+
+// Interrupts the target's current cast, if it can be interrupted. The
+// interrupted spell has no effect but its cooldown keeps running, and the
+// target is free to act again right away. Returns whether a cast was
+// interrupted.
+func (spell *Spell) Interrupt(sim *Simulation, target *Unit) bool {
+	if !target.IsCastingInterruptible(sim) {
+		return false
+	}
+
+	if sim.Log != nil {
+		spell.Unit.Log(sim, "Interrupted %s cast of %s", target.Label, target.Hardcast.ActionID)
+	}
+
+	target.Hardcast = Hardcast{Expires: startingCDTime}
+	if target.hardcastAction != nil && !target.hardcastAction.consumed {
+		target.hardcastAction.Cancel(sim)
+		target.hardcastAction = nil
+	}
+	if target.GCD.ReadyAt() > sim.CurrentTime {
+		target.SetGCDTimer(sim, sim.CurrentTime)
+	}
+
+	spell.SpellMetrics[target.UnitIndex].Interrupts++
+	return true
+}
+
+// Returns the first dispellable aura active on the unit, or nil if there is none.
+func (unit *Unit) GetDispellableAura() *Aura {
+	for _, aura := range unit.activeAuras {
+		if aura.Dispellable {
+			return aura
+		}
+	}
+	return nil
+}
+
+// Removes a dispellable aura from the target, if it has one. Returns whether an
+// aura was removed.
+func (spell *Spell) Dispel(sim *Simulation, target *Unit) bool {
+	aura := target.GetDispellableAura()
+	if aura == nil {
+		return false
+	}
+
+	if sim.Log != nil {
+		spell.Unit.Log(sim, "Dispelled %s from %s", aura.Label, target.Label)
+	}
+	aura.Deactivate(sim)
+	return true
+}
@@ -0,0 +1,175 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+// Casts an interruptible bolt at the first player every 5 seconds from 1 second, and a
+// dispellable enrage every 20 seconds from 3 seconds.
+type castingAI struct {
+	Target *core.Target
+	bolt   *core.Spell
+	enrage *core.Spell
+}
+
+func (ai *castingAI) Initialize(target *core.Target, _ *proto.Target) {
+	ai.Target = target
+	ai.bolt = target.RegisterSpell(core.SpellConfig{
+		ActionID:         core.ActionID{SpellID: 990710},
+		SpellSchool:      core.SpellSchoolShadow,
+		ProcMask:         core.ProcMaskSpellDamage,
+		Flags:            core.SpellFlagInterruptible,
+		DamageMultiplier: 1,
+		Cast: core.CastConfig{
+			IgnoreHaste: true,
+			DefaultCast: core.Cast{
+				CastTime: time.Second * 2,
+			},
+		},
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			spell.CalcAndDealDamage(sim, target, 1000, spell.OutcomeAlwaysHit)
+		},
+	})
+	enrageAura := target.RegisterAura(core.Aura{
+		Label:       "Enrage",
+		ActionID:    core.ActionID{SpellID: 990711},
+		Duration:    time.Second * 30,
+		Dispellable: true,
+	})
+	ai.enrage = target.RegisterSpell(core.SpellConfig{
+		ActionID: core.ActionID{SpellID: 990711},
+		ApplyEffects: func(sim *core.Simulation, _ *core.Unit, _ *core.Spell) {
+			enrageAura.Activate(sim)
+		},
+	})
+}
+func (ai *castingAI) Reset(sim *core.Simulation) {
+	castEvery := func(spell *core.Spell, delay time.Duration, period time.Duration) {
+		core.StartDelayedAction(sim, core.DelayedActionOptions{
+			DoAt: delay,
+			OnAction: func(sim *core.Simulation) {
+				spell.Cast(sim, sim.Raid.AllPlayerUnits[0])
+				core.StartPeriodicAction(sim, core.PeriodicActionOptions{
+					Period: period,
+					OnAction: func(sim *core.Simulation) {
+						spell.Cast(sim, sim.Raid.AllPlayerUnits[0])
+					},
+				})
+			},
+		})
+	}
+	castEvery(ai.bolt, time.Second, time.Second*5)
+	castEvery(ai.enrage, time.Second*3, time.Second*20)
+}
+func (ai *castingAI) ExecuteCustomRotation(_ *core.Simulation) {}
+
+// Interrupts and dispels the first target whenever it can.
+type interruptingAI struct {
+	Target    *core.Target
+	interrupt *core.Spell
+	dispel    *core.Spell
+	dispels   int
+}
+
+func (ai *interruptingAI) Initialize(target *core.Target, _ *proto.Target) {
+	ai.Target = target
+	ai.interrupt = target.RegisterSpell(core.SpellConfig{
+		ActionID: core.ActionID{SpellID: 990720},
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			spell.Interrupt(sim, target)
+		},
+	})
+	ai.dispel = target.RegisterSpell(core.SpellConfig{
+		ActionID: core.ActionID{SpellID: 990721},
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			if spell.Dispel(sim, target) {
+				ai.dispels++
+			}
+		},
+	})
+}
+func (ai *interruptingAI) Reset(sim *core.Simulation) {
+	core.StartPeriodicAction(sim, core.PeriodicActionOptions{
+		Period: time.Millisecond * 500,
+		OnAction: func(sim *core.Simulation) {
+			boss := &sim.Encounter.Targets[0].Unit
+			if boss.IsCastingInterruptible(sim) {
+				ai.interrupt.Cast(sim, boss)
+			}
+			if boss.GetDispellableAura() != nil {
+				ai.dispel.Cast(sim, boss)
+			}
+		},
+	})
+}
+func (ai *interruptingAI) ExecuteCustomRotation(_ *core.Simulation) {}
+
+func TestInterruptsAndDispels(t *testing.T) {
+	const (
+		bossID        = 990700
+		interrupterID = 990701
+		boltID        = 990710
+	)
+
+	boss := newTestTarget()
+	boss.Id = bossID
+	boss.Name = "Casting Boss"
+	boss.Stats[stats.Health] = 1_000_000_000
+
+	setTestPreset(&core.PresetTarget{
+		Config: boss,
+		AI: func() core.TargetAI {
+			return &castingAI{}
+		},
+	})
+
+	interrupter := newTestTarget()
+	interrupter.Id = interrupterID
+	interrupter.Name = "Interrupter"
+	interrupter.TankIndex = -1
+	var ai *interruptingAI
+	setTestPreset(&core.PresetTarget{
+		Config: interrupter,
+		AI: func() core.TargetAI {
+			ai = &interruptingAI{}
+			return ai
+		},
+	})
+
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 5
+	rsr.Encounter.Duration = 60
+	rsr.Encounter.Targets = []*proto.Target{boss, interrupter}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	// Interrupted casts never complete, so they aren't counted as casts.
+	bolts := targetActionMetrics(result, 0, boltID)
+	if bolts.Casts != 0 || bolts.Damage != 0 {
+		t.Fatalf("expected every bolt to be interrupted, but %d were cast for %0.0f damage", bolts.Casts, bolts.Damage)
+	}
+
+	interrupts := int32(0)
+	for _, action := range result.EncounterMetrics.Targets[1].Actions {
+		for _, target := range action.Targets {
+			interrupts += target.Interrupts
+		}
+	}
+	// A bolt is started every 5 seconds.
+	if interrupts < 11*rsr.SimOptions.Iterations {
+		t.Fatalf("expected every bolt to be interrupted, got %d interrupts", interrupts)
+	}
+
+	// The enrage is cast at 3, 23 and 43 seconds.
+	if ai.dispels != 3*int(rsr.SimOptions.Iterations) {
+		t.Fatalf("expected the enrage to be dispelled each time it was cast, got %d dispels", ai.dispels)
+	}
+}
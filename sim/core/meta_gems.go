@@ -0,0 +1,141 @@
+package core
+
+import (
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// MetaGemCondition is the socket color requirement for activating a meta gem.
+// This needs to stay synced with the conditions in proto_utils/gems.ts.
+type MetaGemCondition struct {
+	MinRed    int
+	MinYellow int
+	MinBlue   int
+
+	// For conditions like "more red gems than blue gems", unset otherwise.
+	CompareColorGreater proto.GemColor
+	CompareColorLesser  proto.GemColor
+}
+
+// Keep these in order by item ID.
+var metaGemConditions = map[int32]MetaGemCondition{
+	25890: {MinRed: 2, MinYellow: 2, MinBlue: 2},                                                                 // Destructive Skyfire Diamond
+	25893: {CompareColorGreater: proto.GemColor_GemColorBlue, CompareColorLesser: proto.GemColor_GemColorYellow}, // Mystical Skyfire Diamond
+	25894: {MinRed: 1, MinYellow: 2},                                                                             // Swift Skyfire Diamond
+	25895: {CompareColorGreater: proto.GemColor_GemColorRed, CompareColorLesser: proto.GemColor_GemColorYellow},  // Enigmatic Skyfire Diamond
+	25896: {MinBlue: 3},                                                                                          // Powerful Earthstorm Diamond
+	25897: {CompareColorGreater: proto.GemColor_GemColorRed, CompareColorLesser: proto.GemColor_GemColorBlue},    // Bracing Earthstorm Diamond
+	25898: {MinBlue: 5},                                                                                          // Tenacious Earthstorm Diamond
+	25899: {MinRed: 2, MinYellow: 2, MinBlue: 2},                                                                 // Brutal Earthstorm Diamond
+	25901: {MinRed: 2, MinYellow: 2, MinBlue: 2},                                                                 // Insightful Earthstorm Diamond
+	28556: {MinRed: 1, MinYellow: 2},                                                                             // Swift Windfire Diamond
+	28557: {MinRed: 1, MinYellow: 2},                                                                             // Swift Starfire Diamond
+	32409: {MinRed: 2, MinYellow: 2, MinBlue: 2},                                                                 // Relentless Earthstorm Diamond
+	32410: {MinRed: 2, MinYellow: 2, MinBlue: 2},                                                                 // Thundering Skyfire Diamond
+	32640: {CompareColorGreater: proto.GemColor_GemColorBlue, CompareColorLesser: proto.GemColor_GemColorYellow}, // Potent Unstable Diamond
+	32641: {MinYellow: 3},                                                                                        // Imbued Unstable Diamond
+	34220: {MinBlue: 2},                                                                                          // Chaotic Skyfire Diamond
+	35501: {MinYellow: 1, MinBlue: 2},                                                                            // Eternal Earthstorm Diamond
+	35503: {MinRed: 3},                                                                                           // Ember Skyfire Diamond
+	41285: {MinRed: 3},                                                                                           // Chaotic Skyflare Diamond
+	41307: {MinRed: 1, MinYellow: 1, MinBlue: 1},                                                                 // Destructive Skyflare Diamond
+	41333: {MinRed: 3},                                                                                           // Ember Skyflare Diamond
+	41335: {MinRed: 2, MinYellow: 1},                                                                             // Enigmatic Skyflare Diamond
+	41339: {MinRed: 1, MinYellow: 2},                                                                             // Swift Skyflare Diamond
+	41375: {MinRed: 1, MinYellow: 1, MinBlue: 1},                                                                 // Tireless Skyflare Diamond
+	41376: {MinRed: 2},                                                                                           // Revitalizing Skyflare Diamond
+	41377: {MinRed: 1, MinBlue: 2},                                                                               // Effulgent Skyflare Diamond
+	41378: {MinYellow: 2, MinBlue: 1},                                                                            // Forlorn Skyflare Diamond
+	41379: {MinRed: 2, MinBlue: 1},                                                                               // Impassive Skyflare Diamond
+	41380: {MinRed: 1, MinBlue: 2},                                                                               // Austere Earthsiege Diamond
+	41381: {MinYellow: 2, MinBlue: 1},                                                                            // Persistent Earthsiege Diamond
+	41382: {MinRed: 1, MinYellow: 1, MinBlue: 1},                                                                 // Trenchant Earthsiege Diamond
+	41385: {MinRed: 1, MinBlue: 2},                                                                               // Invigorating Earthsiege Diamond
+	41389: {MinRed: 2, MinYellow: 1},                                                                             // Beaming Earthsiege Diamond
+	41395: {MinRed: 2, MinBlue: 1},                                                                               // Bracing Earthsiege Diamond
+	41396: {MinRed: 2, MinBlue: 1},                                                                               // Eternal Earthsiege Diamond
+	41397: {MinBlue: 3},                                                                                          // Powerful Earthsiege Diamond
+	41398: {MinRed: 3},                                                                                           // Relentless Earthsiege Diamond
+	41400: {MinRed: 1, MinYellow: 1, MinBlue: 1},                                                                 // Thundering Skyflare Diamond
+	41401: {MinRed: 1, MinYellow: 1, MinBlue: 1},                                                                 // Insightful Earthsiege Diamond
+	44076: {MinRed: 1, MinYellow: 2},                                                                             // Swift Starflare Diamond
+	44078: {MinRed: 1, MinYellow: 1, MinBlue: 1},                                                                 // Tireless Starflare Diamond
+	44081: {MinRed: 2, MinBlue: 1},                                                                               // Enigmatic Starflare Diamond
+	44082: {MinRed: 1, MinBlue: 2},                                                                               // Impassive Starflare Diamond
+	44084: {MinYellow: 2, MinBlue: 1},                                                                            // Forlorn Starflare Diamond
+	44087: {MinBlue: 3},                                                                                          // Persistent Earthshatter Diamond
+	44088: {MinYellow: 1, MinBlue: 2},                                                                            // Powerful Earthshatter Diamond
+	44089: {MinRed: 1, MinYellow: 1, MinBlue: 1},                                                                 // Trenchant Earthshatter Diamond
+	52289: {MinYellow: 2},                                                                                        // Fleet Shadowspirit Diamond
+	52291: {MinRed: 3},                                                                                           // Chaotic Shadowspirit Diamond
+	52292: {MinYellow: 1, MinBlue: 1},                                                                            // Bracing Shadowspirit Diamond
+	52293: {MinBlue: 3},                                                                                          // Eternal Shadowspirit Diamond
+	52294: {MinYellow: 2},                                                                                        // Austere Shadowspirit Diamond
+	52295: {MinRed: 1, MinYellow: 1},                                                                             // Effulgent Shadowspirit Diamond
+	52296: {MinYellow: 2},                                                                                        // Ember Shadowspirit Diamond
+	52297: {MinYellow: 1, MinBlue: 1},                                                                            // Revitalizing Shadowspirit Diamond
+	52298: {MinRed: 2},                                                                                           // Destructive Shadowspirit Diamond
+	52299: {MinBlue: 2},                                                                                          // Powerful Shadowspirit Diamond
+	52300: {MinYellow: 1, MinBlue: 1},                                                                            // Enigmatic Shadowspirit Diamond
+	52301: {MinYellow: 1, MinBlue: 1},                                                                            // Impassive Shadowspirit Diamond
+	52302: {MinYellow: 1, MinBlue: 1},                                                                            // Forlorn Shadowspirit Diamond
+	68778: {MinRed: 3},                                                                                           // Agile Shadowspirit Diamond
+	68779: {MinRed: 3},                                                                                           // Reverberating Shadowspirit Diamond
+	68780: {MinRed: 3},                                                                                           // Burning Shadowspirit Diamond
+}
+
+// GetMetaGemCondition returns the activation condition for a meta gem, and
+// false if the gem has no known condition.
+func GetMetaGemCondition(gemID int32) (MetaGemCondition, bool) {
+	condition, ok := metaGemConditions[gemID]
+	return condition, ok
+}
+
+// GemColorCounts counts socketed gems by the socket colors they match.
+// Hybrid gems count towards both of their colors.
+type GemColorCounts struct {
+	Red    int
+	Yellow int
+	Blue   int
+}
+
+func (counts *GemColorCounts) AddGem(gem Gem) {
+	if gem.Color == proto.GemColor_GemColorMeta || gem.Color == proto.GemColor_GemColorCogwheel || gem.ID == 0 {
+		return
+	}
+	if ColorIntersects(proto.GemColor_GemColorRed, gem.Color) {
+		counts.Red++
+	}
+	if ColorIntersects(proto.GemColor_GemColorYellow, gem.Color) {
+		counts.Yellow++
+	}
+	if ColorIntersects(proto.GemColor_GemColorBlue, gem.Color) {
+		counts.Blue++
+	}
+}
+
+func (counts GemColorCounts) ofColor(color proto.GemColor) int {
+	switch color {
+	case proto.GemColor_GemColorRed:
+		return counts.Red
+	case proto.GemColor_GemColorYellow:
+		return counts.Yellow
+	case proto.GemColor_GemColorBlue:
+		return counts.Blue
+	}
+	return 0
+}
+
+// MissingGems returns how many more gems are needed to activate the meta gem.
+func (condition MetaGemCondition) MissingGems(counts GemColorCounts) int {
+	missing := max(0, condition.MinRed-counts.Red) +
+		max(0, condition.MinYellow-counts.Yellow) +
+		max(0, condition.MinBlue-counts.Blue)
+	if condition.CompareColorGreater != proto.GemColor_GemColorUnknown {
+		missing += max(0, counts.ofColor(condition.CompareColorLesser)-counts.ofColor(condition.CompareColorGreater)+1)
+	}
+	return missing
+}
+
+func (condition MetaGemCondition) IsMet(counts GemColorCounts) bool {
+	return condition.MissingGems(counts) == 0
+}
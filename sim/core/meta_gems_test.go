@@ -0,0 +1,58 @@
+package core
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// The conditions have to match the ones the UI shows.
+func TestMetaGemConditionsMatchUI(t *testing.T) {
+	data, err := os.ReadFile("../../ui/core/proto_utils/gems.ts")
+	if err != nil {
+		t.Fatalf("failed to read gems.ts: %s", err)
+	}
+
+	minColors := regexp.MustCompile(`MetaGemCondition\.fromMinColors\((\d+), '[^']*', (\d+), (\d+), (\d+)\)`)
+	compareColors := regexp.MustCompile(`MetaGemCondition\.fromCompareColors\((\d+), '[^']*', GemColor\.(\w+), GemColor\.(\w+)\)`)
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	expected := make(map[int32]MetaGemCondition)
+	for _, match := range minColors.FindAllStringSubmatch(string(data), -1) {
+		expected[int32(atoi(match[1]))] = MetaGemCondition{MinRed: atoi(match[2]), MinYellow: atoi(match[3]), MinBlue: atoi(match[4])}
+	}
+	for _, match := range compareColors.FindAllStringSubmatch(string(data), -1) {
+		expected[int32(atoi(match[1]))] = MetaGemCondition{
+			CompareColorGreater: proto.GemColor(proto.GemColor_value[match[2]]),
+			CompareColorLesser:  proto.GemColor(proto.GemColor_value[match[3]]),
+		}
+	}
+
+	for gemID := range metaGemConditions {
+		if _, ok := expected[gemID]; !ok && gemID != gemOptimizerMeta {
+			t.Fatalf("meta gem %d has a condition which the UI doesn't know", gemID)
+		}
+	}
+	for gemID, condition := range expected {
+		if got, ok := GetMetaGemCondition(gemID); !ok || got != condition {
+			t.Fatalf("meta gem %d has condition %+v, want %+v", gemID, got, condition)
+		}
+	}
+}
+
+func TestMetaGemCompareColors(t *testing.T) {
+	// Bracing Earthstorm Diamond needs more red gems than blue gems.
+	condition, _ := GetMetaGemCondition(25897)
+	if missing := condition.MissingGems(GemColorCounts{Red: 2, Blue: 2}); missing != 1 {
+		t.Fatalf("expected 1 missing red gem with as many red as blue gems, got %d", missing)
+	}
+	if !condition.IsMet(GemColorCounts{Red: 3, Blue: 2}) {
+		t.Fatalf("expected the condition to be met with more red than blue gems")
+	}
+}
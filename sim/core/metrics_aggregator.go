@@ -155,6 +155,12 @@ type SpellMetrics struct {
 	Parries int32
 	Blocks  int32
 
+	// Number of the target's casts this spell interrupted.
+	Interrupts int32
+
+	// Number of times the target was immune to this spell.
+	Immunes int32
+
 	TotalDamage    float64 // Damage done by all casts of this spell.
 	TotalThreat    float64 // Threat generated by all casts of this spell.
 	TotalHealing   float64 // Healing done by all casts of this spell.
@@ -174,6 +180,9 @@ type TargetedActionMetrics struct {
 	Blocks  int32
 	Glances int32
 
+	Interrupts int32
+	Immunes    int32
+
 	Damage    float64
 	Threat    float64
 	Healing   float64
@@ -193,6 +202,8 @@ func (tam *TargetedActionMetrics) ToProto() *proto.TargetedActionMetrics {
 		Parries:    tam.Parries,
 		Blocks:     tam.Blocks,
 		Glances:    tam.Glances,
+		Interrupts: tam.Interrupts,
+		Immunes:    tam.Immunes,
 		Damage:     tam.Damage,
 		Threat:     tam.Threat,
 		Healing:    tam.Healing,
@@ -330,6 +341,8 @@ func (unitMetrics *UnitMetrics) addSpellMetrics(spell *Spell, actionID ActionID,
 		tam.Parries += spellTargetMetrics.Parries
 		tam.Blocks += spellTargetMetrics.Blocks
 		tam.Glances += spellTargetMetrics.Glances
+		tam.Interrupts += spellTargetMetrics.Interrupts
+		tam.Immunes += spellTargetMetrics.Immunes
 		tam.Damage += spellTargetMetrics.TotalDamage
 		tam.Threat += spellTargetMetrics.TotalThreat
 		tam.Healing += spellTargetMetrics.TotalHealing
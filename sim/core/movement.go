@@ -1,7 +1,6 @@
 package core
 
 import (
-	"math"
 	"time"
 
 	"github.com/wowsims/cata/sim/core/proto"
@@ -9,13 +8,22 @@ import (
 
 type MovementAction struct {
 	PendingAction
-	srcPosition float64       // starting position
+	srcPosition Vector2       // starting position
+	dstPosition Vector2       // position the unit is moving to
 	startTime   time.Duration // starting time of the movement
 	speed       float64       // theoretical movement speed, can be 0
 }
 
-func (action *MovementAction) GetCurrentPosition(sim *Simulation) float64 {
-	return action.srcPosition + float64(sim.CurrentTime-action.startTime)*action.speed/float64(time.Second)
+func (action *MovementAction) GetCurrentPosition(sim *Simulation) Vector2 {
+	if action.speed == 0 {
+		return action.dstPosition
+	}
+
+	traveled := float64(sim.CurrentTime-action.startTime) * action.speed / float64(time.Second)
+	if traveled >= action.srcPosition.DistanceTo(action.dstPosition) {
+		return action.dstPosition
+	}
+	return action.srcPosition.Add(action.srcPosition.DirectionTo(action.dstPosition).Scale(traveled))
 }
 
 func (unit *Unit) initMovement() {
@@ -40,20 +48,31 @@ func (unit *Unit) initMovement() {
 
 		ApplyEffects: func(sim *Simulation, target *Unit, spell *Spell) {
 			unit.moveAura.Activate(sim)
-			unit.moveAura.SetStacks(sim, max(int32(unit.DistanceFromTarget), 1))
+			unit.moveAura.SetStacks(sim, max(int32(unit.DistanceFromTarget()), 1))
 		},
 	})
 }
 
+// Moves the unit straight towards or away from its current target, until it is
+// moveRange yards away from it.
 func (unit *Unit) MoveTo(moveRange float64, sim *Simulation) {
-	if moveRange == unit.DistanceFromTarget {
+	if moveRange == unit.DistanceFromTarget() {
 		return
 	}
 
 	unit.UpdatePosition(sim)
-	moveDistance := moveRange - unit.DistanceFromTarget
-	timeToMove := time.Duration(math.Abs(moveDistance)/unit.GetMovementSpeed()*1000) * time.Millisecond
-	registerMovementAction(unit, sim, unit.GetMovementSpeed()*TernaryFloat64(moveDistance < 0, -1., 1.), sim.CurrentTime+timeToMove)
+	unit.MoveToPosition(unit.PositionAtRange(moveRange), sim)
+}
+
+func (unit *Unit) MoveToPosition(position Vector2, sim *Simulation) {
+	unit.UpdatePosition(sim)
+	moveDistance := unit.Position.DistanceTo(position)
+	if moveDistance == 0 {
+		return
+	}
+
+	timeToMove := time.Duration(moveDistance/unit.GetMovementSpeed()*1000) * time.Millisecond
+	registerMovementAction(unit, sim, unit.GetMovementSpeed(), position, sim.CurrentTime+timeToMove)
 }
 
 func (unit *Unit) MoveDuration(duration time.Duration, sim *Simulation) {
@@ -62,7 +81,7 @@ func (unit *Unit) MoveDuration(duration time.Duration, sim *Simulation) {
 	}
 
 	unit.UpdatePosition(sim)
-	registerMovementAction(unit, sim, 0., sim.CurrentTime+duration)
+	registerMovementAction(unit, sim, 0., unit.Position, sim.CurrentTime+duration)
 }
 
 func (unit *Unit) UpdatePosition(sim *Simulation) {
@@ -70,15 +89,23 @@ func (unit *Unit) UpdatePosition(sim *Simulation) {
 		return
 	}
 
-	oldDist := unit.DistanceFromTarget
-	unit.DistanceFromTarget = unit.movementAction.GetCurrentPosition(sim)
-	if oldDist == unit.DistanceFromTarget {
+	oldPosition := unit.Position
+	unit.Position = unit.movementAction.GetCurrentPosition(sim)
+	if oldPosition == unit.Position {
 		return
 	}
 
-	unit.OnMovement(unit.DistanceFromTarget, MovementUpdate)
+	unit.OnMovement(unit.DistanceFromTarget(), MovementUpdate)
+	unit.updateAutoAttackRange(sim)
 
-	// update auto attack state
+	yards := max(int32(unit.DistanceFromTarget()), 1) // never set to 0 yards as we deactivate the aura
+	if yards != unit.moveAura.GetStacks() {
+		unit.moveAura.SetStacks(sim, yards)
+	}
+}
+
+// Starts or stops auto attacks depending on whether the unit is in range of its target.
+func (unit *Unit) updateAutoAttackRange(sim *Simulation) {
 	if unit.AutoAttacks.mh.enabled != unit.AutoAttacks.mh.IsInRange() {
 		if unit.AutoAttacks.mh.IsInRange() {
 			unit.AutoAttacks.EnableMeleeSwing(sim)
@@ -94,11 +121,6 @@ func (unit *Unit) UpdatePosition(sim *Simulation) {
 			unit.AutoAttacks.CancelRangedSwing(sim)
 		}
 	}
-
-	yards := max(int32(unit.DistanceFromTarget), 1) // never set to 0 yards as we deactivate the aura
-	if yards != unit.moveAura.GetStacks() {
-		unit.moveAura.SetStacks(sim, yards)
-	}
 }
 
 func (unit *Unit) FinalizeMovement(sim *Simulation) {
@@ -109,10 +131,10 @@ func (unit *Unit) FinalizeMovement(sim *Simulation) {
 	unit.UpdatePosition(sim)
 	unit.moveAura.Deactivate(sim)
 
-	unit.OnMovement(unit.DistanceFromTarget, MovementEnd)
+	unit.OnMovement(unit.DistanceFromTarget(), MovementEnd)
 }
 
-func registerMovementAction(unit *Unit, sim *Simulation, speed float64, endTime time.Duration) {
+func registerMovementAction(unit *Unit, sim *Simulation, speed float64, dstPosition Vector2, endTime time.Duration) {
 	if unit.movementAction != nil {
 		unit.movementAction.Cancel(sim)
 	} else {
@@ -122,7 +144,8 @@ func registerMovementAction(unit *Unit, sim *Simulation, speed float64, endTime
 	movementAction := MovementAction{
 		startTime:   sim.CurrentTime,
 		speed:       speed,
-		srcPosition: unit.DistanceFromTarget,
+		srcPosition: unit.Position,
+		dstPosition: dstPosition,
 	}
 
 	movementAction.NextActionAt = endTime
@@ -130,7 +153,7 @@ func registerMovementAction(unit *Unit, sim *Simulation, speed float64, endTime
 		unit.FinalizeMovement(sim)
 	}
 
-	unit.OnMovement(unit.DistanceFromTarget, MovementStart)
+	unit.OnMovement(unit.DistanceFromTarget(), MovementStart)
 	unit.movementAction = &movementAction
 	sim.AddPendingAction(&movementAction.PendingAction)
 }
@@ -160,8 +183,7 @@ func (unit *Unit) MultiplyMovementSpeed(sim *Simulation, amount float64) {
 
 	// we have a pending movement action that depends on our movement speed
 	if unit.movementAction != nil && unit.movementAction.speed != 0 {
-		dest := unit.movementAction.speed * float64(unit.movementAction.NextActionAt-unit.movementAction.startTime) / float64(time.Second)
-		unit.MoveTo(dest, sim)
+		unit.MoveToPosition(unit.movementAction.dstPosition, sim)
 	}
 }
 
@@ -68,7 +68,7 @@ func NewPet(name string, owner *Character, baseStats stats.Stats, statInheritanc
 
 				ReactionTime: owner.ReactionTime,
 
-				StartDistanceFromTarget: MaxMeleeRange, // TODO: Match to owner and add movement logic to pet rotations
+				StartDistanceFromTarget: MaxMeleeRange, // TODO: Add movement logic to pet rotations
 			},
 			Name:       name,
 			Party:      owner.Party,
@@ -107,6 +107,7 @@ func (pet *Pet) reset(sim *Simulation, agent PetAgent) {
 	pet.isReset = true
 
 	pet.Character.reset(sim, agent)
+	pet.placeNearTarget()
 
 	pet.CancelGCDTimer(sim)
 	pet.AutoAttacks.CancelAutoSwing(sim)
@@ -116,6 +117,15 @@ func (pet *Pet) reset(sim *Simulation, agent PetAgent) {
 		pet.Enable(sim, agent)
 	}
 }
+
+// Puts the pet in range of its target, on the same side as its owner.
+func (pet *Pet) placeNearTarget() {
+	if pet.CurrentTarget == nil {
+		return
+	}
+	pet.Position = pet.CurrentTarget.Position.Towards(pet.Owner.Position, pet.StartDistanceFromTarget)
+}
+
 func (pet *Pet) doneIteration(sim *Simulation) {
 	pet.Character.doneIteration(sim)
 	pet.Disable(sim)
@@ -162,6 +172,8 @@ func (pet *Pet) Enable(sim *Simulation, petAgent PetAgent) {
 	//reset current mana after applying stats
 	pet.manaBar.reset()
 
+	pet.placeNearTarget()
+
 	// Call onEnable callbacks before enabling auto swing
 	// to not have to reorder PAs multiple times
 	pet.enabled = true
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"math"
+	"slices"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Positions are computed in floating point, so a unit placed exactly at a range
+// can end up a hair beyond it. Maximum ranges are checked with this much slack.
+const rangeTolerance = 1e-6
+
+// A point on the ground, in yards.
+type Vector2 struct {
+	X float64
+	Y float64
+}
+
+func Vector2FromProto(position *proto.Position) Vector2 {
+	if position == nil {
+		return Vector2{}
+	}
+	return Vector2{X: position.X, Y: position.Y}
+}
+
+func (v Vector2) Add(other Vector2) Vector2 {
+	return Vector2{X: v.X + other.X, Y: v.Y + other.Y}
+}
+
+func (v Vector2) Sub(other Vector2) Vector2 {
+	return Vector2{X: v.X - other.X, Y: v.Y - other.Y}
+}
+
+func (v Vector2) Scale(amount float64) Vector2 {
+	return Vector2{X: v.X * amount, Y: v.Y * amount}
+}
+
+func (v Vector2) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+func (v Vector2) DistanceTo(other Vector2) float64 {
+	return other.Sub(v).Length()
+}
+
+// Returns the unit vector pointing from v towards other, or the +X direction if
+// both points are the same.
+func (v Vector2) DirectionTo(other Vector2) Vector2 {
+	delta := other.Sub(v)
+	length := delta.Length()
+	if length == 0 {
+		return Vector2{X: 1}
+	}
+	return Vector2{X: delta.X / length, Y: delta.Y / length}
+}
+
+// Returns v rotated counter-clockwise about the origin by the given angle, in radians.
+func (v Vector2) Rotate(angle float64) Vector2 {
+	sin, cos := math.Sincos(angle)
+	return Vector2{X: v.X*cos - v.Y*sin, Y: v.X*sin + v.Y*cos}
+}
+
+// Returns the point which is the given distance from v, in the direction of other.
+func (v Vector2) Towards(other Vector2, distance float64) Vector2 {
+	return v.Add(v.DirectionTo(other).Scale(distance))
+}
+
+// Distance in yards between this unit and another.
+func (unit *Unit) DistanceTo(other *Unit) float64 {
+	return unit.Position.DistanceTo(other.Position)
+}
+
+// Distance in yards between this unit and its current target, or 0 if it has
+// no target.
+func (unit *Unit) DistanceFromTarget() float64 {
+	if unit.CurrentTarget == nil {
+		return 0
+	}
+	return unit.DistanceTo(unit.CurrentTarget)
+}
+
+// Returns the point the given distance from the unit's current target, on the
+// unit's side of it.
+func (unit *Unit) PositionAtRange(distance float64) Vector2 {
+	if unit.CurrentTarget == nil {
+		return unit.Position
+	}
+	return unit.CurrentTarget.Position.Towards(unit.Position, distance)
+}
+
+// Puts the unit back at its starting position. Must be called after the unit's
+// target has been reset.
+func (unit *Unit) resetPosition() {
+	if unit.startPosition != nil {
+		unit.Position = *unit.startPosition
+	} else if unit.CurrentTarget != nil {
+		unit.Position = unit.CurrentTarget.Position.Add(Vector2{X: unit.StartDistanceFromTarget})
+	} else {
+		unit.Position = Vector2{}
+	}
+}
+
+// Returns the active targets within radius yards of center. This is the same
+// slice as ActiveTargetUnits when every active target is in range.
+func (encounter *Encounter) TargetUnitsInRange(center Vector2, radius float64) []*Unit {
+	for i, unit := range encounter.ActiveTargetUnits {
+		if unit.Position.DistanceTo(center) <= radius+rangeTolerance {
+			continue
+		}
+
+		inRange := slices.Clone(encounter.ActiveTargetUnits[:i])
+		for _, unit := range encounter.ActiveTargetUnits[i+1:] {
+			if unit.Position.DistanceTo(center) <= radius+rangeTolerance {
+				inRange = append(inRange, unit)
+			}
+		}
+		return inRange
+	}
+	return encounter.ActiveTargetUnits
+}
+
+// Returns the active targets within radius yards of the unit, for spells which
+// hit everything around the caster.
+func (unit *Unit) TargetUnitsAround(radius float64) []*Unit {
+	return unit.Env.Encounter.TargetUnitsInRange(unit.Position, radius)
+}
+
+// Returns up to maxTargets targets for a spell which hits the given target and
+// others near it: the target itself, then the other active targets within radius
+// yards of center, in target order starting after the target.
+func (env *Environment) CleaveTargetUnits(target *Unit, center Vector2, radius float64, maxTargets int32) []*Unit {
+	targets := []*Unit{target}
+	activeTargets := env.Encounter.ActiveTargetUnits
+	start := max(slices.IndexFunc(activeTargets, func(unit *Unit) bool { return unit.Index > target.Index }), 0)
+	for i := range activeTargets {
+		if int32(len(targets)) >= maxTargets {
+			break
+		}
+		next := activeTargets[(start+i)%len(activeTargets)]
+		if next != target && next.Position.DistanceTo(center) <= radius+rangeTolerance {
+			targets = append(targets, next)
+		}
+	}
+	return targets
+}
+
+// Moves the target to a new position. Raid units attacking it which were in
+// melee range follow it into melee range again, while ranged units only move
+// once it is out of casting range.
+func (target *Target) Reposition(sim *Simulation, position Vector2) {
+	if sim.Log != nil {
+		target.Log(sim, "Moving to (%0.1f, %0.1f)", position.X, position.Y)
+	}
+	target.Position = position
+
+	for _, unit := range sim.Raid.AllUnits {
+		if !unit.IsEnabled() || unit.CurrentTarget != &target.Unit {
+			continue
+		}
+		unit.returnToRange(sim)
+	}
+}
+
+// Moves the unit back into range of its target after the target moved or
+// changed: melee units return to melee range, and ranged units to casting range.
+func (unit *Unit) returnToRange(sim *Simulation) {
+	unit.UpdatePosition(sim)
+	unit.updateAutoAttackRange(sim)
+
+	moveRange := unit.StartDistanceFromTarget
+	if moveRange > MaxMeleeRange {
+		moveRange = MaxCastRange
+	}
+	if unit.DistanceFromTarget() <= moveRange+rangeTolerance {
+		return
+	}
+	unit.followTarget(sim, moveRange)
+}
+
+// Moves the unit to the given range of its target, once any cast in progress
+// has finished.
+func (unit *Unit) followTarget(sim *Simulation, moveRange float64) {
+	// Movement stops up to a millisecond short of the destination, so overshoot
+	// slightly to be sure to end up in range.
+	moveRange = max(moveRange-1, 0)
+
+	if unit.Hardcast.Expires <= sim.CurrentTime || unit.Hardcast.CanMove {
+		unit.MoveTo(moveRange, sim)
+		return
+	}
+
+	target := unit.CurrentTarget
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: unit.Hardcast.Expires,
+		OnAction: func(sim *Simulation) {
+			if unit.CurrentTarget == target {
+				unit.MoveTo(moveRange, sim)
+			}
+		},
+	})
+}
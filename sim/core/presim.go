@@ -69,6 +69,7 @@ func (sim *Simulation) runPresims(request *proto.RaidSimRequest) *proto.RaidSimR
 	presimRequest.SimOptions.RandomSeed = 1
 	presimRequest.SimOptions.Debug = false
 	presimRequest.SimOptions.DebugFirstIteration = false
+	presimRequest.SimOptions.AplTrace = false
 	presimRequest.SimOptions.Iterations = numPresimIterations
 	duration := DurationFromSeconds(presimRequest.Encounter.Duration)
 
@@ -94,10 +95,10 @@ func (sim *Simulation) runPresims(request *proto.RaidSimRequest) *proto.RaidSimR
 		}
 
 		// Run the presim.
-		presimResult := runSim(presimRequest, nil, true, nil)
+		presimResult := runSim(sim.ctx, presimRequest, nil, true)
 		lastResult = presimResult
 
-		if presimResult.ErrorResult != "" {
+		if presimResult.ErrorResult != "" || presimResult.Cancelled {
 			break
 		}
 
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+type raidDamageEvent struct {
+	config *proto.RaidDamageEvent
+	source *Target
+	spell  *Spell
+}
+
+// validateRaidDamageEvents returns an error for raid damage events the sim can't run with.
+func validateRaidDamageEvents(configs []*proto.RaidDamageEvent) error {
+	for i, config := range configs {
+		if config.SpellId == 0 {
+			return fmt.Errorf("Raid damage event %d needs a spell ID", i+1)
+		}
+		if slices.ContainsFunc(configs[:i], func(other *proto.RaidDamageEvent) bool {
+			return other.SpellId == config.SpellId
+		}) {
+			return fmt.Errorf("Raid damage event %d uses spell ID %d, which another event already uses", i+1, config.SpellId)
+		}
+		if config.Target == proto.RaidDamageEvent_RandomPlayers && config.NumTargets <= 0 {
+			return fmt.Errorf("Raid damage event %d hits random players, but the number of players is %d", i+1, config.NumTargets)
+		}
+	}
+	return nil
+}
+
+// Registers the spells for the encounter's raid damage events. They are cast by
+// the first target, so that they show up in its metrics, and only happen while
+// it is active.
+func (encounter *Encounter) initializeRaidDamageEvents(configs []*proto.RaidDamageEvent) {
+	caster := encounter.Targets[0]
+	for _, config := range configs {
+		encounter.raidDamageEvents = append(encounter.raidDamageEvents, &raidDamageEvent{
+			config: config,
+			source: caster,
+			spell:  caster.registerRaidDamageSpell(config),
+		})
+	}
+}
+
+func (target *Target) registerRaidDamageSpell(config *proto.RaidDamageEvent) *Spell {
+	school := SpellSchoolFromProto(config.School)
+	procMask := ProcMaskSpellDamage
+	flags := SpellFlagNone
+	if school == SpellSchoolPhysical {
+		procMask = ProcMaskMeleeMHSpecial
+		flags = SpellFlagMeleeMetrics | SpellFlagApplyArmorReduction
+	}
+	avoidable := config.Avoidable && school == SpellSchoolPhysical
+
+	return target.RegisterSpell(SpellConfig{
+		ActionID:    ActionID{SpellID: config.SpellId},
+		SpellSchool: school,
+		ProcMask:    procMask,
+		Flags:       flags,
+
+		DamageMultiplier: 1,
+		CritMultiplier:   2,
+
+		ApplyEffects: func(sim *Simulation, _ *Unit, spell *Spell) {
+			outcome := Ternary(avoidable, spell.OutcomeEnemyMeleeWhite, spell.OutcomeAlwaysHit)
+			for _, player := range raidDamageTargets(sim, config) {
+				baseDamage := sim.RollWithLabel(config.MinDamage, max(config.MinDamage, config.MaxDamage), "Raid Damage")
+				spell.CalcAndDealDamage(sim, player, baseDamage, outcome)
+			}
+		},
+	})
+}
+
+// Returns the players hit by a raid damage event.
+func raidDamageTargets(sim *Simulation, config *proto.RaidDamageEvent) []*Unit {
+	players := sim.Raid.AllPlayerUnits
+
+	switch config.Target {
+	case proto.RaidDamageEvent_Tank:
+		if tank := sim.Encounter.Targets[0].CurrentTarget; tank != nil {
+			return []*Unit{tank}
+		}
+		return nil
+	case proto.RaidDamageEvent_RandomPlayers:
+		numTargets := int(config.NumTargets)
+		if numTargets >= len(players) {
+			return players
+		}
+
+		candidates := slices.Clone(players)
+		for i := 0; i < numTargets; i++ {
+			j := i + int(sim.RandomFloat("Raid Damage Target")*float64(len(candidates)-i))
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		}
+		return candidates[:numTargets]
+	default:
+		return players
+	}
+}
+
+func (encounter *Encounter) resetRaidDamageEvents(sim *Simulation) {
+	for _, event := range encounter.raidDamageEvents {
+		event.schedule(sim, DurationFromSeconds(event.config.StartTime), 0)
+	}
+}
+
+func (event *raidDamageEvent) schedule(sim *Simulation, doAt time.Duration, count int32) {
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: max(doAt, sim.CurrentTime),
+		OnAction: func(sim *Simulation) {
+			// Events are skipped while the source has not spawned yet or is gone,
+			// and skipped events don't count towards the maximum.
+			if event.source.IsActive {
+				// The targets are picked when the damage lands, so the cast target
+				// is only used for metrics.
+				target := event.spell.Unit.CurrentTarget
+				if target == nil {
+					target = sim.Raid.AllPlayerUnits[0]
+				}
+				event.spell.Cast(sim, target)
+				count++
+			}
+
+			if event.config.Interval > 0 && (event.config.MaxCount == 0 || count < event.config.MaxCount) {
+				event.schedule(sim, sim.CurrentTime+DurationFromSeconds(event.config.Interval), count)
+			}
+		},
+	})
+}
@@ -0,0 +1,151 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Returns the metrics of a target's action, summed over the players it hit.
+func targetActionMetrics(result *proto.RaidSimResult, targetIndex int, spellID int32) *proto.TargetedActionMetrics {
+	total := &proto.TargetedActionMetrics{}
+	for _, action := range result.EncounterMetrics.Targets[targetIndex].Actions {
+		if action.Id.GetSpellId() != spellID {
+			continue
+		}
+		for _, target := range action.Targets {
+			total.Casts += target.Casts
+			total.Hits += target.Hits
+			total.Dodges += target.Dodges
+			total.Parries += target.Parries
+			total.Damage += target.Damage
+		}
+	}
+	return total
+}
+
+func TestRaidDamageEvents(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerBloodDk())
+	rsr.Raid.Parties[0].Players = append(rsr.Raid.Parties[0].Players, getTestPlayerMM())
+	rsr.Raid.Tanks = []*proto.UnitReference{{Type: proto.UnitReference_Player, Index: 0}}
+	rsr.SimOptions.Iterations = 10
+	rsr.Encounter.RaidDamageEvents = []*proto.RaidDamageEvent{
+		{
+			SpellId:   990600,
+			School:    proto.SpellSchool_SpellSchoolShadow,
+			MinDamage: 10_000,
+			MaxDamage: 12_000,
+			StartTime: 5,
+			Interval:  10,
+			MaxCount:  3,
+		},
+		{
+			SpellId:    990601,
+			School:     proto.SpellSchool_SpellSchoolFire,
+			MinDamage:  20_000,
+			StartTime:  10,
+			Interval:   20,
+			Target:     proto.RaidDamageEvent_RandomPlayers,
+			NumTargets: 1,
+		},
+		{
+			SpellId:   990602,
+			School:    proto.SpellSchool_SpellSchoolPhysical,
+			MinDamage: 50_000,
+			Interval:  2,
+			Target:    proto.RaidDamageEvent_Tank,
+			Avoidable: true,
+		},
+	}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	iterations := rsr.SimOptions.Iterations
+
+	raidWide := targetActionMetrics(result, 0, 990600)
+	if raidWide.Casts != 3*iterations {
+		t.Fatalf("expected the raid-wide event 3 times per iteration, got %d casts", raidWide.Casts)
+	}
+	if raidWide.Hits != 2*raidWide.Casts {
+		t.Fatalf("expected the raid-wide event to hit both players, got %d hits from %d casts", raidWide.Hits, raidWide.Casts)
+	}
+
+	random := targetActionMetrics(result, 0, 990601)
+	if random.Casts == 0 || random.Hits != random.Casts {
+		t.Fatalf("expected the random event to hit one player per cast, got %d hits from %d casts", random.Hits, random.Casts)
+	}
+
+	tank := targetActionMetrics(result, 0, 990602)
+	if tank.Casts < 150*iterations {
+		t.Fatalf("expected the tank event every 2 seconds, got %d casts", tank.Casts)
+	}
+	if tank.Dodges == 0 || tank.Parries == 0 {
+		t.Fatalf("expected the tank to avoid some hits, got %d dodges and %d parries", tank.Dodges, tank.Parries)
+	}
+}
+
+func TestRaidDamageEventsWaitForSpawn(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerBloodDk())
+	rsr.SimOptions.Iterations = 10
+	target := newTestTarget()
+	target.SpawnTime = 12
+	rsr.Encounter.Targets = []*proto.Target{target}
+	rsr.Encounter.RaidDamageEvents = []*proto.RaidDamageEvent{
+		{
+			SpellId:   990603,
+			School:    proto.SpellSchool_SpellSchoolShadow,
+			MinDamage: 10_000,
+			StartTime: 5,
+			Interval:  10,
+			MaxCount:  2,
+		},
+	}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	// The event at 5s is skipped, and doesn't count towards the maximum.
+	if metrics := targetActionMetrics(result, 0, 990603); metrics.Casts != 2*rsr.SimOptions.Iterations {
+		t.Fatalf("expected the event twice per iteration once the target spawns, got %d casts", metrics.Casts)
+	}
+}
+
+func TestRaidDamageEventValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		events []*proto.RaidDamageEvent
+		err    string
+	}{
+		{
+			name:   "MissingSpellID",
+			events: []*proto.RaidDamageEvent{{MinDamage: 1000}},
+			err:    "Raid damage event 1 needs a spell ID",
+		},
+		{
+			name:   "DuplicateSpellID",
+			events: []*proto.RaidDamageEvent{{SpellId: 990604}, {SpellId: 990604}},
+			err:    "Raid damage event 2 uses spell ID 990604",
+		},
+		{
+			name:   "NoRandomPlayers",
+			events: []*proto.RaidDamageEvent{{SpellId: 990605, Target: proto.RaidDamageEvent_RandomPlayers}},
+			err:    "Raid damage event 1 hits random players, but the number of players is 0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rsr := makeTestCase(getTestPlayerBloodDk())
+			rsr.Encounter.RaidDamageEvents = tc.events
+
+			result := core.RunRaidSim(rsr)
+			if !strings.Contains(result.ErrorResult, tc.err) {
+				t.Fatalf("expected error %q, got %q", tc.err, result.ErrorResult)
+			}
+		})
+	}
+}
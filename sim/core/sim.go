@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
 )
 
 type Task interface {
@@ -38,10 +40,16 @@ type Simulation struct {
 	NeedsInput     bool          // Sim is in interactive mode and needs input
 
 	ProgressReport func(*proto.ProgressMetrics)
-	QuitChannel    chan bool
+
+	// Stops the sim after the current iteration when done.
+	ctx context.Context
 
 	Log func(string, ...interface{})
 
+	// APL decisions recorded while SimOptions.AplTrace is set, see APLRotation.decideNextAction.
+	tracingAPL bool
+	aplTrace   []*proto.APLTraceDecision
+
 	executePhase int32 // 20, 25, or 35 for the respective execute range, 100 otherwise
 
 	executePhaseCallbacks []func(*Simulation, int32) // 2nd parameter is 35 for 35%, 25 for 25% and 20 for 20%
@@ -105,11 +113,13 @@ func (sim *Simulation) RemoveTask(task Task) {
 	}
 }
 
-func RunSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, quitSignal chan bool) *proto.RaidSimResult {
-	return runSim(rsr, progress, false, quitSignal)
+// RunSim runs all iterations of the request on the current goroutine. When ctx is cancelled
+// the sim stops after the current iteration, and returns the partial result marked as cancelled.
+func RunSim(ctx context.Context, rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics) *proto.RaidSimResult {
+	return runSim(ctx, rsr, progress, false)
 }
 
-func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool, quitSignal chan bool) (result *proto.RaidSimResult) {
+func runSim(ctx context.Context, rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool) (result *proto.RaidSimResult) {
 	if !rsr.SimOptions.IsTest {
 		defer func() {
 			if err := recover(); err != nil {
@@ -137,12 +147,21 @@ func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, ski
 		}()
 	}
 
-	sim := NewSim(rsr)
-
-	if quitSignal != nil {
-		sim.QuitChannel = quitSignal
+	if err := validateTargets(rsr.Encounter); err != nil {
+		result = &proto.RaidSimResult{
+			ErrorResult: err.Error(),
+		}
+		if progress != nil {
+			progress <- &proto.ProgressMetrics{
+				FinalRaidResult: result,
+			}
+		}
+		return result
 	}
 
+	sim := NewSim(rsr)
+	sim.ctx = ctx
+
 	if !skipPresim {
 		if progress != nil {
 			progress <- &proto.ProgressMetrics{
@@ -152,6 +171,13 @@ func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, ski
 			runtime.Gosched() // allow time for message to make it back out.
 		}
 		presimResult := sim.runPresims(rsr)
+		if presimResult != nil && presimResult.Cancelled {
+			// Results from an unfinished presim don't mean anything for the real sim.
+			presimResult = &proto.RaidSimResult{
+				ErrorResult: "Canceled during presims!",
+				Cancelled:   true,
+			}
+		}
 		if presimResult != nil && presimResult.ErrorResult != "" {
 			if progress != nil {
 				progress <- &proto.ProgressMetrics{
@@ -203,6 +229,8 @@ func newSimWithEnv(env *Environment, simOptions *proto.SimOptions) *Simulation {
 		rand:  NewSplitMix(uint64(rseed)),
 		rseed: rseed,
 
+		ctx: context.Background(),
+
 		isTest:    simOptions.IsTest,
 		testRands: make(map[string]Rand),
 	}
@@ -297,7 +325,9 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 	// 	fmt.Printf(fmt.Sprintf("[%0.1f] "+message+"\n", append([]interface{}{sim.CurrentTime.Seconds()}, vals...)...))
 	// }
 
+	sim.tracingAPL = sim.Options.AplTrace
 	sim.runOnce()
+	sim.tracingAPL = false
 	firstIterationDuration := sim.Duration
 	if sim.Encounter.EndFightAtHealth != 0 {
 		firstIterationDuration = sim.CurrentTime
@@ -308,20 +338,12 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 		sim.Log = nil
 	}
 
+	completedIterations := sim.Options.Iterations
 	var st time.Time
 	for i := int32(1); i < sim.Options.Iterations; i++ {
-		if sim.QuitChannel != nil {
-			select {
-			case <-sim.QuitChannel:
-				quitResult := &proto.RaidSimResult{
-					ErrorResult: "Canceled due to quit signal!",
-				}
-				if sim.ProgressReport != nil {
-					sim.ProgressReport(&proto.ProgressMetrics{FinalRaidResult: quitResult})
-				}
-				return quitResult
-			default:
-			}
+		if sim.ctx.Err() != nil {
+			completedIterations = i
+			break
 		}
 
 		// fmt.Printf("Iteration: %d\n", i)
@@ -348,12 +370,16 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 
 		Logs:                   logsBuffer.String(),
 		FirstIterationDuration: firstIterationDuration.Seconds(),
-		AvgIterationDuration:   totalDuration.Seconds() / float64(sim.Options.Iterations),
+		AvgIterationDuration:   totalDuration.Seconds() / float64(completedIterations),
+
+		Cancelled: completedIterations < sim.Options.Iterations,
+
+		AplTrace: sim.aplTrace,
 	}
 
 	// Final progress report
 	if sim.ProgressReport != nil {
-		sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: sim.Options.Iterations, Dps: result.RaidMetrics.Dps.Avg, FinalRaidResult: result})
+		sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: completedIterations, Dps: result.RaidMetrics.Dps.Avg, FinalRaidResult: result})
 	}
 
 	if d := sim.Options.Iterations; d > 3000 {
@@ -555,7 +581,7 @@ func (sim *Simulation) advance(nextTime time.Duration) {
 
 	// this is a loop to handle duplicate ExecuteProportions, e.g. if they're all set to 100%, you reach
 	// execute phases 35%, 25%, and 20% in the first advance() call.
-	for sim.CurrentTime >= sim.nextExecuteDuration || sim.Encounter.DamageTaken >= sim.nextExecuteDamage {
+	for sim.CurrentTime >= sim.nextExecuteDuration || sim.executeDamageTaken() >= sim.nextExecuteDamage {
 		sim.nextExecutePhase()
 		for _, callback := range sim.executePhaseCallbacks {
 			callback(sim, sim.executePhase)
@@ -579,7 +605,9 @@ func (sim *Simulation) setupReverseExecute(phase int32, activeProportion float64
 func (sim *Simulation) nextExecutePhase() {
 	setup := func(phase int32, damage float64, health float64) {
 		sim.executePhase = phase
-		if sim.Encounter.EndFightAtHealth > 0 {
+		if executeTarget := sim.Encounter.executeTarget; executeTarget != nil {
+			sim.nextExecuteDamage = (1 - damage) * executeTarget.GetStat(stats.Health)
+		} else if sim.Encounter.EndFightAtHealth > 0 {
 			sim.nextExecuteDamage = (1 - damage) * sim.Encounter.EndFightAtHealth
 		} else {
 			sim.nextExecuteDuration = time.Duration((1 - health) * float64(sim.Duration))
@@ -605,6 +633,16 @@ func (sim *Simulation) nextExecutePhase() {
 	}
 }
 
+// Damage which counts towards the execute phases in health fights. This is the
+// damage taken by the first target, so that execute ranges line up with its
+// actual health.
+func (sim *Simulation) executeDamageTaken() float64 {
+	if executeTarget := sim.Encounter.executeTarget; executeTarget != nil {
+		return executeTarget.damageTaken
+	}
+	return sim.Encounter.DamageTaken
+}
+
 func (sim *Simulation) AddPendingAction(pa *PendingAction) {
 	//if pa.NextActionAt < sim.CurrentTime {
 	//	panic(fmt.Sprintf("Cant add action in the past: %s", pa.NextActionAt))
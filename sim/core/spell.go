@@ -18,6 +18,7 @@ type SpellConfig struct {
 	SpellSchool    SpellSchool
 	ProcMask       ProcMask
 	Flags          SpellFlag
+	Mechanic       SpellMechanic
 	MissileSpeed   float64
 	BaseCost       float64
 	MetricSplits   int
@@ -82,6 +83,9 @@ type Spell struct {
 	// Flags
 	Flags SpellFlag
 
+	// Bleed, Stun, etc. Targets immune to the mechanic are immune to the spell.
+	Mechanic SpellMechanic
+
 	// The specific class spell id
 	// should be a unique bit
 	ClassSpellMask int64
@@ -201,6 +205,7 @@ func (unit *Unit) RegisterSpell(config SpellConfig) *Spell {
 		SpellSchool:    config.SpellSchool,
 		ProcMask:       config.ProcMask,
 		Flags:          config.Flags,
+		Mechanic:       config.Mechanic,
 		MissileSpeed:   config.MissileSpeed,
 		ClassSpellMask: config.ClassSpellMask,
 
@@ -303,7 +308,7 @@ func (unit *Unit) RegisterSpell(config SpellConfig) *Spell {
 		spell.MaxRange = config.MaxRange
 		oldExtraCastCondition := spell.ExtraCastCondition
 		spell.ExtraCastCondition = func(sim *Simulation, target *Unit) bool {
-			if ((spell.MinRange != 0) && (spell.Unit.DistanceFromTarget < spell.MinRange)) || ((spell.MaxRange != 0) && (spell.Unit.DistanceFromTarget > spell.MaxRange)) {
+			if !spell.inRange(target) {
 				if sim.Log != nil {
 					sim.Log("Cannot cast spell %s, out of range!", spell.ActionID)
 				}
@@ -479,62 +484,64 @@ func (spell *Spell) TimeToReady(sim *Simulation) time.Duration {
 	return MaxTimeToReady(spell.CD.Timer, spell.SharedCD.Timer, sim)
 }
 
+// Returns whether the target is within the spell's MinRange and MaxRange.
+func (spell *Spell) inRange(target *Unit) bool {
+	distance := spell.Unit.DistanceFromTarget()
+	if target != nil {
+		distance = spell.Unit.DistanceTo(target)
+	}
+	return ((spell.MinRange == 0) || (distance >= spell.MinRange)) && ((spell.MaxRange == 0) || (distance <= spell.MaxRange+rangeTolerance))
+}
+
 // Returns whether a call to Cast() would be successful, without actually
 // doing a cast.
 func (spell *Spell) CanCast(sim *Simulation, target *Unit) bool {
-	if spell == nil {
-		return false
+	return spell != nil && spell.cantCastReason(sim, target) == ""
+}
+
+// Returns why a call to Cast() would fail, or an empty string if it would be
+// successful. Shared by CanCast and the APL trace, so that traced reasons always
+// match the checks which are actually made.
+func (spell *Spell) cantCastReason(sim *Simulation, target *Unit) string {
+	if target != nil && target.isDespawned() {
+		return "target is despawned"
 	}
 
 	if spell.ExtraCastCondition != nil && !spell.ExtraCastCondition(sim, target) {
-		//if sim.Log != nil {
-		//	sim.Log("Cant cast because of extra condition")
-		//}
-		return false
+		// The range check is part of the extra cast condition.
+		if !spell.inRange(target) {
+			return "out of range"
+		}
+		return "cast condition not met"
 	}
 
 	// While moving only instant casts are possible
 	if spell.Flags&SpellFlagCanCastWhileMoving == 0 && spell.DefaultCast.CastTime > 0 && spell.Unit.Moving {
-		//if sim.Log != nil {
-		//	sim.Log("Cant cast because moving")
-		//}
-		return false
+		return "moving"
 	}
 
 	// While casting or channeling, no other action is possible
 	if spell.Unit.Hardcast.Expires > sim.CurrentTime {
-		//if sim.Log != nil {
-		//	sim.Log("Cant cast because already casting/channeling")
-		//}
-		return false
+		return "already casting"
 	}
 
 	if ((spell.DefaultCast.GCD > 0) || (spell.Flags.Matches(SpellFlagMCD) && spell.Unit.Rotation.inSequence)) && !spell.Unit.GCD.IsReady(sim) {
-		//if sim.Log != nil {
-		//	sim.Log("Cant cast because of GCD")
-		//}
-		return false
+		return "blocked by GCD"
 	}
 
 	if !BothTimersReady(spell.CD.Timer, spell.SharedCD.Timer, sim) {
-		//if sim.Log != nil {
-		//	sim.Log("Cant cast because of CDs")
-		//}
-		return false
+		return "on cooldown"
 	}
 
 	if spell.Cost != nil {
 		// temp hack
 		spell.CurCast.Cost = spell.DefaultCast.Cost
 		if !spell.Cost.MeetsRequirement(sim, spell) {
-			//if sim.Log != nil {
-			//	sim.Log("Cant cast because of resource cost")
-			//}
-			return false
+			return "can't afford"
 		}
 	}
 
-	return true
+	return ""
 }
 
 func (spell *Spell) Cast(sim *Simulation, target *Unit) bool {
@@ -572,9 +579,8 @@ func (spell *Spell) applyEffects(sim *Simulation, target *Unit) {
 }
 
 func (spell *Spell) ApplyAOEThreatIgnoreMultipliers(threatAmount float64) {
-	numTargets := spell.Unit.Env.GetNumTargets()
-	for i := int32(0); i < numTargets; i++ {
-		spell.SpellMetrics[i].TotalThreat += threatAmount
+	for _, target := range spell.Unit.Env.Encounter.ActiveTargetUnits {
+		spell.SpellMetrics[target.UnitIndex].TotalThreat += threatAmount
 	}
 }
 func (spell *Spell) ApplyAOEThreat(threatAmount float64) {
@@ -621,7 +627,7 @@ func (spell *Spell) TravelTime() time.Duration {
 	if spell.MissileSpeed == 0 {
 		return 0
 	} else {
-		return time.Duration(float64(time.Second) * spell.Unit.DistanceFromTarget / spell.MissileSpeed)
+		return time.Duration(float64(time.Second) * spell.Unit.DistanceFromTarget() / spell.MissileSpeed)
 	}
 }
 
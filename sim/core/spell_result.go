@@ -176,6 +176,10 @@ func (spell *Spell) ApplyPostOutcomeDamageModifiers(sim *Simulation, result *Spe
 func (spell *Spell) CalcOutcome(sim *Simulation, target *Unit, outcomeApplier OutcomeApplier) *SpellResult {
 	attackTable := spell.Unit.AttackTables[target.UnitIndex]
 	result := spell.NewResult(target)
+	if target.IsImmuneToSpell(spell) {
+		spell.applyImmuneOutcome(result)
+		return result
+	}
 
 	outcomeApplier(sim, result, attackTable)
 	result.Threat = spell.ThreatFromDamage(result.Outcome, result.Damage)
@@ -186,6 +190,10 @@ func (spell *Spell) calcDamageInternal(sim *Simulation, target *Unit, baseDamage
 	attackTable := spell.Unit.AttackTables[target.UnitIndex]
 
 	result := spell.NewResult(target)
+	if target.IsImmuneToSpell(spell) {
+		spell.applyImmuneOutcome(result)
+		return result
+	}
 	result.Damage = baseDamage
 
 	if sim.Log == nil {
@@ -287,6 +295,7 @@ func (spell *Spell) dealDamageInternal(sim *Simulation, isPeriodic bool, result
 	// Don't include damage done by EnemyUnits to Players
 	if result.Target.Type == EnemyUnit {
 		sim.Encounter.DamageTaken += result.Damage
+		sim.Encounter.Targets[result.Target.Index].takeDamage(sim, result.Damage)
 	}
 
 	if sim.Log != nil {
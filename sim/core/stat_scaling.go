@@ -0,0 +1,333 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+const (
+	maxStatScalingPoints = 2500
+
+	// A difference between two neighbouring points counts as a jump when it is this many robust
+	// standard deviations away from the typical difference.
+	statScalingJumpThreshold = 5.0
+	minStatScalingJumpPoints = 5
+)
+
+// StatScalingSim sweeps one or two stats over a range of bonus amounts.
+// When ctx is cancelled the points that finished are returned, without breakpoints.
+func StatScalingSim(ctx context.Context, request *proto.StatScalingRequest, progress chan *proto.ProgressMetrics) *proto.StatScalingResult {
+	result, err := runStatScaling(ctx, request, progress)
+	if err != nil {
+		result = &proto.StatScalingResult{
+			ErrorResult: err.Error(),
+		}
+	}
+
+	if progress != nil {
+		progress <- &proto.ProgressMetrics{
+			FinalScalingResult: result,
+		}
+	}
+	return result
+}
+
+func validateStatScalingAxes(axes []*proto.StatScalingAxis) (int, error) {
+	if len(axes) != 1 && len(axes) != 2 {
+		return 0, fmt.Errorf("stat scaling: expected 1 or 2 axes, got %d", len(axes))
+	}
+	if len(axes) == 2 && axes[0].Stat == axes[1].Stat {
+		return 0, errors.New("stat scaling: both axes use the same stat")
+	}
+
+	numPoints := 1
+	for _, axis := range axes {
+		if axis.Step <= 0 || axis.Max < axis.Min {
+			return 0, fmt.Errorf("stat scaling: invalid range for %s", axis.Stat)
+		}
+		numPoints *= len(axisValues(axis))
+	}
+	if numPoints > maxStatScalingPoints {
+		return 0, fmt.Errorf("stat scaling: %d points exceeds the maximum of %d", numPoints, maxStatScalingPoints)
+	}
+	return numPoints, nil
+}
+
+func axisValues(axis *proto.StatScalingAxis) []float64 {
+	var values []float64
+	// Small tolerance so the max is included despite floating point steps.
+	for i := 0; ; i++ {
+		value := axis.Min + float64(i)*axis.Step
+		if value > axis.Max+axis.Step*1e-6 {
+			break
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func runStatScaling(ctx context.Context, request *proto.StatScalingRequest, progress chan *proto.ProgressMetrics) (*proto.StatScalingResult, error) {
+	if request.Player == nil || request.Encounter == nil || request.SimOptions == nil {
+		return nil, errors.New("stat scaling: missing player, encounter or sim options")
+	}
+	numPoints, err := validateStatScalingAxes(request.Axes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reuse the stat weights setup, it needs the same bonus stats handling.
+	raidProto := newStatWeightsRaid(&proto.StatWeightsRequest{
+		Player:     request.Player,
+		RaidBuffs:  request.RaidBuffs,
+		PartyBuffs: request.PartyBuffs,
+		Debuffs:    request.Debuffs,
+		Tanks:      request.Tanks,
+	})
+	simOptions := googleProto.Clone(request.SimOptions).(*proto.SimOptions)
+	// Every point uses the same seed, so the differences between points come from the stats only.
+	simOptions.IsTest = true
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+	baseSimRequest := &proto.RaidSimRequest{
+		Raid:       raidProto,
+		Encounter:  request.Encounter,
+		SimOptions: simOptions,
+	}
+
+	// Points are ordered with the last axis changing fastest.
+	points := make([]*proto.StatScalingPoint, 0, numPoints)
+	points = append(points, &proto.StatScalingPoint{})
+	for _, axis := range request.Axes {
+		var expanded []*proto.StatScalingPoint
+		for _, point := range points {
+			for _, value := range axisValues(axis) {
+				expanded = append(expanded, &proto.StatScalingPoint{
+					Bonus: append(slices.Clone(point.Bonus), value),
+				})
+			}
+		}
+		points = expanded
+	}
+
+	concurrency := (runtime.NumCPU() - 1) * 2
+	if concurrency <= 0 {
+		concurrency = 2
+	}
+	tickets := make(chan struct{}, concurrency)
+	for i := 0; i < concurrency; i++ {
+		tickets <- struct{}{}
+	}
+
+	var waitGroup sync.WaitGroup
+	var simsCompleted int32
+	results := make([]*proto.RaidSimResult, len(points))
+	for i, point := range points {
+		waitGroup.Add(1)
+		go func(i int, point *proto.StatScalingPoint) {
+			defer waitGroup.Done()
+			select {
+			case <-tickets:
+			case <-ctx.Done():
+				return
+			}
+
+			simRequest := googleProto.Clone(baseSimRequest).(*proto.RaidSimRequest)
+			for a, axis := range request.Axes {
+				stats.UnitStatFromStat(stats.Stat(axis.Stat)).AddToStatsProto(simRequest.Raid.Parties[0].Players[0].BonusStats, point.Bonus[a])
+			}
+			results[i] = RunSim(ctx, simRequest, nil)
+			tickets <- struct{}{}
+
+			completed := atomic.AddInt32(&simsCompleted, 1)
+			if progress != nil {
+				progress <- &proto.ProgressMetrics{
+					TotalIterations:     int32(len(points)) * simOptions.Iterations,
+					CompletedIterations: completed * simOptions.Iterations,
+					CompletedSims:       completed,
+					TotalSims:           int32(len(points)),
+				}
+			}
+		}(i, point)
+	}
+	waitGroup.Wait()
+
+	result := &proto.StatScalingResult{}
+	for i, simResult := range results {
+		if simResult == nil || simResult.Cancelled {
+			continue
+		}
+		if simResult.ErrorResult != "" {
+			return nil, errors.New("simulation failed: " + simResult.ErrorResult)
+		}
+		dps := simResult.RaidMetrics.Parties[0].Players[0].Dps
+		points[i].Dps = dps.Avg
+		points[i].DpsStdev = dps.Stdev
+		result.Points = append(result.Points, points[i])
+	}
+	if ctx.Err() != nil {
+		return result, nil
+	}
+
+	result.Breakpoints = append(result.Breakpoints, analyticBreakpoints(request, raidProto)...)
+	if len(request.Axes) == 1 {
+		result.Breakpoints = append(result.Breakpoints, findDpsJumps(request.Axes[0].Stat, result.Points)...)
+	}
+	sort.SliceStable(result.Breakpoints, func(i, j int) bool {
+		if result.Breakpoints[i].Stat != result.Breakpoints[j].Stat {
+			return result.Breakpoints[i].Stat < result.Breakpoints[j].Stat
+		}
+		return result.Breakpoints[i].Bonus < result.Breakpoints[j].Bonus
+	})
+	return result, nil
+}
+
+// analyticBreakpoints computes hit and expertise caps and DoT haste breakpoints from the
+// character's unbuffed stats, for every axis whose range contains them.
+func analyticBreakpoints(request *proto.StatScalingRequest, raidProto *proto.Raid) []*proto.StatScalingBreakpoint {
+	env, _, _ := NewEnvironment(raidProto, request.Encounter, false)
+	character := env.Raid.Parties[0].Players[0].GetCharacter()
+	target := env.Encounter.Targets[0]
+	attackTable := NewAttackTable(&character.Unit, &target.Unit)
+
+	// Hit and expertise that isn't part of the character's stats still counts towards the caps.
+	// Per-spell bonuses, like weapon specializations, are taken from the auto attacks.
+	var mhHit, ohHit, mhExpertise float64
+	if mh := character.AutoAttacks.MHAuto(); mh != nil {
+		mhHit, mhExpertise = mh.BonusHitRating, mh.BonusExpertiseRating
+	}
+	if oh := character.AutoAttacks.OHAuto(); oh != nil {
+		ohHit = oh.BonusHitRating
+	}
+
+	var breakpoints []*proto.StatScalingBreakpoint
+	for _, axis := range request.Axes {
+		stat := stats.Stat(axis.Stat)
+		current := character.GetStat(stat)
+		addCap := func(capRating float64, otherRating float64, breakpointType proto.StatScalingBreakpointType) {
+			bonus := capRating - otherRating - current
+			if capRating > 0 && bonus >= axis.Min && bonus <= axis.Max {
+				breakpoints = append(breakpoints, &proto.StatScalingBreakpoint{
+					Stat:  axis.Stat,
+					Bonus: bonus,
+					Type:  breakpointType,
+				})
+			}
+		}
+
+		switch stat {
+		case stats.MeleeHit:
+			hitTaken := target.PseudoStats.BonusMeleeHitRatingTaken
+			addCap(attackTable.BaseMissChance*MeleeHitRatingPerHitChance*100, mhHit+hitTaken, proto.StatScalingBreakpointType_BreakpointTypeMeleeHitCap)
+			if character.HasOHWeapon() {
+				addCap((attackTable.BaseMissChance+0.19)*MeleeHitRatingPerHitChance*100, ohHit+hitTaken, proto.StatScalingBreakpointType_BreakpointTypeDualWieldHitCap)
+			}
+		case stats.SpellHit:
+			addCap(attackTable.BaseSpellMissChance*SpellHitRatingPerHitChance*100, target.PseudoStats.BonusSpellHitRatingTaken, proto.StatScalingBreakpointType_BreakpointTypeSpellHitCap)
+		case stats.Expertise:
+			addCap(attackTable.BaseDodgeChance*ExpertisePerQuarterPercentReduction*400, mhExpertise, proto.StatScalingBreakpointType_BreakpointTypeDodgeCap)
+			addCap(attackTable.BaseParryChance*ExpertisePerQuarterPercentReduction*400, mhExpertise, proto.StatScalingBreakpointType_BreakpointTypeParryCap)
+		case stats.SpellHaste:
+			// DoT tick periods only use cast speed, so no other axis can move a tick breakpoint.
+			breakpoints = append(breakpoints, dotTickBreakpoints(character, &target.Unit, axis, current)...)
+		}
+	}
+	return breakpoints
+}
+
+// dotTickBreakpoints returns the haste rating bonuses at which each haste-affected DoT of the
+// character gains a tick. This mirrors the tick count rounding in Dot.RecomputeAuraDuration.
+func dotTickBreakpoints(character *Character, target *Unit, axis *proto.StatScalingAxis, currentRating float64) []*proto.StatScalingBreakpoint {
+	const hasteRatingPerUnit = HasteRatingPerHastePercent * 100
+	var breakpoints []*proto.StatScalingBreakpoint
+	seen := make(map[ActionID]bool)
+
+	for _, spell := range character.Spellbook {
+		dot := spell.Dot(target)
+		if dot == nil {
+			dot = spell.AOEDot()
+		}
+		if dot == nil || !dot.AffectedByCastSpeed || dot.isChanneled || dot.HasteAffectsDuration || dot.BaseTickCount == 0 || seen[spell.ActionID] {
+			continue
+		}
+		seen[spell.ActionID] = true
+
+		// ticks = round(baseTicks * castSpeedMultiplier * (1 + rating/hasteRatingPerUnit) / castTimeMultiplier)
+		scale := float64(dot.BaseTickCount) * character.PseudoStats.CastSpeedMultiplier / max(spell.CastTimeMultiplier, 1e-9)
+		ticksAt := func(bonus float64) float64 {
+			return scale * (1 + (currentRating+bonus)/hasteRatingPerUnit)
+		}
+		for ticks := int32(math.Floor(ticksAt(axis.Min)+0.5)) + 1; float64(ticks)-0.5 <= ticksAt(axis.Max); ticks++ {
+			rating := hasteRatingPerUnit * ((float64(ticks)-0.5)/scale - 1)
+			bonus := rating - currentRating
+			if bonus < axis.Min || bonus > axis.Max {
+				continue
+			}
+			breakpoints = append(breakpoints, &proto.StatScalingBreakpoint{
+				Stat:   axis.Stat,
+				Bonus:  bonus,
+				Type:   proto.StatScalingBreakpointType_BreakpointTypeDotTick,
+				Action: spell.ActionID.ToProto(),
+				Ticks:  ticks,
+			})
+		}
+	}
+	return breakpoints
+}
+
+// findDpsJumps looks for differences between neighbouring points that stand out from the rest
+// of the curve, and reports them halfway between the two points.
+func findDpsJumps(stat proto.Stat, points []*proto.StatScalingPoint) []*proto.StatScalingBreakpoint {
+	if len(points) < minStatScalingJumpPoints {
+		return nil
+	}
+
+	diffs := make([]float64, len(points)-1)
+	for i := range diffs {
+		diffs[i] = points[i+1].Dps - points[i].Dps
+	}
+	typical := median(diffs)
+	deviations := make([]float64, len(diffs))
+	for i, diff := range diffs {
+		deviations[i] = math.Abs(diff - typical)
+	}
+	// Scale the median absolute deviation to a standard deviation.
+	robustStdev := median(deviations) * 1.4826
+	if robustStdev == 0 {
+		return nil
+	}
+
+	var breakpoints []*proto.StatScalingBreakpoint
+	for i, deviation := range deviations {
+		if deviation > statScalingJumpThreshold*robustStdev {
+			breakpoints = append(breakpoints, &proto.StatScalingBreakpoint{
+				Stat:  stat,
+				Bonus: (points[i].Bonus[0] + points[i+1].Bonus[0]) / 2,
+				Type:  proto.StatScalingBreakpointType_BreakpointTypeDpsJump,
+			})
+		}
+	}
+	return breakpoints
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
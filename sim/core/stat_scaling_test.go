@@ -0,0 +1,51 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+func TestStatScalingHitCap(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 50
+
+	result := core.StatScalingSim(context.Background(), &proto.StatScalingRequest{
+		Player:     rsr.Raid.Parties[0].Players[0],
+		RaidBuffs:  rsr.Raid.Buffs,
+		PartyBuffs: rsr.Raid.Parties[0].Buffs,
+		Debuffs:    rsr.Raid.Debuffs,
+		Encounter:  rsr.Encounter,
+		SimOptions: rsr.SimOptions,
+		Axes: []*proto.StatScalingAxis{
+			{Stat: proto.Stat_StatMeleeHit, Min: -1000, Max: 200, Step: 200},
+		},
+	}, nil)
+	if result.ErrorResult != "" {
+		t.Fatalf("StatScalingSim() returned error: %s", result.ErrorResult)
+	}
+	if len(result.Points) != 7 {
+		t.Fatalf("StatScalingSim() returned %d points, want 7", len(result.Points))
+	}
+
+	// The test character is hit capped, so extra hit does nothing and less hit lowers DPS.
+	last := len(result.Points) - 1
+	if result.Points[last].Dps != result.Points[last-1].Dps {
+		t.Errorf("DPS above the hit cap changed from %0.2f to %0.2f", result.Points[last-1].Dps, result.Points[last].Dps)
+	}
+	if result.Points[0].Dps >= result.Points[last-1].Dps {
+		t.Errorf("DPS below the hit cap is %0.2f, want less than %0.2f", result.Points[0].Dps, result.Points[last-1].Dps)
+	}
+
+	foundCap := false
+	for _, breakpoint := range result.Breakpoints {
+		if breakpoint.Type == proto.StatScalingBreakpointType_BreakpointTypeMeleeHitCap {
+			foundCap = breakpoint.Bonus > -200 && breakpoint.Bonus <= 0
+		}
+	}
+	if !foundCap {
+		t.Errorf("Missing melee hit cap breakpoint between -200 and 0, got %v", result.Breakpoints)
+	}
+}
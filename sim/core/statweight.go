@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"math"
 	"runtime"
 	"sync"
@@ -51,6 +52,9 @@ type StatWeightValues struct {
 	WeightsStdev  UnitStats
 	EpValues      UnitStats
 	EpValuesStdev UnitStats
+
+	// Only set in regression mode.
+	RegressionFit *proto.StatWeightRegressionFit
 }
 
 func NewStatWeightValues() StatWeightValues {
@@ -68,6 +72,7 @@ func (swv *StatWeightValues) ToProto() *proto.StatWeightValues {
 		WeightsStdev:  swv.WeightsStdev.ToProto(),
 		EpValues:      swv.EpValues.ToProto(),
 		EpValuesStdev: swv.EpValuesStdev.ToProto(),
+		RegressionFit: swv.RegressionFit,
 	}
 }
 
@@ -78,6 +83,11 @@ type StatWeightsResult struct {
 	Dtps   StatWeightValues
 	Tmi    StatWeightValues
 	PDeath StatWeightValues
+
+	Cancelled bool
+
+	// Only set in sequential mode.
+	StatConfidences []*proto.StatWeightConfidence
 }
 
 func NewStatWeightsResult() *StatWeightsResult {
@@ -99,10 +109,22 @@ func (swr *StatWeightsResult) ToProto() *proto.StatWeightsResult {
 		Dtps:   swr.Dtps.ToProto(),
 		Tmi:    swr.Tmi.ToProto(),
 		PDeath: swr.PDeath.ToProto(),
+
+		Cancelled:       swr.Cancelled,
+		StatConfidences: swr.StatConfidences,
 	}
 }
 
-func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, progress chan *proto.ProgressMetrics) *StatWeightsResult {
+// CalcStatWeight sims a positive and negative offset of each requested stat against a shared baseline.
+// When ctx is cancelled the running sims are stopped, and only stats whose sims all finished get a weight.
+func CalcStatWeight(ctx context.Context, swr *proto.StatWeightsRequest, referenceStat stats.Stat, progress chan *proto.ProgressMetrics) *StatWeightsResult {
+	if swr.GetSequential().GetEnabled() {
+		return calcStatWeightSequential(ctx, swr, referenceStat, progress)
+	}
+	if swr.GetRegression().GetEnabled() {
+		return calcStatWeightRegression(ctx, swr, referenceStat, progress)
+	}
+
 	if swr.Player.BonusStats == nil {
 		swr.Player.BonusStats = &proto.UnitStats{}
 	}
@@ -143,7 +165,13 @@ func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, pro
 		Encounter:  swr.Encounter,
 		SimOptions: simOptions,
 	}
-	baselineResult := RunRaidSim(baseSimRequest)
+	baselineResult := RunRaidSimCtx(ctx, baseSimRequest)
+	if baselineResult.Cancelled {
+		// Weights need the full baseline to compare against.
+		result := NewStatWeightsResult()
+		result.Cancelled = true
+		return result
+	}
 	if baselineResult.ErrorResult != "" {
 		// TODO: get stack trace out.
 		return &StatWeightsResult{}
@@ -173,13 +201,17 @@ func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, pro
 	doStat := func(stat stats.UnitStat, value float64, isLow bool) {
 		defer waitGroup.Done()
 		// wait until we have CPU time available.
-		<-tickets
+		select {
+		case <-tickets:
+		case <-ctx.Done():
+			return
+		}
 
 		simRequest := googleProto.Clone(baseSimRequest).(*proto.RaidSimRequest)
 		stat.AddToStatsProto(simRequest.Raid.Parties[0].Players[0].BonusStats, value)
 
 		reporter := make(chan *proto.ProgressMetrics, 10)
-		go RunSim(simRequest, reporter, nil) // RunRaidSim(simRequest)
+		go RunSim(ctx, simRequest, reporter) // RunRaidSim(simRequest)
 
 		var localIterations int32
 		var errorStr string
@@ -206,9 +238,13 @@ func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, pro
 			}
 		}
 		// TODO: get stack trace out if final result error is set.
-		if errorStr != "" {
+		if errorStr != "" && !simResult.GetCancelled() {
 			panic("Stat weights error: " + errorStr)
 		}
+		if simResult.GetCancelled() {
+			// Partial results can't be compared to the baseline iteration by iteration.
+			simResult = nil
+		}
 
 		if isLow {
 			resultsLow[stat] = simResult
@@ -218,30 +254,7 @@ func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, pro
 		tickets <- struct{}{}
 	}
 
-	const defaultStatMod = 40.0 // match to the impact of a single gem
-	statModsLow := make([]float64, stats.UnitStatsLen)
-	statModsHigh := make([]float64, stats.UnitStatsLen)
-
-	// Make sure reference stat is included.
-	statModsLow[referenceStat] = defaultStatMod
-	statModsHigh[referenceStat] = defaultStatMod
-
-	statsToWeigh := stats.ProtoArrayToStatsList(swr.StatsToWeigh)
-	for _, s := range statsToWeigh {
-		stat := stats.UnitStatFromStat(s)
-		statMod := defaultStatMod
-		if stat.EqualsStat(stats.Armor) || stat.EqualsStat(stats.BonusArmor) {
-			statMod = defaultStatMod * 10
-		}
-		statModsHigh[stat] = statMod
-		statModsLow[stat] = -statMod
-	}
-	for _, s := range swr.PseudoStatsToWeigh {
-		stat := stats.UnitStatFromPseudoStat(s)
-		statMod := defaultStatMod * 0.5
-		statModsHigh[stat] = statMod
-		statModsLow[stat] = -statMod
-	}
+	statModsLow, statModsHigh := statWeightMods(swr, referenceStat)
 
 	// Start all the threads.
 	for i := range statModsLow {
@@ -262,9 +275,10 @@ func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, pro
 
 	// Compute weight results.
 	result := NewStatWeightsResult()
+	result.Cancelled = ctx.Err() != nil
 	for i := 0; i < stats.UnitStatsLen; i++ {
 		stat := stats.UnitStatFromIdx(i)
-		if resultsLow[stat] == nil && resultsHigh[stat] == nil {
+		if resultsLow[stat] == nil || resultsHigh[stat] == nil {
 			continue
 		}
 
@@ -309,10 +323,64 @@ func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, pro
 		result.PDeath.WeightsStdev.AddStat(stat, 0)
 	}
 
-	// Compute EP results.
-	for i := range statModsLow {
+	result.calcEpValues(statModsLow, referenceStat)
+	return result
+}
+
+// newStatWeightsRaid returns the raid of the request, with a copy of the player
+// whose bonus stats are ready to be modified.
+func newStatWeightsRaid(swr *proto.StatWeightsRequest) *proto.Raid {
+	player := googleProto.Clone(swr.Player).(*proto.Player)
+	if player.BonusStats == nil {
+		player.BonusStats = &proto.UnitStats{}
+	}
+	if player.BonusStats.Stats == nil {
+		player.BonusStats.Stats = make([]float64, stats.Len)
+	}
+	if player.BonusStats.PseudoStats == nil {
+		player.BonusStats.PseudoStats = make([]float64, stats.PseudoStatsLen)
+	}
+
+	raidProto := SinglePlayerRaidProto(player, swr.PartyBuffs, swr.RaidBuffs, swr.Debuffs)
+	raidProto.Tanks = swr.Tanks
+	return raidProto
+}
+
+// statWeightMods returns the amount each stat is lowered and raised by in the stat weight sims.
+// Stats that aren't weighed are 0 in both.
+func statWeightMods(swr *proto.StatWeightsRequest, referenceStat stats.Stat) ([]float64, []float64) {
+	const defaultStatMod = 40.0 // match to the impact of a single gem
+	statModsLow := make([]float64, stats.UnitStatsLen)
+	statModsHigh := make([]float64, stats.UnitStatsLen)
+
+	// Make sure reference stat is included.
+	statModsLow[referenceStat] = defaultStatMod
+	statModsHigh[referenceStat] = defaultStatMod
+
+	statsToWeigh := stats.ProtoArrayToStatsList(swr.StatsToWeigh)
+	for _, s := range statsToWeigh {
+		stat := stats.UnitStatFromStat(s)
+		statMod := defaultStatMod
+		if stat.EqualsStat(stats.Armor) || stat.EqualsStat(stats.BonusArmor) {
+			statMod = defaultStatMod * 10
+		}
+		statModsHigh[stat] = statMod
+		statModsLow[stat] = -statMod
+	}
+	for _, s := range swr.PseudoStatsToWeigh {
+		stat := stats.UnitStatFromPseudoStat(s)
+		statMod := defaultStatMod * 0.5
+		statModsHigh[stat] = statMod
+		statModsLow[stat] = -statMod
+	}
+	return statModsLow, statModsHigh
+}
+
+// calcEpValues fills in the EP values of all weighed stats from their weights.
+func (swr *StatWeightsResult) calcEpValues(statMods []float64, referenceStat stats.Stat) {
+	for i := range statMods {
 		stat := stats.UnitStatFromIdx(i)
-		if statModsLow[stat] == 0 {
+		if statMods[stat] == 0 {
 			continue
 		}
 
@@ -326,13 +394,11 @@ func CalcStatWeight(swr *proto.StatWeightsRequest, referenceStat stats.Stat, pro
 			weightResults.EpValuesStdev.AddStat(stat, stdev)
 		}
 
-		calcEpResults(&result.Dps, referenceStat)
-		calcEpResults(&result.Hps, referenceStat)
-		calcEpResults(&result.Tps, referenceStat)
-		calcEpResults(&result.Dtps, DTPSReferenceStat)
-		calcEpResults(&result.Tmi, DTPSReferenceStat)
-		calcEpResults(&result.PDeath, DTPSReferenceStat)
+		calcEpResults(&swr.Dps, referenceStat)
+		calcEpResults(&swr.Hps, referenceStat)
+		calcEpResults(&swr.Tps, referenceStat)
+		calcEpResults(&swr.Dtps, DTPSReferenceStat)
+		calcEpResults(&swr.Tmi, DTPSReferenceStat)
+		calcEpResults(&swr.PDeath, DTPSReferenceStat)
 	}
-
-	return result
 }
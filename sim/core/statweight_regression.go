@@ -0,0 +1,351 @@
+package core
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultRegressionSamplesPerTerm  = 3
+	defaultRegressionPerturbationMod = 2.0
+)
+
+// statWeightModel describes the terms of a stat weight regression. Stat changes are
+// normalized to [-1, 1] so the system stays well conditioned.
+type statWeightModel struct {
+	stats     []stats.UnitStat
+	scales    []float64 // Stat change that is normalized to 1, per stat.
+	quadratic bool
+}
+
+// numTerms returns the number of model coefficients, including the intercept.
+func (model *statWeightModel) numTerms() int {
+	n := len(model.stats)
+	if model.quadratic {
+		return 1 + n + n*(n+1)/2
+	}
+	return 1 + n
+}
+
+// row returns the model terms for a sample with the given normalized stat changes.
+func (model *statWeightModel) row(x []float64) []float64 {
+	row := make([]float64, 0, model.numTerms())
+	row = append(row, 1)
+	row = append(row, x...)
+	if model.quadratic {
+		for j := range x {
+			for k := j; k < len(x); k++ {
+				row = append(row, x[j]*x[k])
+			}
+		}
+	}
+	return row
+}
+
+// statWeightRegression is the least squares fit of a statWeightModel.
+type statWeightRegression struct {
+	coefficients []float64
+	stdErrors    []float64
+	rSquared     float64
+}
+
+// fitStatWeightRegression solves the normal equations of the least squares fit.
+// Returns false if there are too few samples or the samples don't determine all terms.
+func fitStatWeightRegression(rows [][]float64, y []float64) (statWeightRegression, bool) {
+	numTerms := len(rows[0])
+	if len(rows) <= numTerms {
+		return statWeightRegression{}, false
+	}
+
+	xtx := make([][]float64, numTerms)
+	xty := make([]float64, numTerms)
+	for j := range xtx {
+		xtx[j] = make([]float64, numTerms)
+	}
+	for i, row := range rows {
+		for j := range row {
+			xty[j] += row[j] * y[i]
+			for k := range row {
+				xtx[j][k] += row[j] * row[k]
+			}
+		}
+	}
+
+	inverse, ok := invertMatrix(xtx)
+	if !ok {
+		return statWeightRegression{}, false
+	}
+
+	fit := statWeightRegression{
+		coefficients: make([]float64, numTerms),
+		stdErrors:    make([]float64, numTerms),
+	}
+	for j := range inverse {
+		for k := range inverse[j] {
+			fit.coefficients[j] += inverse[j][k] * xty[k]
+		}
+	}
+
+	var mean float64
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(len(y))
+
+	var residualSumSq, totalSumSq float64
+	for i, row := range rows {
+		predicted := 0.0
+		for j := range row {
+			predicted += row[j] * fit.coefficients[j]
+		}
+		residualSumSq += (y[i] - predicted) * (y[i] - predicted)
+		totalSumSq += (y[i] - mean) * (y[i] - mean)
+	}
+
+	fit.rSquared = 1
+	if totalSumSq > 0 {
+		fit.rSquared = 1 - residualSumSq/totalSumSq
+	}
+	variance := residualSumSq / float64(len(rows)-numTerms)
+	for j := range fit.stdErrors {
+		fit.stdErrors[j] = math.Sqrt(max(0, variance*inverse[j][j]))
+	}
+	return fit, true
+}
+
+// invertMatrix inverts a square matrix with Gauss-Jordan elimination and partial pivoting.
+func invertMatrix(matrix [][]float64) ([][]float64, bool) {
+	n := len(matrix)
+	a := make([][]float64, n)
+	inverse := make([][]float64, n)
+	for i := range matrix {
+		a[i] = append([]float64{}, matrix[i]...)
+		inverse[i] = make([]float64, n)
+		inverse[i][i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+
+		scale := 1 / a[col][col]
+		for k := 0; k < n; k++ {
+			a[col][k] *= scale
+			inverse[col][k] *= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			factor := a[row][col]
+			for k := 0; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+				inverse[row][k] -= factor * inverse[col][k]
+			}
+		}
+	}
+	return inverse, true
+}
+
+// toValues writes the fitted weights in stat units into weightResults.
+func (model *statWeightModel) toValues(fit statWeightRegression, numSamples int, weightResults *StatWeightValues) {
+	for j, stat := range model.stats {
+		weightResults.Weights.AddStat(stat, fit.coefficients[1+j]/model.scales[j])
+		weightResults.WeightsStdev.AddStat(stat, fit.stdErrors[1+j]/model.scales[j])
+	}
+
+	regressionFit := &proto.StatWeightRegressionFit{
+		RSquared: fit.rSquared,
+		Samples:  int32(numSamples),
+	}
+	if model.quadratic {
+		interactions := make([]UnitStats, len(model.stats))
+		for j := range interactions {
+			interactions[j] = NewUnitStats()
+		}
+		term := 1 + len(model.stats)
+		for j := range model.stats {
+			for k := j; k < len(model.stats); k++ {
+				coefficient := fit.coefficients[term] / (model.scales[j] * model.scales[k])
+				interactions[j].AddStat(model.stats[k], coefficient)
+				if k != j {
+					interactions[k].AddStat(model.stats[j], coefficient)
+				}
+				term++
+			}
+		}
+		for j, stat := range model.stats {
+			statInteractions := &proto.StatWeightInteractions{
+				Coefficients: interactions[j].ToProto(),
+			}
+			if stat.IsStat() {
+				statInteractions.UnitStat = &proto.StatWeightInteractions_Stat{Stat: proto.Stat(stat.StatIdx())}
+			} else {
+				statInteractions.UnitStat = &proto.StatWeightInteractions_PseudoStat{PseudoStat: proto.PseudoStat(stat.PseudoStatIdx())}
+			}
+			regressionFit.Interactions = append(regressionFit.Interactions, statInteractions)
+		}
+	}
+	weightResults.RegressionFit = regressionFit
+}
+
+// calcStatWeightRegression sims random combined changes of all weighed stats with the same RNG seed,
+// and fits a linear or quadratic model of each metric against the stat changes.
+func calcStatWeightRegression(ctx context.Context, swr *proto.StatWeightsRequest, referenceStat stats.Stat, progress chan *proto.ProgressMetrics) *StatWeightsResult {
+	settings := swr.Regression
+
+	_, statModsHigh := statWeightMods(swr, referenceStat)
+	perturbationScale := settings.PerturbationScale
+	if perturbationScale <= 0 {
+		perturbationScale = defaultRegressionPerturbationMod
+	}
+	model := &statWeightModel{quadratic: settings.Quadratic}
+	for i, statMod := range statModsHigh {
+		if statMod == 0 {
+			continue
+		}
+		model.stats = append(model.stats, stats.UnitStatFromIdx(i))
+		model.scales = append(model.scales, statMod*perturbationScale)
+	}
+
+	numSamples := int(settings.NumSamples)
+	if numSamples <= 0 {
+		numSamples = model.numTerms() * defaultRegressionSamplesPerTerm
+	}
+	numSamples = max(numSamples, model.numTerms()+2)
+
+	simOptions := googleProto.Clone(swr.SimOptions).(*proto.SimOptions)
+	if settings.IterationsPerSample > 0 {
+		simOptions.Iterations = settings.IterationsPerSample
+	}
+	// Every sample uses the same seed, so the differences between them come from the stats only.
+	simOptions.IsTest = true
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+	baseSimRequest := &proto.RaidSimRequest{
+		Raid:       newStatWeightsRaid(swr),
+		Encounter:  swr.Encounter,
+		SimOptions: simOptions,
+	}
+
+	// The first sample is the unchanged character.
+	sampleRand := NewSplitMix(uint64(simOptions.RandomSeed))
+	samples := make([][]float64, numSamples)
+	for i := range samples {
+		samples[i] = make([]float64, len(model.stats))
+		if i == 0 {
+			continue
+		}
+		for j := range samples[i] {
+			samples[i][j] = sampleRand.NextFloat64()*2 - 1
+		}
+	}
+
+	concurrency := (runtime.NumCPU() - 1) * 2
+	if concurrency <= 0 {
+		concurrency = 2
+	}
+	tickets := make(chan struct{}, concurrency)
+	for i := 0; i < concurrency; i++ {
+		tickets <- struct{}{}
+	}
+
+	var waitGroup sync.WaitGroup
+	var simsCompleted int32
+	results := make([]*proto.RaidSimResult, numSamples)
+	for i := range samples {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			select {
+			case <-tickets:
+			case <-ctx.Done():
+				return
+			}
+
+			simRequest := googleProto.Clone(baseSimRequest).(*proto.RaidSimRequest)
+			for j, stat := range model.stats {
+				stat.AddToStatsProto(simRequest.Raid.Parties[0].Players[0].BonusStats, samples[i][j]*model.scales[j])
+			}
+			results[i] = RunSim(ctx, simRequest, nil)
+			tickets <- struct{}{}
+
+			completed := atomic.AddInt32(&simsCompleted, 1)
+			if progress != nil {
+				progress <- &proto.ProgressMetrics{
+					TotalIterations:     int32(numSamples) * simOptions.Iterations,
+					CompletedIterations: completed * simOptions.Iterations,
+					CompletedSims:       completed,
+					TotalSims:           int32(numSamples),
+				}
+			}
+		}(i)
+	}
+	waitGroup.Wait()
+
+	// Fit on all samples that finished, which is all of them unless cancelled.
+	var rows [][]float64
+	var players []*proto.UnitMetrics
+	for i, result := range results {
+		if result == nil || result.Cancelled {
+			continue
+		}
+		// TODO: get stack trace out if final result error is set.
+		if result.ErrorResult != "" {
+			panic("Stat weights error: " + result.ErrorResult)
+		}
+		rows = append(rows, model.row(samples[i]))
+		players = append(players, result.RaidMetrics.Parties[0].Players[0])
+	}
+
+	result := NewStatWeightsResult()
+	result.Cancelled = ctx.Err() != nil
+	if len(rows) == 0 {
+		return result
+	}
+	for _, metric := range newStatWeightMetrics(referenceStat) {
+		y := make([]float64, len(players))
+		for i, player := range players {
+			y[i] = metric.dist(player).Avg
+		}
+		fit, ok := fitStatWeightRegression(rows, y)
+		if !ok {
+			continue
+		}
+		model.toValues(fit, len(rows), metric.values(result))
+	}
+
+	// Chance of death is a single value per sim rather than a distribution, so it's fit separately.
+	deaths := make([]float64, len(players))
+	for i, player := range players {
+		deaths[i] = player.ChanceOfDeath
+	}
+	if fit, ok := fitStatWeightRegression(rows, deaths); ok {
+		model.toValues(fit, len(rows), &result.PDeath)
+	}
+
+	statMods := make([]float64, stats.UnitStatsLen)
+	for j, stat := range model.stats {
+		statMods[stat] = model.scales[j]
+	}
+	result.calcEpValues(statMods, referenceStat)
+	return result
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+func TestStatWeightRegressionFit(t *testing.T) {
+	model := &statWeightModel{
+		stats:     []stats.UnitStat{stats.UnitStatFromStat(stats.MeleeHaste), stats.UnitStatFromStat(stats.Mastery)},
+		scales:    []float64{100, 50},
+		quadratic: true,
+	}
+
+	// dps = 1000 + 2*haste + 1*mastery + 0.01*haste*mastery - 0.005*haste^2
+	rand := NewSplitMix(1)
+	var rows [][]float64
+	var y []float64
+	for i := 0; i < 20; i++ {
+		x := []float64{rand.NextFloat64()*2 - 1, rand.NextFloat64()*2 - 1}
+		haste, mastery := x[0]*model.scales[0], x[1]*model.scales[1]
+		rows = append(rows, model.row(x))
+		y = append(y, 1000+2*haste+mastery+0.01*haste*mastery-0.005*haste*haste)
+	}
+
+	fit, ok := fitStatWeightRegression(rows, y)
+	if !ok {
+		t.Fatalf("fitStatWeightRegression() failed")
+	}
+	values := NewStatWeightValues()
+	model.toValues(fit, len(rows), &values)
+
+	expectNear := func(label string, got, want float64) {
+		if math.Abs(got-want) > 1e-6 {
+			t.Fatalf("%s = %f, want %f", label, got, want)
+		}
+	}
+	expectNear("haste weight", values.Weights.Stats[stats.MeleeHaste], 2)
+	expectNear("mastery weight", values.Weights.Stats[stats.Mastery], 1)
+	expectNear("r squared", values.RegressionFit.RSquared, 1)
+
+	hasteInteractions := values.RegressionFit.Interactions[0].Coefficients.Stats
+	expectNear("haste squared", hasteInteractions[stats.MeleeHaste], -0.005)
+	expectNear("haste x mastery", hasteInteractions[stats.Mastery], 0.01)
+	masteryInteractions := values.RegressionFit.Interactions[1].Coefficients.Stats
+	expectNear("mastery x haste", masteryInteractions[stats.MeleeHaste], 0.01)
+}
+
+func TestStatWeightRegressionTooFewSamples(t *testing.T) {
+	model := &statWeightModel{
+		stats:  []stats.UnitStat{stats.UnitStatFromStat(stats.MeleeHaste)},
+		scales: []float64{100},
+	}
+	rows := [][]float64{model.row([]float64{0}), model.row([]float64{1})}
+	if _, ok := fitStatWeightRegression(rows, []float64{1, 2}); ok {
+		t.Fatalf("fitStatWeightRegression() succeeded without residual degrees of freedom")
+	}
+}
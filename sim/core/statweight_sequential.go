@@ -0,0 +1,350 @@
+package core
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultSequentialBatchIterations = 1000
+	defaultSequentialTargetEpError   = 0.05
+	defaultSequentialPruneZScore     = 2.0
+	defaultSequentialMinBatches      = 2
+)
+
+// statWeightMetric is one of the metrics stat weights are calculated for.
+type statWeightMetric struct {
+	dist    func(*proto.UnitMetrics) *proto.DistributionMetrics
+	values  func(*StatWeightsResult) *StatWeightValues
+	refStat stats.Stat
+}
+
+func newStatWeightMetrics(referenceStat stats.Stat) []statWeightMetric {
+	return []statWeightMetric{
+		{dist: func(um *proto.UnitMetrics) *proto.DistributionMetrics { return um.Dps }, values: func(r *StatWeightsResult) *StatWeightValues { return &r.Dps }, refStat: referenceStat},
+		{dist: func(um *proto.UnitMetrics) *proto.DistributionMetrics { return um.Hps }, values: func(r *StatWeightsResult) *StatWeightValues { return &r.Hps }, refStat: referenceStat},
+		{dist: func(um *proto.UnitMetrics) *proto.DistributionMetrics { return um.Threat }, values: func(r *StatWeightsResult) *StatWeightValues { return &r.Tps }, refStat: referenceStat},
+		{dist: func(um *proto.UnitMetrics) *proto.DistributionMetrics { return um.Dtps }, values: func(r *StatWeightsResult) *StatWeightValues { return &r.Dtps }, refStat: DTPSReferenceStat},
+		{dist: func(um *proto.UnitMetrics) *proto.DistributionMetrics { return um.Tmi }, values: func(r *StatWeightsResult) *StatWeightValues { return &r.Tmi }, refStat: DTPSReferenceStat},
+	}
+}
+
+// sequentialStatState accumulates the sim results of a single stat over all batches.
+type sequentialStatState struct {
+	stat             stats.UnitStat
+	modLow, modHigh  float64
+	weights          []aggregator // Per statWeightMetric, of the weight measured in each pair of low and high iterations.
+	deathSum         float64      // Iteration weighted sum of chance of death weights.
+	deathIterations  int32
+	iterations       int32
+	done             bool
+	converged        bool
+	pruned           bool
+	lastEpValue      float64
+	lastEpError      float64
+	lastIndifferent  bool // Whether the EP value was indistinguishable from 0 in all metrics.
+	lastHasEpMetrics bool
+}
+
+func (state *sequentialStatState) addBatch(metrics []statWeightMetric, baseline, low, high *proto.UnitMetrics, batchIterations int32) {
+	for m, metric := range metrics {
+		baseValues := metric.dist(baseline).AllValues
+		lowValues := metric.dist(low).AllValues
+		highValues := metric.dist(high).AllValues
+		for i := 0; i < int(batchIterations); i++ {
+			// The low and high iterations share their seed, so they are a single sample.
+			weightLow := (lowValues[i] - baseValues[i]) / state.modLow
+			weightHigh := (highValues[i] - baseValues[i]) / state.modHigh
+			state.weights[m].add((weightLow + weightHigh) / 2)
+		}
+	}
+
+	deathLow := (low.ChanceOfDeath - baseline.ChanceOfDeath) / state.modLow
+	deathHigh := (high.ChanceOfDeath - baseline.ChanceOfDeath) / state.modHigh
+	state.deathSum += (deathLow + deathHigh) / 2 * float64(batchIterations)
+	state.deathIterations += batchIterations
+	state.iterations += batchIterations
+}
+
+// updateConfidence computes the EP value and its standard error for every metric with a non-zero
+// reference weight, and keeps the least precise one. The error includes that of the reference
+// weight, except for the reference stat itself whose EP value is always 1.
+func (state *sequentialStatState) updateConfidence(metrics []statWeightMetric, refWeights []float64, refErrors []float64, isReference bool, pruneZScore float64) {
+	state.lastEpValue = 0
+	state.lastEpError = 0
+	state.lastIndifferent = true
+	state.lastHasEpMetrics = false
+	for m := range metrics {
+		if refWeights[m] == 0 || state.weights[m].n == 0 {
+			continue
+		}
+		mean, stdErr := state.weights[m].meanAndStdErr()
+		epValue := mean / refWeights[m]
+		refError := refErrors[m]
+		if isReference {
+			refError = 0
+		}
+		// First order error of the ratio, treating both weights as independent.
+		epError := math.Sqrt(stdErr*stdErr+epValue*epValue*refError*refError) / math.Abs(refWeights[m])
+		if !state.lastHasEpMetrics || epError > state.lastEpError {
+			state.lastEpValue = epValue
+			state.lastEpError = epError
+		}
+		state.lastHasEpMetrics = true
+		if math.Abs(epValue) > pruneZScore*epError {
+			state.lastIndifferent = false
+		}
+	}
+}
+
+func (state *sequentialStatState) toConfidenceProto() *proto.StatWeightConfidence {
+	confidence := &proto.StatWeightConfidence{
+		EpValue:    state.lastEpValue,
+		EpError:    state.lastEpError,
+		Iterations: state.iterations,
+		Converged:  state.converged,
+		Pruned:     state.pruned,
+	}
+	if state.stat.IsStat() {
+		confidence.UnitStat = &proto.StatWeightConfidence_Stat{Stat: proto.Stat(state.stat.StatIdx())}
+	} else {
+		confidence.UnitStat = &proto.StatWeightConfidence_PseudoStat{PseudoStat: proto.PseudoStat(state.stat.PseudoStatIdx())}
+	}
+	return confidence
+}
+
+// calcStatWeightSequential runs the stat weight sims in batches sharing RNG seeds with a baseline batch,
+// until every stat has converged, was pruned, or used up sim_options.iterations.
+func calcStatWeightSequential(ctx context.Context, swr *proto.StatWeightsRequest, referenceStat stats.Stat, progress chan *proto.ProgressMetrics) *StatWeightsResult {
+	settings := swr.Sequential
+	batchIterations := settings.BatchIterations
+	if batchIterations <= 0 {
+		batchIterations = defaultSequentialBatchIterations
+	}
+	targetEpError := settings.TargetEpError
+	if targetEpError <= 0 {
+		targetEpError = defaultSequentialTargetEpError
+	}
+	pruneZScore := settings.PruneZScore
+	if pruneZScore <= 0 {
+		pruneZScore = defaultSequentialPruneZScore
+	}
+	minBatches := settings.MinBatches
+	if minBatches <= 0 {
+		minBatches = defaultSequentialMinBatches
+	}
+	maxIterations := swr.SimOptions.Iterations
+
+	raidProto := newStatWeightsRaid(swr)
+	simOptions := googleProto.Clone(swr.SimOptions).(*proto.SimOptions)
+	simOptions.SaveAllValues = true
+	simOptions.IsTest = true
+	simOptions.Iterations = batchIterations
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+	baseSeed := simOptions.RandomSeed
+
+	metrics := newStatWeightMetrics(referenceStat)
+	statModsLow, statModsHigh := statWeightMods(swr, referenceStat)
+	states := make([]*sequentialStatState, stats.UnitStatsLen)
+	var orderedStates []*sequentialStatState
+	for i := range statModsLow {
+		if statModsLow[i] == 0 {
+			continue
+		}
+		states[i] = &sequentialStatState{
+			stat:    stats.UnitStatFromIdx(i),
+			modLow:  statModsLow[i],
+			modHigh: statModsHigh[i],
+			weights: make([]aggregator, len(metrics)),
+		}
+		orderedStates = append(orderedStates, states[i])
+	}
+	refState := states[referenceStat]
+
+	concurrency := (runtime.NumCPU() - 1) * 2
+	if concurrency <= 0 {
+		concurrency = 2
+	}
+	tickets := make(chan struct{}, concurrency)
+	for i := 0; i < concurrency; i++ {
+		tickets <- struct{}{}
+	}
+
+	var iterationsDone int32
+	var simsCompleted int32
+	var iterationsTotal int32
+	var simsTotal int32
+	updateTotals := func() {
+		// Upper bound assuming all remaining stats use their full iteration budget,
+		// in both their low and high sims.
+		remainingIterations := int32(0)
+		for _, state := range orderedStates {
+			if !state.done {
+				remainingIterations += 2 * max(0, maxIterations-state.iterations)
+			}
+		}
+		atomic.StoreInt32(&iterationsTotal, atomic.LoadInt32(&iterationsDone)+remainingIterations)
+		atomic.StoreInt32(&simsTotal, atomic.LoadInt32(&simsCompleted)+(remainingIterations+batchIterations-1)/batchIterations)
+	}
+	confidences := func() []*proto.StatWeightConfidence {
+		list := make([]*proto.StatWeightConfidence, 0, len(orderedStates))
+		for _, state := range orderedStates {
+			list = append(list, state.toConfidenceProto())
+		}
+		return list
+	}
+	updateTotals()
+
+	for batch := int32(0); ctx.Err() == nil; batch++ {
+		var active []*sequentialStatState
+		for _, state := range orderedStates {
+			if !state.done {
+				active = append(active, state)
+			}
+		}
+		if len(active) == 0 {
+			break
+		}
+
+		// Every sim in a batch uses the same seeds, so iterations can be compared one by one.
+		batchOptions := googleProto.Clone(simOptions).(*proto.SimOptions)
+		batchOptions.RandomSeed = baseSeed + int64(batch)*int64(batchIterations)
+		baseSimRequest := &proto.RaidSimRequest{
+			Raid:       raidProto,
+			Encounter:  swr.Encounter,
+			SimOptions: batchOptions,
+		}
+		baselineResult := RunRaidSimCtx(ctx, baseSimRequest)
+		if baselineResult.Cancelled {
+			break
+		}
+		if baselineResult.ErrorResult != "" {
+			// TODO: get stack trace out.
+			return &StatWeightsResult{}
+		}
+
+		var waitGroup sync.WaitGroup
+		resultsLow := make([]*proto.RaidSimResult, len(active))
+		resultsHigh := make([]*proto.RaidSimResult, len(active))
+		doStat := func(state *sequentialStatState, value float64, result **proto.RaidSimResult) {
+			defer waitGroup.Done()
+			select {
+			case <-tickets:
+			case <-ctx.Done():
+				return
+			}
+
+			simRequest := googleProto.Clone(baseSimRequest).(*proto.RaidSimRequest)
+			state.stat.AddToStatsProto(simRequest.Raid.Parties[0].Players[0].BonusStats, value)
+			*result = RunSim(ctx, simRequest, nil)
+			tickets <- struct{}{}
+
+			atomic.AddInt32(&iterationsDone, batchIterations)
+			atomic.AddInt32(&simsCompleted, 1)
+			if progress != nil {
+				progress <- &proto.ProgressMetrics{
+					TotalIterations:     atomic.LoadInt32(&iterationsTotal),
+					CompletedIterations: atomic.LoadInt32(&iterationsDone),
+					CompletedSims:       atomic.LoadInt32(&simsCompleted),
+					TotalSims:           atomic.LoadInt32(&simsTotal),
+				}
+			}
+		}
+		for i, state := range active {
+			waitGroup.Add(2)
+			go doStat(state, state.modLow, &resultsLow[i])
+			go doStat(state, state.modHigh, &resultsHigh[i])
+		}
+		waitGroup.Wait()
+
+		baselinePlayer := baselineResult.RaidMetrics.Parties[0].Players[0]
+		for i, state := range active {
+			low, high := resultsLow[i], resultsHigh[i]
+			if low == nil || high == nil || low.Cancelled || high.Cancelled {
+				continue
+			}
+			for _, simResult := range []*proto.RaidSimResult{low, high} {
+				// TODO: get stack trace out if final result error is set.
+				if simResult.ErrorResult != "" {
+					panic("Stat weights error: " + simResult.ErrorResult)
+				}
+			}
+			state.addBatch(metrics, baselinePlayer, low.RaidMetrics.Parties[0].Players[0], high.RaidMetrics.Parties[0].Players[0], batchIterations)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		refWeights := make([]float64, len(metrics))
+		refErrors := make([]float64, len(metrics))
+		for m := range metrics {
+			if refState.weights[m].n > 0 {
+				refWeights[m], refErrors[m] = refState.weights[m].meanAndStdErr()
+			}
+		}
+		for _, state := range active {
+			state.updateConfidence(metrics, refWeights, refErrors, state == refState, pruneZScore)
+			// Without any EP value there is nothing to converge on, so the stat uses its full budget.
+			if batch+1 >= minBatches && state.lastHasEpMetrics {
+				if state.lastEpError <= targetEpError {
+					state.converged = true
+				} else if state != refState && state.lastIndifferent {
+					state.pruned = true
+				}
+			}
+			state.done = state.converged || state.pruned || state.iterations >= maxIterations
+		}
+
+		// The EP errors of the other stats include the error of the reference weight,
+		// so it keeps getting more precise for as long as any of them runs.
+		if refState.iterations < maxIterations {
+			refState.done = true
+			for _, state := range orderedStates {
+				if state != refState && !state.done {
+					refState.done = false
+				}
+			}
+		}
+		updateTotals()
+
+		if progress != nil {
+			progress <- &proto.ProgressMetrics{
+				TotalIterations:     atomic.LoadInt32(&iterationsTotal),
+				CompletedIterations: atomic.LoadInt32(&iterationsDone),
+				CompletedSims:       atomic.LoadInt32(&simsCompleted),
+				TotalSims:           atomic.LoadInt32(&simsTotal),
+				StatConfidences:     confidences(),
+			}
+		}
+	}
+
+	result := NewStatWeightsResult()
+	result.Cancelled = ctx.Err() != nil
+	for _, state := range orderedStates {
+		if state.deathIterations == 0 {
+			continue
+		}
+		for m, metric := range metrics {
+			mean, stdev := state.weights[m].meanAndStdDev()
+			weightResults := metric.values(result)
+			weightResults.Weights.AddStat(state.stat, mean)
+			weightResults.WeightsStdev.AddStat(state.stat, stdev)
+		}
+		result.PDeath.Weights.AddStat(state.stat, state.deathSum/float64(state.deathIterations))
+		result.PDeath.WeightsStdev.AddStat(state.stat, 0)
+	}
+	result.calcEpValues(statModsLow, referenceStat)
+	result.StatConfidences = confidences()
+
+	return result
+}
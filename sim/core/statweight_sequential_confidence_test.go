@@ -0,0 +1,39 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSequentialStatConfidence(t *testing.T) {
+	// A weight of 2 with a standard error of 0.05, against a reference weight of 4 with a standard error of 0.2.
+	newState := func() *sequentialStatState {
+		weights := aggregator{}
+		for _, v := range []float64{1.9, 2.1, 1.9, 2.1} {
+			weights.add(v)
+		}
+		return &sequentialStatState{weights: []aggregator{weights}}
+	}
+	metrics := []statWeightMetric{{}}
+	refWeights, refErrors := []float64{4}, []float64{0.2}
+
+	state := newState()
+	state.updateConfidence(metrics, refWeights, refErrors, false, 2)
+	if want := math.Sqrt(0.05*0.05+0.5*0.5*0.2*0.2) / 4; state.lastEpValue != 0.5 || math.Abs(state.lastEpError-want) > 1e-9 {
+		t.Fatalf("Got EP %f +- %f, want 0.5 +- %f", state.lastEpValue, state.lastEpError, want)
+	}
+
+	// The reference stat's EP value is 1 by definition, so only its own error counts.
+	state = newState()
+	state.updateConfidence(metrics, refWeights, refErrors, true, 2)
+	if want := 0.05 / 4; math.Abs(state.lastEpError-want) > 1e-9 {
+		t.Fatalf("Got reference EP error %f, want %f", state.lastEpError, want)
+	}
+
+	// Without a reference weight there is no EP value at all.
+	state = newState()
+	state.updateConfidence(metrics, []float64{0}, refErrors, false, 2)
+	if state.lastHasEpMetrics {
+		t.Fatalf("Expected no EP metrics without a reference weight")
+	}
+}
@@ -0,0 +1,89 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+func newSequentialStatWeightsRequest(iterations int32, batchIterations int32, minBatches int32) *proto.StatWeightsRequest {
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = iterations
+
+	return &proto.StatWeightsRequest{
+		Player:          rsr.Raid.Parties[0].Players[0],
+		RaidBuffs:       rsr.Raid.Buffs,
+		PartyBuffs:      rsr.Raid.Parties[0].Buffs,
+		Debuffs:         rsr.Raid.Debuffs,
+		Encounter:       rsr.Encounter,
+		SimOptions:      rsr.SimOptions,
+		StatsToWeigh:    []proto.Stat{proto.Stat_StatAgility, proto.Stat_StatSpirit},
+		EpReferenceStat: proto.Stat_StatAgility,
+		Sequential: &proto.SequentialStatWeightsSettings{
+			Enabled:         true,
+			BatchIterations: batchIterations,
+			TargetEpError:   0.1,
+			MinBatches:      minBatches,
+		},
+	}
+}
+
+func runSequentialStatWeights(t *testing.T, swr *proto.StatWeightsRequest) (*proto.StatWeightsResult, bool) {
+	progress := make(chan *proto.ProgressMetrics, 100)
+	core.StatWeightsAsyncCtx(context.Background(), swr, progress)
+	var result *proto.StatWeightsResult
+	sawConfidences := false
+	for msg := range progress {
+		if len(msg.StatConfidences) > 0 {
+			sawConfidences = true
+		}
+		if msg.FinalWeightResult != nil {
+			result = msg.FinalWeightResult
+			break
+		}
+	}
+
+	if result.Cancelled || len(result.StatConfidences) != 2 {
+		t.Fatalf("Got cancelled %t and %d stat confidences, want 2 stats", result.Cancelled, len(result.StatConfidences))
+	}
+	return result, sawConfidences
+}
+
+func TestSequentialStatWeights(t *testing.T) {
+	const batchIterations = 100
+	const minBatches = 2
+	result, sawConfidences := runSequentialStatWeights(t, newSequentialStatWeightsRequest(2000, batchIterations, minBatches))
+
+	if !sawConfidences {
+		t.Fatalf("No progress update had stat confidences")
+	}
+	if ep := result.Dps.EpValues.Stats[stats.Agility]; ep != 1 {
+		t.Fatalf("Reference stat has EP %f, want 1", ep)
+	}
+
+	// Spirit does nothing for a hunter, so it should stop as soon as allowed.
+	spirit := result.StatConfidences[1]
+	if spirit.GetStat() != proto.Stat_StatSpirit || !(spirit.Converged || spirit.Pruned) {
+		t.Fatalf("Spirit confidence = %v, want converged or pruned", spirit)
+	}
+	if spirit.Iterations != batchIterations*minBatches {
+		t.Fatalf("Spirit used %d iterations, want %d", spirit.Iterations, batchIterations*minBatches)
+	}
+}
+
+func TestSequentialStatWeightsWithoutEpValues(t *testing.T) {
+	// Spirit has no weight for a hunter, so there is no EP value to converge on.
+	const iterations = 300
+	swr := newSequentialStatWeightsRequest(iterations, 100, 1)
+	swr.EpReferenceStat = proto.Stat_StatSpirit
+	result, _ := runSequentialStatWeights(t, swr)
+
+	for _, confidence := range result.StatConfidences {
+		if confidence.Converged || confidence.Pruned || confidence.Iterations != iterations {
+			t.Fatalf("Confidence = %v, want all %d iterations without converging or pruning", confidence, iterations)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+func TestRegressionStatWeights(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 20
+
+	result := core.StatWeights(&proto.StatWeightsRequest{
+		Player:          rsr.Raid.Parties[0].Players[0],
+		RaidBuffs:       rsr.Raid.Buffs,
+		PartyBuffs:      rsr.Raid.Parties[0].Buffs,
+		Debuffs:         rsr.Raid.Debuffs,
+		Encounter:       rsr.Encounter,
+		SimOptions:      rsr.SimOptions,
+		StatsToWeigh:    []proto.Stat{proto.Stat_StatAgility, proto.Stat_StatStamina},
+		EpReferenceStat: proto.Stat_StatAgility,
+		Regression: &proto.RegressionStatWeightsSettings{
+			Enabled:    true,
+			NumSamples: 8,
+		},
+	})
+	metrics := map[string]*proto.StatWeightValues{
+		"dps":    result.Dps,
+		"dtps":   result.Dtps,
+		"pdeath": result.PDeath,
+	}
+	for label, values := range metrics {
+		if values.RegressionFit == nil || values.RegressionFit.Samples != 8 {
+			t.Fatalf("expected a %s regression fit over 8 samples, got %v", label, values.RegressionFit)
+		}
+	}
+	if result.Dps.Weights.Stats[proto.Stat_StatAgility] <= 0 {
+		t.Fatalf("expected a positive agility dps weight, got %f", result.Dps.Weights.Stats[proto.Stat_StatAgility])
+	}
+}
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	goproto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+const (
+	defaultTalentSearchRounds  = 1
+	defaultTalentSearchResults = 10
+)
+
+// glyphSlot is a glyph field of the Glyphs proto, along with the enum of its glyphs.
+type glyphSlot struct {
+	field  protoreflect.FieldDescriptor
+	glyphs protoreflect.EnumDescriptor
+}
+
+// talentSearch generates the loadouts next to a loadout: every legal move of points between two
+// talents of the same tree, and every glyph swap.
+type talentSearch struct {
+	talentTrees []TalentTreeConfig
+	glyphSlots  []glyphSlot
+}
+
+func newTalentSearch(settings *proto.TalentSearchSettings, class proto.Class) (*talentSearch, error) {
+	search := &talentSearch{}
+	if settings.SearchTalents {
+		talentTrees, err := GetTalentTrees(class)
+		if err != nil {
+			return nil, err
+		}
+		search.talentTrees = talentTrees
+	}
+
+	className := strings.TrimPrefix(class.String(), "Class")
+	glyphsDescriptor := (&proto.Glyphs{}).ProtoReflect().Descriptor()
+	for _, glyphType := range []struct {
+		enabled bool
+		name    string
+	}{
+		{settings.SearchPrimeGlyphs, "Prime"},
+		{settings.SearchMajorGlyphs, "Major"},
+		{settings.SearchMinorGlyphs, "Minor"},
+	} {
+		if !glyphType.enabled {
+			continue
+		}
+		enumName := protoreflect.FullName(fmt.Sprintf("proto.%s%sGlyph", className, glyphType.name))
+		enumType, err := protoregistry.GlobalTypes.FindEnumByName(enumName)
+		if err != nil {
+			return nil, fmt.Errorf("no %s glyphs for class %s", strings.ToLower(glyphType.name), class)
+		}
+		for i := 1; i <= 3; i++ {
+			field := glyphsDescriptor.Fields().ByName(protoreflect.Name(fmt.Sprintf("%s%d", strings.ToLower(glyphType.name), i)))
+			search.glyphSlots = append(search.glyphSlots, glyphSlot{field: field, glyphs: enumType.Descriptor()})
+		}
+	}
+
+	if len(search.talentTrees) == 0 && len(search.glyphSlots) == 0 {
+		return nil, errors.New("talent search: nothing to search")
+	}
+	return search, nil
+}
+
+func talentLoadoutKey(loadout *proto.TalentLoadout) string {
+	glyphs, _ := goproto.MarshalOptions{Deterministic: true}.Marshal(loadout.Glyphs)
+	return loadout.TalentsString + "|" + string(glyphs)
+}
+
+// neighbours returns the loadouts that differ from loadout by one talent point move or glyph swap.
+func (search *talentSearch) neighbours(loadout *proto.TalentLoadout) []*proto.TalentLoadout {
+	var neighbours []*proto.TalentLoadout
+	describe := func(change string) string {
+		if loadout.Name == "" {
+			return change
+		}
+		return loadout.Name + ", " + change
+	}
+
+	if len(search.talentTrees) > 0 {
+		// The starting loadout was validated, so this can't fail.
+		points, _ := parseTalentPoints(search.talentTrees, loadout.TalentsString)
+		for treeIdx, tree := range search.talentTrees {
+			for from, fromTalent := range tree.Talents {
+				for to, toTalent := range tree.Talents {
+					if from == to {
+						continue
+					}
+					maxMoved := min(points[treeIdx][from], toTalent.MaxPoints-points[treeIdx][to])
+					for moved := 1; moved <= maxMoved; moved++ {
+						newPoints := points.clone()
+						newPoints[treeIdx][from] -= moved
+						newPoints[treeIdx][to] += moved
+						if newPoints.validate(search.talentTrees) != nil {
+							continue
+						}
+						neighbours = append(neighbours, &proto.TalentLoadout{
+							TalentsString: newPoints.String(),
+							Glyphs:        loadout.Glyphs,
+							Name:          describe(fmt.Sprintf("%d point(s) from %s to %s", moved, fromTalent.FancyName, toTalent.FancyName)),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	glyphs := loadout.Glyphs
+	if glyphs == nil {
+		glyphs = &proto.Glyphs{}
+	}
+	for _, slot := range search.glyphSlots {
+		current := protoreflect.EnumNumber(glyphs.ProtoReflect().Get(slot.field).Int())
+		equipped := map[protoreflect.EnumNumber]bool{}
+		for _, other := range search.glyphSlots {
+			if other.glyphs == slot.glyphs {
+				equipped[protoreflect.EnumNumber(glyphs.ProtoReflect().Get(other.field).Int())] = true
+			}
+		}
+
+		values := slot.glyphs.Values()
+		for i := 0; i < values.Len(); i++ {
+			glyph := values.Get(i)
+			if glyph.Number() == 0 || equipped[glyph.Number()] {
+				continue
+			}
+			newGlyphs := goproto.Clone(glyphs).(*proto.Glyphs)
+			newGlyphs.ProtoReflect().Set(slot.field, protoreflect.ValueOfInt32(int32(glyph.Number())))
+
+			change := fmt.Sprintf("%s in %s", glyph.Name(), slot.field.Name())
+			if current != 0 {
+				change = fmt.Sprintf("%s instead of %s", glyph.Name(), slot.glyphs.Values().ByNumber(current).Name())
+			}
+			neighbours = append(neighbours, &proto.TalentLoadout{
+				TalentsString: loadout.TalentsString,
+				Glyphs:        newGlyphs,
+				Name:          describe(change),
+			})
+		}
+	}
+	return neighbours
+}
+
+// searchTalents bulk sims the loadouts next to the player's talents and glyphs, and repeats that
+// for the best loadout found, for the configured number of rounds.
+func (b *bulkSimRunner) searchTalents(ctx context.Context, player *proto.Player, progress chan *proto.ProgressMetrics) (*proto.BulkSimResult, error) {
+	settings := b.Request.BulkSettings.TalentSearch
+	search, err := newTalentSearch(settings, player.Class)
+	if err != nil {
+		return nil, err
+	}
+	if len(search.talentTrees) > 0 {
+		points, err := parseTalentPoints(search.talentTrees, player.TalentsString)
+		if err != nil {
+			return nil, err
+		}
+		if err := points.validate(search.talentTrees); err != nil {
+			return nil, fmt.Errorf("talent search: invalid starting talents: %w", err)
+		}
+	}
+
+	rounds := int(settings.Rounds)
+	if rounds <= 0 {
+		rounds = defaultTalentSearchRounds
+	}
+	numResults := int(settings.NumResults)
+	if numResults <= 0 {
+		numResults = defaultTalentSearchResults
+	}
+
+	best := &proto.TalentLoadout{TalentsString: player.TalentsString, Glyphs: player.Glyphs}
+	seen := map[string]bool{talentLoadoutKey(best): true}
+	resultsByKey := map[string]*itemSubstitutionSimResult{}
+	var baseResult *itemSubstitutionSimResult
+
+	for round := 0; round < rounds; round++ {
+		var candidates []*proto.TalentLoadout
+		for _, loadout := range search.neighbours(best) {
+			if key := talentLoadoutKey(loadout); !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, loadout)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		// Requests are only created once the race asks for the combo. The equipped loadout is
+		// simmed every round, so the candidates are always compared against it.
+		combos := make(chan singleBulkSim)
+		go func() {
+			defer close(combos)
+			send := func(combo singleBulkSim) bool {
+				select {
+				case combos <- combo:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+			if !send(singleBulkSim{req: goproto.Clone(b.Request.BaseSettings).(*proto.RaidSimRequest), cl: &raidSimRequestChangeLog{}, eq: &equipmentSubstitution{}}) {
+				return
+			}
+			for _, loadout := range candidates {
+				request := goproto.Clone(b.Request.BaseSettings).(*proto.RaidSimRequest)
+				request.Raid.Parties[0].Players[0].TalentsString = loadout.TalentsString
+				request.Raid.Parties[0].Players[0].Glyphs = loadout.Glyphs
+				if !send(singleBulkSim{req: request, cl: &raidSimRequestChangeLog{TalentLoadout: loadout}, eq: &equipmentSubstitution{}}) {
+					return
+				}
+			}
+		}()
+
+		rankedResults, roundBase, err := b.rankCombos(ctx, combos, progress)
+		if err != nil {
+			return nil, err
+		}
+		baseResult = roundBase
+		for _, result := range rankedResults {
+			if !result.isBaseResult() {
+				resultsByKey[talentLoadoutKey(result.ChangeLog.TalentLoadout)] = result
+			}
+		}
+
+		roundBest := rankedResults[0]
+		if roundBest.isBaseResult() || (round > 0 && roundBest.Score() <= resultsByKey[talentLoadoutKey(best)].Score()) {
+			break
+		}
+		best = roundBest.ChangeLog.TalentLoadout
+	}
+
+	if baseResult == nil {
+		return nil, errors.New("talent search: no loadouts to search")
+	}
+	rankedResults := []*itemSubstitutionSimResult{baseResult}
+	for _, result := range resultsByKey {
+		rankedResults = append(rankedResults, result)
+	}
+	sortByScore(rankedResults)
+	return newBulkSimResult(rankedResults, baseResult, numResults), nil
+}
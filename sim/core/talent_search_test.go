@@ -0,0 +1,76 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+func TestTalentPointsValidate(t *testing.T) {
+	talentTrees, err := GetTalentTrees(proto.Class_ClassHunter)
+	if err != nil {
+		t.Fatalf("GetTalentTrees() returned error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		comment string
+		talents string
+		valid   bool
+	}{
+		{comment: "full marksmanship build", talents: "032002-2302320032120231221-03", valid: true},
+		{comment: "second row without points in the first row", talents: "-00020", valid: false},
+		{comment: "talent with more than its max points", talents: "4", valid: false},
+		{comment: "other tree before 31 points in the primary tree", talents: "032-23023", valid: false},
+		{comment: "prerequisite not maxed", talents: "-1322", valid: false},
+	} {
+		points, err := parseTalentPoints(talentTrees, tc.talents)
+		if err != nil {
+			t.Fatalf("%s: parseTalentPoints() returned error: %v", tc.comment, err)
+		}
+		if got := points.validate(talentTrees); (got == nil) != tc.valid {
+			t.Errorf("%s: validate(%s) = %v, want valid = %v", tc.comment, tc.talents, got, tc.valid)
+		}
+	}
+}
+
+func TestTalentSearchNeighbours(t *testing.T) {
+	search, err := newTalentSearch(&proto.TalentSearchSettings{
+		SearchTalents:     true,
+		SearchPrimeGlyphs: true,
+	}, proto.Class_ClassHunter)
+	if err != nil {
+		t.Fatalf("newTalentSearch() returned error: %v", err)
+	}
+
+	base := &proto.TalentLoadout{
+		TalentsString: "032002-2302320032120231221-03",
+		Glyphs: &proto.Glyphs{
+			Prime1: int32(proto.HunterPrimeGlyph_GlyphOfArcaneShot),
+			Prime2: int32(proto.HunterPrimeGlyph_GlyphOfRapidFire),
+		},
+	}
+	neighbours := search.neighbours(base)
+
+	var talentMoves, glyphSwaps int
+	for _, loadout := range neighbours {
+		if loadout.TalentsString != base.TalentsString {
+			talentMoves++
+			points, _ := parseTalentPoints(search.talentTrees, loadout.TalentsString)
+			if err := points.validate(search.talentTrees); err != nil {
+				t.Fatalf("neighbour %s (%s) is invalid: %v", loadout.TalentsString, loadout.Name, err)
+			}
+			continue
+		}
+		glyphSwaps++
+		if loadout.Glyphs.Prime1 == loadout.Glyphs.Prime2 || loadout.Glyphs.Prime1 == loadout.Glyphs.Prime3 || loadout.Glyphs.Prime2 == loadout.Glyphs.Prime3 {
+			t.Fatalf("neighbour %s has a duplicate glyph", loadout.Name)
+		}
+	}
+	if talentMoves == 0 {
+		t.Errorf("neighbours() returned no talent moves")
+	}
+	// 10 prime glyphs, 2 of which are equipped, for each of the 3 slots.
+	if glyphSwaps != 3*8 {
+		t.Errorf("neighbours() returned %d glyph swaps, want %d", glyphSwaps, 3*8)
+	}
+}
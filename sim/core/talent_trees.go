@@ -0,0 +1,191 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wowsims/cata/assets/talents"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// These need to stay synced with the talents picker in the UI.
+const (
+	talentPointsPerRow      = 5
+	maxPlayerTalentPoints   = 41
+	playerTreesUnlockPoints = 31
+)
+
+type TalentLocation struct {
+	RowIdx int `json:"rowIdx"`
+	ColIdx int `json:"colIdx"`
+}
+
+type TalentConfig struct {
+	FieldName string         `json:"fieldName"`
+	FancyName string         `json:"fancyName"`
+	Location  TalentLocation `json:"location"`
+	// Location of a talent that needs to be maxed before this one, if any.
+	PrereqLocation *TalentLocation `json:"prereqLocation"`
+	MaxPoints      int             `json:"maxPoints"`
+}
+
+// TalentTreeConfig is a talent tree, with talents in the same order as in talent strings.
+type TalentTreeConfig struct {
+	Name    string         `json:"name"`
+	Talents []TalentConfig `json:"talents"`
+}
+
+var talentTreeFiles = map[proto.Class]string{
+	proto.Class_ClassDeathKnight: "death_knight.json",
+	proto.Class_ClassDruid:       "druid.json",
+	proto.Class_ClassHunter:      "hunter.json",
+	proto.Class_ClassMage:        "mage.json",
+	proto.Class_ClassPaladin:     "paladin.json",
+	proto.Class_ClassPriest:      "priest.json",
+	proto.Class_ClassRogue:       "rogue.json",
+	proto.Class_ClassShaman:      "shaman.json",
+	proto.Class_ClassWarlock:     "warlock.json",
+	proto.Class_ClassWarrior:     "warrior.json",
+}
+
+var (
+	talentTreesMu    sync.Mutex
+	talentTreesCache = map[proto.Class][]TalentTreeConfig{}
+)
+
+// GetTalentTrees returns the talent trees of a class.
+func GetTalentTrees(class proto.Class) ([]TalentTreeConfig, error) {
+	talentTreesMu.Lock()
+	defer talentTreesMu.Unlock()
+	if cached, ok := talentTreesCache[class]; ok {
+		return cached, nil
+	}
+
+	file, ok := talentTreeFiles[class]
+	if !ok {
+		return nil, fmt.Errorf("no talent trees for class %s", class)
+	}
+	data, err := talents.FS.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var talentTrees []TalentTreeConfig
+	if err := json.Unmarshal(data, &talentTrees); err != nil {
+		return nil, fmt.Errorf("failed to parse talent trees of %s: %w", class, err)
+	}
+	talentTreesCache[class] = talentTrees
+	return talentTrees, nil
+}
+
+// talentPoints holds the points in each talent, per tree.
+type talentPoints [][]int
+
+func parseTalentPoints(talentTrees []TalentTreeConfig, talentsStr string) (talentPoints, error) {
+	treeStrs := strings.Split(talentsStr, "-")
+	if len(treeStrs) > len(talentTrees) {
+		return nil, fmt.Errorf("talent string %s has too many trees", talentsStr)
+	}
+
+	points := make(talentPoints, len(talentTrees))
+	for treeIdx, tree := range talentTrees {
+		points[treeIdx] = make([]int, len(tree.Talents))
+		if treeIdx >= len(treeStrs) {
+			continue
+		}
+		if len(treeStrs[treeIdx]) > len(tree.Talents) {
+			return nil, fmt.Errorf("talent string %s has too many talents in tree %s", talentsStr, tree.Name)
+		}
+		for talentIdx, talentValStr := range treeStrs[treeIdx] {
+			talentVal, err := strconv.Atoi(string(talentValStr))
+			if err != nil {
+				return nil, fmt.Errorf("invalid talent string %s: %w", talentsStr, err)
+			}
+			points[treeIdx][talentIdx] = talentVal
+		}
+	}
+	return points, nil
+}
+
+// String returns the talent string, with trailing 0's of each tree truncated.
+func (points talentPoints) String() string {
+	treeStrs := make([]string, len(points))
+	for treeIdx, treePoints := range points {
+		var sb strings.Builder
+		for _, talentVal := range treePoints {
+			sb.WriteString(strconv.Itoa(talentVal))
+		}
+		treeStrs[treeIdx] = strings.TrimRight(sb.String(), "0")
+	}
+	return strings.Join(treeStrs, "-")
+}
+
+func (points talentPoints) clone() talentPoints {
+	return MapSlice(points, func(treePoints []int) []int {
+		return append([]int(nil), treePoints...)
+	})
+}
+
+func (points talentPoints) treeTotal(treeIdx int) int {
+	total := 0
+	for _, talentVal := range points[treeIdx] {
+		total += talentVal
+	}
+	return total
+}
+
+// validate checks the same rules as the talents picker in the UI: talents can't exceed their
+// max points, each row needs 5 points per row above it in the same tree, prerequisites need to
+// be maxed, and other trees are only unlocked with 31 points in the primary tree.
+func (points talentPoints) validate(talentTrees []TalentTreeConfig) error {
+	total := 0
+	primaryTree := 0
+	for treeIdx, tree := range talentTrees {
+		treeTotal := points.treeTotal(treeIdx)
+		total += treeTotal
+		if treeTotal > points.treeTotal(primaryTree) {
+			primaryTree = treeIdx
+		}
+
+		pointsByRow := map[int]int{}
+		for talentIdx, talent := range tree.Talents {
+			pointsByRow[talent.Location.RowIdx] += points[treeIdx][talentIdx]
+		}
+		for talentIdx, talent := range tree.Talents {
+			talentVal := points[treeIdx][talentIdx]
+			if talentVal < 0 || talentVal > talent.MaxPoints {
+				return fmt.Errorf("%s has %d points, max is %d", talent.FancyName, talentVal, talent.MaxPoints)
+			}
+			if talentVal == 0 {
+				continue
+			}
+
+			pointsAbove := 0
+			for rowIdx := 0; rowIdx < talent.Location.RowIdx; rowIdx++ {
+				pointsAbove += pointsByRow[rowIdx]
+			}
+			if pointsAbove < talent.Location.RowIdx*talentPointsPerRow {
+				return fmt.Errorf("%s needs %d points in the rows above", talent.FancyName, talent.Location.RowIdx*talentPointsPerRow)
+			}
+
+			if talent.PrereqLocation != nil {
+				for prereqIdx, prereq := range tree.Talents {
+					if prereq.Location == *talent.PrereqLocation && points[treeIdx][prereqIdx] < prereq.MaxPoints {
+						return fmt.Errorf("%s needs %s to be maxed", talent.FancyName, prereq.FancyName)
+					}
+				}
+			}
+		}
+	}
+
+	if total > maxPlayerTalentPoints {
+		return fmt.Errorf("%d talent points spent, max is %d", total, maxPlayerTalentPoints)
+	}
+	primaryTotal := points.treeTotal(primaryTree)
+	if total > primaryTotal && primaryTotal < playerTreesUnlockPoints {
+		return fmt.Errorf("other trees need %d points in the primary tree", playerTreesUnlockPoints)
+	}
+	return nil
+}
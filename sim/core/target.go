@@ -1,6 +1,9 @@
 package core
 
 import (
+	"cmp"
+	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
@@ -12,9 +15,15 @@ type Encounter struct {
 	Duration          time.Duration
 	DurationVariation time.Duration
 	Targets           []*Target
-	ActiveTargets     []*Target
 	TargetUnits       []*Unit
 
+	// Targets which are currently spawned, in target order. Until the first
+	// iteration starts every target is active, so per-target data can be sized
+	// using these. The lists are replaced whenever a target spawns or despawns,
+	// so it is safe to keep iterating over them while that happens.
+	ActiveTargets     []*Target
+	ActiveTargetUnits []*Unit
+
 	ExecuteProportion_20 float64
 	ExecuteProportion_25 float64
 	ExecuteProportion_35 float64
@@ -27,8 +36,20 @@ type Encounter struct {
 	// In health fight: set to true until we get something to base on
 	DurationIsEstimate bool
 
+	// Inputs shared by all targets, e.g. the raid size and difficulty.
+	Inputs []*proto.TargetInput
+
+	// In health fights, the target whose health drives the execute phases.
+	executeTarget *Target
+
 	// Value to multiply by, for damage spells which are subject to the aoe cap.
 	aoeCapMultiplier float64
+
+	// Raid units that were moved off a despawned target during the iteration.
+	retargetedUnits []*Unit
+
+	raidDamageEvents []*raidDamageEvent
+	targetGroups     []*targetGroup
 }
 
 func NewEncounter(options *proto.Encounter) Encounter {
@@ -44,11 +65,11 @@ func NewEncounter(options *proto.Encounter) Encounter {
 		ExecuteProportion_90: max(options.ExecuteProportion_90, 0),
 		Targets:              []*Target{},
 		ActiveTargets:        []*Target{},
+		Inputs:               options.Inputs,
 	}
 	for targetIndex, targetOptions := range options.Targets {
 		target := NewTarget(targetOptions, int32(targetIndex))
 		encounter.Targets = append(encounter.Targets, target)
-		encounter.ActiveTargets = append(encounter.ActiveTargets, target)
 		encounter.TargetUnits = append(encounter.TargetUnits, &target.Unit)
 	}
 	if len(encounter.Targets) == 0 {
@@ -56,9 +77,9 @@ func NewEncounter(options *proto.Encounter) Encounter {
 		// computing character stats, and targets won't matter there.
 		target := NewTarget(&proto.Target{}, 0)
 		encounter.Targets = append(encounter.Targets, target)
-		encounter.ActiveTargets = append(encounter.ActiveTargets, target)
 		encounter.TargetUnits = append(encounter.TargetUnits, &target.Unit)
 	}
+	encounter.updateActiveTargets()
 
 	// If UseHealth is set, we use the sum of targets health. After creating the targets to make sure stat modifications are done
 	if options.UseHealth {
@@ -68,7 +89,11 @@ func NewEncounter(options *proto.Encounter) Encounter {
 		if encounter.EndFightAtHealth == 0 {
 			encounter.EndFightAtHealth = 1 // default to something so we don't instantly end without anything.
 		}
+		if encounter.Targets[0].GetStat(stats.Health) > 0 {
+			encounter.executeTarget = encounter.Targets[0]
+		}
 	}
+	encounter.constructTargetGroups(options.TargetGroups)
 
 	if encounter.EndFightAtHealth > 0 {
 		// Until we pre-sim set duration to 10m
@@ -76,8 +101,6 @@ func NewEncounter(options *proto.Encounter) Encounter {
 		encounter.DurationIsEstimate = true
 	}
 
-	encounter.updateAOECapMultiplier()
-
 	return encounter
 }
 
@@ -85,7 +108,65 @@ func (encounter *Encounter) AOECapMultiplier() float64 {
 	return encounter.aoeCapMultiplier
 }
 func (encounter *Encounter) updateAOECapMultiplier() {
-	encounter.aoeCapMultiplier = min(10/float64(len(encounter.Targets)), 1)
+	encounter.aoeCapMultiplier = AOECapMultiplierForTargets(len(encounter.ActiveTargets))
+}
+
+// Value to multiply by, for damage spells which are subject to the aoe cap and hit
+// the given number of targets.
+func AOECapMultiplierForTargets(numTargets int) float64 {
+	return min(10/float64(max(numTargets, 1)), 1)
+}
+
+// Rebuilds the active target lists from the targets' IsActive flags.
+func (encounter *Encounter) updateActiveTargets() {
+	activeTargets := make([]*Target, 0, len(encounter.Targets))
+	activeTargetUnits := make([]*Unit, 0, len(encounter.Targets))
+	for _, target := range encounter.Targets {
+		if target.IsActive {
+			activeTargets = append(activeTargets, target)
+			activeTargetUnits = append(activeTargetUnits, &target.Unit)
+		}
+	}
+	encounter.ActiveTargets = activeTargets
+	encounter.ActiveTargetUnits = activeTargetUnits
+	encounter.updateAOECapMultiplier()
+}
+
+// Points raid units which are targeting a despawned target at the first active target.
+func (encounter *Encounter) retargetRaid(sim *Simulation) {
+	if len(encounter.ActiveTargetUnits) == 0 {
+		return
+	}
+	newTarget := encounter.ActiveTargetUnits[0]
+	for _, unit := range sim.Raid.AllUnits {
+		if unit.CurrentTarget == nil || !unit.CurrentTarget.isDespawned() {
+			continue
+		}
+		if !slices.Contains(encounter.retargetedUnits, unit) {
+			encounter.retargetedUnits = append(encounter.retargetedUnits, unit)
+		}
+		unit.CurrentTarget = newTarget
+		if sim.Log != nil {
+			unit.Log(sim, "Switching target to %s", newTarget.Label)
+		}
+	}
+}
+
+func (encounter *Encounter) reset(sim *Simulation) {
+	// Reset primary targets damage taken for tracking health fights.
+	encounter.DamageTaken = 0
+
+	for _, unit := range encounter.retargetedUnits {
+		unit.CurrentTarget = unit.defaultTarget
+	}
+	encounter.retargetedUnits = encounter.retargetedUnits[:0]
+
+	for _, target := range encounter.Targets {
+		target.Reset(sim)
+	}
+	encounter.updateActiveTargets()
+	encounter.resetRaidDamageEvents(sim)
+	encounter.resetTargetGroups(sim)
 }
 
 func (encounter *Encounter) doneIteration(sim *Simulation) {
@@ -116,6 +197,35 @@ type Target struct {
 	IsActive bool
 
 	AI TargetAI
+
+	spawnAt        time.Duration
+	despawnAt      time.Duration
+	despawnOnDeath bool
+
+	// The group this target is fought with, if any.
+	group *targetGroup
+
+	// Damage taken during the iteration, for targets with health.
+	damageTaken float64
+
+	// Sorted by descending health fraction.
+	healthTriggers    []healthTrigger
+	nextHealthTrigger int
+}
+
+type healthTrigger struct {
+	fraction float64
+	callback func(*Simulation)
+}
+
+// validateTargets returns an error for target settings the sim can't run with.
+func validateTargets(encounter *proto.Encounter) error {
+	for i, target := range encounter.GetTargets() {
+		if target.DespawnTime > 0 && target.DespawnTime <= max(target.SpawnTime, 0) {
+			return fmt.Errorf("Target %d despawns at %gs, before it spawns at %gs", i+1, target.DespawnTime, max(target.SpawnTime, 0))
+		}
+	}
+	return validateRaidDamageEvents(encounter.GetRaidDamageEvents())
 }
 
 func NewTarget(options *proto.Target, targetIndex int32) *Target {
@@ -140,10 +250,22 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 			ReactionTime:          time.Millisecond * 1620,
 		},
 		IsActive: true,
+
+		spawnAt:        max(DurationFromSeconds(options.SpawnTime), 0),
+		despawnAt:      NeverExpires,
+		despawnOnDeath: options.DespawnOnDeath && unitStats[stats.Health] > 0,
+	}
+	if options.DespawnTime > 0 {
+		target.despawnAt = DurationFromSeconds(options.DespawnTime)
+		if target.despawnAt <= target.spawnAt {
+			panic(fmt.Sprintf("%s despawns at %s, before it spawns at %s", target.Label, target.despawnAt, target.spawnAt))
+		}
 	}
 	defaultRaidBossLevel := int32(CharacterLevel + 3)
 	target.GCD = target.NewTimer()
 	target.RotationTimer = target.NewTimer()
+	startPosition := Vector2FromProto(options.Position)
+	target.startPosition = &startPosition
 	if target.Level == 0 {
 		target.Level = defaultRaidBossLevel
 	}
@@ -157,9 +279,11 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 	target.PseudoStats.ParryHaste = options.ParryHaste
 	target.PseudoStats.InFrontOfTarget = true
 	target.PseudoStats.DamageSpread = options.DamageSpread
+	target.Immunities = ImmunitiesFromProto(options)
 
-	preset := GetPresetTargetWithID(options.Id)
-	if preset != nil && preset.AI != nil {
+	if options.Replay != nil && replayAIFactory != nil {
+		target.AI = replayAIFactory(options.Replay)()
+	} else if preset := GetPresetTargetWithID(options.Id); preset != nil && preset.AI != nil {
 		target.AI = preset.AI()
 	}
 
@@ -169,19 +293,172 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 func (target *Target) Reset(sim *Simulation) {
 	target.Unit.reset(sim, nil)
 	target.CurrentTarget = target.defaultTarget
+	target.resetPosition()
+
+	target.IsActive = target.spawnAt <= 0
+	target.enabled = target.IsActive
+	target.damageTaken = 0
+	target.nextHealthTrigger = 0
+	if target.IsActive {
+		target.SetGCDTimer(sim, 0)
+	} else if target.spawnAt != NeverExpires {
+		sim.AddPendingAction(&PendingAction{
+			NextActionAt: target.spawnAt,
+			OnAction:     target.Spawn,
+		})
+	}
+	if target.despawnAt != NeverExpires {
+		sim.AddPendingAction(&PendingAction{
+			NextActionAt: target.despawnAt,
+			OnAction:     target.Despawn,
+		})
+	}
 
-	target.SetGCDTimer(sim, 0)
 	if target.AI != nil {
 		target.AI.Reset(sim)
 	}
 }
 
+// Spawn makes the target active, so it can be attacked and starts acting.
+func (target *Target) Spawn(sim *Simulation) {
+	if target.IsActive {
+		return
+	}
+	target.IsActive = true
+	target.enabled = true
+	if sim.Log != nil {
+		target.Log(sim, "Spawned")
+	}
+
+	encounter := &sim.Encounter
+	encounter.updateActiveTargets()
+	encounter.retargetRaid(sim)
+
+	if sim.CurrentTime >= 0 {
+		target.AutoAttacks.EnableAutoSwing(sim)
+	}
+	target.SetGCDTimer(sim, max(0, sim.CurrentTime))
+}
+
+// Despawn removes the target from the fight until the end of the iteration. Its auras are
+// expired, and raid members targeting it move on to the next active target.
+func (target *Target) Despawn(sim *Simulation) {
+	if !target.IsActive {
+		return
+	}
+	target.IsActive = false
+	target.enabled = false
+	if sim.Log != nil {
+		target.Log(sim, "Despawned")
+	}
+
+	target.AutoAttacks.CancelAutoSwing(sim)
+	if target.rotationAction != nil {
+		target.CancelGCDTimer(sim)
+	}
+	target.auraTracker.expireAll(sim)
+
+	encounter := &sim.Encounter
+	encounter.updateActiveTargets()
+	encounter.retargetRaid(sim)
+}
+
+// Returns the health the target has left in this iteration.
+func (target *Target) RemainingHealth() float64 {
+	return max(target.GetStat(stats.Health)-target.damageTaken, 0)
+}
+
+// Keeps the target despawned at the start of every iteration, until something calls Spawn.
+func (target *Target) DisableAutoSpawn() {
+	target.spawnAt = NeverExpires
+}
+
+// Returns the fraction (0-1) of health the target has left in this iteration, or 1
+// for targets without health.
+func (target *Target) HealthPercent() float64 {
+	health := target.GetStat(stats.Health)
+	if health <= 0 {
+		return 1
+	}
+	return max(1-target.damageTaken/health, 0)
+}
+
+// Registers a callback for the first time in each iteration that the target's health
+// drops to the given fraction (0-1). Has no effect for targets without health.
+func (target *Target) OnHealthPercent(fraction float64, callback func(*Simulation)) {
+	target.healthTriggers = append(target.healthTriggers, healthTrigger{
+		fraction: fraction,
+		callback: callback,
+	})
+	slices.SortStableFunc(target.healthTriggers, func(a, b healthTrigger) int {
+		return cmp.Compare(b.fraction, a.fraction)
+	})
+}
+
+// Tracks damage taken by targets with health, including by the other targets of
+// a shared health group.
+func (target *Target) takeDamage(sim *Simulation, damage float64) {
+	if target.group != nil && target.group.config.HealthMode == proto.TargetGroup_Shared {
+		for _, member := range target.group.targets {
+			member.loseHealth(sim, damage)
+		}
+		return
+	}
+	target.loseHealth(sim, damage)
+}
+
+// Removes health from the target, firing health triggers and handling its death.
+func (target *Target) loseHealth(sim *Simulation, damage float64) {
+	health := target.GetStat(stats.Health)
+	if health <= 0 || !target.IsActive {
+		return
+	}
+	wasAlive := target.damageTaken < health
+	target.damageTaken += damage
+
+	for target.nextHealthTrigger < len(target.healthTriggers) {
+		trigger := target.healthTriggers[target.nextHealthTrigger]
+		if target.HealthPercent() > trigger.fraction {
+			break
+		}
+		target.nextHealthTrigger++
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt:     sim.CurrentTime,
+			OnAction: trigger.callback,
+		})
+	}
+
+	if !wasAlive || target.damageTaken < health {
+		return
+	}
+	if target.group != nil && target.group.config.HealthMode == proto.TargetGroup_Linked {
+		for _, member := range target.group.targets {
+			if remainingHealth := member.RemainingHealth(); remainingHealth > 0 && member.IsActive {
+				sim.Encounter.DamageTaken += remainingHealth
+				member.loseHealth(sim, remainingHealth)
+			}
+		}
+	}
+	if target.despawnOnDeath {
+		// Despawn once the current spell is done, like player deaths.
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt:     sim.CurrentTime,
+			OnAction: target.Despawn,
+		})
+	}
+}
+
+// Returns the next active target after this one, wrapping around. If no other
+// target is active, returns this target.
 func (target *Target) NextTarget() *Target {
-	nextIndex := target.Index + 1
-	if nextIndex >= target.Env.GetNumTargets() {
-		nextIndex = 0
+	targets := target.Env.Encounter.Targets
+	for i := 1; i < len(targets); i++ {
+		next := targets[(int(target.Index)+i)%len(targets)]
+		if next.IsActive {
+			return next
+		}
 	}
-	return target.Env.GetTarget(nextIndex)
+	return target
 }
 
 func (target *Target) GetMetricsProto() *proto.UnitMetrics {
@@ -4,6 +4,8 @@ import (
 	"log"
 
 	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	goproto "google.golang.org/protobuf/proto"
 )
 
 type TargetAI interface {
@@ -70,6 +72,11 @@ type PresetTarget struct {
 	Config *proto.Target
 
 	AI AIFactory
+
+	// Optional hook for presets whose health and damage depend on the encounter
+	// inputs, e.g. the chosen raid size and difficulty. The results only replace
+	// values which are still at the preset's defaults, so edits are kept.
+	ScaledStats func(inputs []*proto.TargetInput) (health float64, minBaseDamage float64)
 }
 
 func (pt PresetTarget) Path() string {
@@ -86,6 +93,13 @@ func (pt PresetTarget) ToProto() *proto.PresetTarget {
 var presetTargets []*PresetTarget
 var PresetEncounters []*proto.PresetEncounter
 
+// Builds the AI of targets which replay a real pull, see proto.Target.Replay.
+var replayAIFactory func(replay *proto.ReplayTarget) AIFactory
+
+func RegisterReplayAIFactory(factory func(replay *proto.ReplayTarget) AIFactory) {
+	replayAIFactory = factory
+}
+
 func AddPresetTarget(newPreset *PresetTarget) {
 	for _, preset := range presetTargets {
 		if preset.Path() == newPreset.Path() {
@@ -104,6 +118,95 @@ func GetPresetTargetWithPath(path string) *PresetTarget {
 	return nil
 }
 
+// NPC IDs of presets which were merged into a preset with encounter inputs, so
+// saved encounters which still use them keep working.
+type legacyPresetTarget struct {
+	id     int32
+	inputs []*proto.TargetInput
+}
+
+var legacyPresetTargets = map[int32]legacyPresetTarget{}
+
+// Registers the NPC ID of a removed preset target. Targets using it are simmed as
+// the preset with the given ID, with the given encounter inputs unless the
+// encounter has its own.
+func AddLegacyPresetTarget(legacyID int32, id int32, inputs []*proto.TargetInput) {
+	if _, ok := legacyPresetTargets[legacyID]; ok {
+		log.Fatalf("Legacy Preset Target with ID %d already added!", legacyID)
+	}
+	legacyPresetTargets[legacyID] = legacyPresetTarget{
+		id:     id,
+		inputs: inputs,
+	}
+}
+
+// Returns the encounter with legacy preset IDs replaced and preset stats scaled
+// to the encounter inputs. The given proto is left unchanged.
+func applyPresetTargetInputs(encounterProto *proto.Encounter) *proto.Encounter {
+	var applied *proto.Encounter
+	clone := func() {
+		if applied == nil {
+			applied = goproto.Clone(encounterProto).(*proto.Encounter)
+		}
+	}
+
+	for i, targetProto := range encounterProto.GetTargets() {
+		if legacy, ok := legacyPresetTargets[targetProto.Id]; ok {
+			clone()
+			applied.Targets[i].Id = legacy.id
+			if len(applied.Inputs) == 0 {
+				for _, input := range legacy.inputs {
+					applied.Inputs = append(applied.Inputs, goproto.Clone(input).(*proto.TargetInput))
+				}
+			}
+		}
+	}
+	if applied != nil {
+		encounterProto = applied
+	}
+
+	for i, targetProto := range encounterProto.GetTargets() {
+		preset := GetPresetTargetWithID(targetProto.Id)
+		if preset == nil || preset.ScaledStats == nil {
+			continue
+		}
+		health, minBaseDamage := preset.ScaledStats(encounterProto.Inputs)
+		if len(targetProto.Stats) > int(stats.Health) && targetProto.Stats[stats.Health] == preset.Config.Stats[stats.Health] {
+			clone()
+			applied.Targets[i].Stats[stats.Health] = health
+		}
+		if targetProto.MinBaseDamage == preset.Config.MinBaseDamage {
+			clone()
+			applied.Targets[i].MinBaseDamage = minBaseDamage
+		}
+	}
+
+	if applied == nil {
+		return encounterProto
+	}
+	return applied
+}
+
+// Returns the input with the given label, or nil if there is none.
+func FindTargetInput(inputs []*proto.TargetInput, label string) *proto.TargetInput {
+	for _, input := range inputs {
+		if input.Label == label {
+			return input
+		}
+	}
+	return nil
+}
+
+// Returns the target input with the given label, or nil if the config doesn't have one.
+func GetTargetInput(config *proto.Target, label string) *proto.TargetInput {
+	return FindTargetInput(config.TargetInputs, label)
+}
+
+// Returns the encounter input with the given label, or nil if there is none.
+func (encounter *Encounter) GetInput(label string) *proto.TargetInput {
+	return FindTargetInput(encounter.Inputs, label)
+}
+
 func GetPresetTargetWithID(id int32) *PresetTarget {
 	for _, preset := range presetTargets {
 		if preset.Config.Id == id {
@@ -114,6 +217,12 @@ func GetPresetTargetWithID(id int32) *PresetTarget {
 }
 
 func AddPresetEncounter(name string, targetPaths []string) {
+	AddPresetEncounterWithInputs(name, targetPaths, nil)
+}
+
+// Adds a preset encounter whose targets depend on the given inputs, see
+// PresetTarget.ScaledStats and Encounter.GetInput.
+func AddPresetEncounterWithInputs(name string, targetPaths []string, inputs []*proto.TargetInput) {
 	if len(targetPaths) == 0 {
 		log.Fatalf("Encounter must have targets!")
 	}
@@ -142,5 +251,6 @@ func AddPresetEncounter(name string, targetPaths []string) {
 	PresetEncounters = append(PresetEncounters, &proto.PresetEncounter{
 		Path:    path,
 		Targets: targetProtos,
+		Inputs:  inputs,
 	})
 }
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+// Targets which are fought together, e.g. the bosses of a council fight.
+type targetGroup struct {
+	config  *proto.TargetGroup
+	targets []*Target
+
+	// The raid's tanks, in swap order. Only set for groups with tank swaps.
+	tanks []*Unit
+}
+
+func (encounter *Encounter) constructTargetGroups(configs []*proto.TargetGroup) {
+	for _, config := range configs {
+		group := &targetGroup{
+			config: config,
+		}
+		for _, targetIndex := range config.TargetIndices {
+			if targetIndex < 0 || int(targetIndex) >= len(encounter.Targets) {
+				panic(fmt.Sprintf("Invalid target index %d in target group", targetIndex))
+			}
+			target := encounter.Targets[targetIndex]
+			if target.group != nil {
+				panic(fmt.Sprintf("Target %d is in more than one target group", targetIndex))
+			}
+			target.group = group
+			group.targets = append(group.targets, target)
+		}
+
+		if config.HealthMode == proto.TargetGroup_Shared && encounter.EndFightAtHealth > 0 {
+			// The group only has one health pool worth of damage to take.
+			for _, target := range group.targets[1:] {
+				encounter.EndFightAtHealth -= target.GetStat(stats.Health)
+			}
+		}
+
+		encounter.targetGroups = append(encounter.targetGroups, group)
+	}
+}
+
+// Sets up the tank swaps of the encounter's target groups, between the given tanks.
+func (encounter *Encounter) initializeTargetGroups(tanks []*Unit) {
+	for _, group := range encounter.targetGroups {
+		swap := group.config.TankSwap
+		if swap == nil {
+			continue
+		}
+		group.tanks = tanks
+
+		if swap.DebuffSpellId != 0 {
+			if swap.DebuffStacks <= 0 || swap.DebuffDuration <= 0 {
+				panic("Tank swap debuffs need a duration and a number of stacks to swap at")
+			}
+			group.registerSwapDebuffs(swap)
+		}
+	}
+}
+
+// Registers the stacking debuff the group's targets put on their tank, and swaps
+// tanks once it reaches its maximum stacks.
+func (group *targetGroup) registerSwapDebuffs(swap *proto.TankSwap) {
+	debuffs := make([]*Aura, len(group.tanks))
+	for i, tank := range group.tanks {
+		debuffs[i] = tank.GetOrRegisterAura(Aura{
+			Label:     fmt.Sprintf("Tank Swap Debuff %d", swap.DebuffSpellId),
+			ActionID:  ActionID{SpellID: swap.DebuffSpellId},
+			Duration:  DurationFromSeconds(swap.DebuffDuration),
+			MaxStacks: swap.DebuffStacks,
+			OnStacksChange: func(aura *Aura, sim *Simulation, oldStacks int32, newStacks int32) {
+				if newStacks == aura.MaxStacks && oldStacks < newStacks {
+					group.swapTanks(sim)
+				}
+			},
+		})
+	}
+
+	for _, target := range group.targets {
+		MakePermanent(target.RegisterAura(Aura{
+			Label: fmt.Sprintf("Tank Swap Trigger %d", swap.DebuffSpellId),
+			OnSpellHitDealt: func(aura *Aura, sim *Simulation, spell *Spell, result *SpellResult) {
+				if !result.Landed() || !spell.ProcMask.Matches(ProcMaskMelee) {
+					return
+				}
+				tankIndex := slices.Index(group.tanks, result.Target)
+				if tankIndex == -1 {
+					return
+				}
+				debuff := debuffs[tankIndex]
+				debuff.Activate(sim)
+				debuff.AddStack(sim)
+			},
+		}))
+	}
+}
+
+// Returns whether the unit tanks any target during the encounter, because of tank swaps.
+func (encounter *Encounter) isSwapTank(unit *Unit) bool {
+	for _, group := range encounter.targetGroups {
+		if slices.Contains(group.tanks, unit) {
+			return true
+		}
+	}
+	return false
+}
+
+func (encounter *Encounter) resetTargetGroups(sim *Simulation) {
+	for _, group := range encounter.targetGroups {
+		if swap := group.config.TankSwap; swap != nil && swap.Interval > 0 {
+			group.scheduleSwap(sim, DurationFromSeconds(swap.StartTime))
+		}
+	}
+}
+
+func (group *targetGroup) scheduleSwap(sim *Simulation, doAt time.Duration) {
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: max(doAt, sim.CurrentTime),
+		OnAction: func(sim *Simulation) {
+			group.swapTanks(sim)
+			group.scheduleSwap(sim, sim.CurrentTime+DurationFromSeconds(group.config.TankSwap.Interval))
+		},
+	})
+}
+
+// Moves every target of the group tanked by one of the tanks to the next tank.
+func (group *targetGroup) swapTanks(sim *Simulation) {
+	if len(group.tanks) < 2 {
+		return
+	}
+
+	// Work out all the new tanks first, so that a target's new tank doesn't
+	// affect the targets after it.
+	newTanks := make([]*Unit, len(group.targets))
+	for i, target := range group.targets {
+		tankIndex := slices.Index(group.tanks, target.CurrentTarget)
+		if tankIndex != -1 && target.IsActive {
+			newTanks[i] = group.tanks[(tankIndex+1)%len(group.tanks)]
+		}
+	}
+
+	encounter := &sim.Encounter
+	for i, target := range group.targets {
+		tank := newTanks[i]
+		if tank == nil {
+			continue
+		}
+		if sim.Log != nil {
+			target.Log(sim, "Tank swap from %s to %s", target.CurrentTarget.Label, tank.Label)
+		}
+		target.CurrentTarget = tank
+
+		if tank.CurrentTarget != &target.Unit {
+			tank.CurrentTarget = &target.Unit
+			if !slices.Contains(encounter.retargetedUnits, tank) {
+				encounter.retargetedUnits = append(encounter.retargetedUnits, tank)
+			}
+			tank.returnToRange(sim)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+// Records when each target in the encounter dies.
+type deathRecordingAI struct {
+	deaths map[int32][]time.Duration
+}
+
+func (ai *deathRecordingAI) Initialize(target *core.Target, _ *proto.Target) {
+	target.OnHealthPercent(0, func(sim *core.Simulation) {
+		ai.deaths[target.Index] = append(ai.deaths[target.Index], sim.CurrentTime)
+	})
+}
+func (ai *deathRecordingAI) Reset(_ *core.Simulation)                 {}
+func (ai *deathRecordingAI) ExecuteCustomRotation(_ *core.Simulation) {}
+
+func runTargetGroupHealthTest(t *testing.T, id int32, healthMode proto.TargetGroup_HealthMode) (map[int32][]time.Duration, *proto.RaidSimResult) {
+	config := newTestTarget()
+	config.Id = id
+	config.Name = "Grouped Target " + healthMode.String()
+	config.Stats[stats.Health] = 1_000_000
+	config.TankIndex = -1
+	ai := &deathRecordingAI{deaths: map[int32][]time.Duration{}}
+	setTestPreset(&core.PresetTarget{
+		Config: config,
+		AI: func() core.TargetAI {
+			ai.deaths = map[int32][]time.Duration{}
+			return ai
+		},
+	})
+
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 5
+	rsr.Encounter.Targets = []*proto.Target{config, config}
+	rsr.Encounter.TargetGroups = []*proto.TargetGroup{
+		{TargetIndices: []int32{0, 1}, HealthMode: healthMode},
+	}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	if len(ai.deaths[0]) != int(rsr.SimOptions.Iterations) {
+		t.Fatalf("expected the first target to die in every iteration, got %d deaths", len(ai.deaths[0]))
+	}
+	return ai.deaths, result
+}
+
+func TestTargetGroupSharedHealth(t *testing.T) {
+	deaths, result := runTargetGroupHealthTest(t, 990800, proto.TargetGroup_Shared)
+
+	for i, deathTime := range deaths[0] {
+		if i >= len(deaths[1]) || deaths[1][i] != deathTime {
+			t.Fatalf("expected both targets to die together, got %v and %v", deaths[0], deaths[1])
+		}
+	}
+	// The hunter focuses the first target, so the second dies from shared damage.
+	if targetDamageTaken(result, 1) >= targetDamageTaken(result, 0) {
+		t.Fatalf("expected the hunter to focus the first target")
+	}
+}
+
+func TestTargetGroupLinkedHealth(t *testing.T) {
+	deaths, _ := runTargetGroupHealthTest(t, 990801, proto.TargetGroup_Linked)
+
+	for i, deathTime := range deaths[0] {
+		if i >= len(deaths[1]) || deaths[1][i] != deathTime {
+			t.Fatalf("expected the second target to die with the first, got %v and %v", deaths[0], deaths[1])
+		}
+	}
+}
+
+// Returns the number of melee hits the first target landed on each player.
+func targetHitsOnPlayers(result *proto.RaidSimResult) []int32 {
+	var hits []int32
+	for _, player := range result.RaidMetrics.Parties[0].Players {
+		playerHits := int32(0)
+		for _, action := range result.EncounterMetrics.Targets[0].Actions {
+			if action.Id.GetOtherId() != proto.OtherAction_OtherActionAttack {
+				continue
+			}
+			for _, target := range action.Targets {
+				if target.UnitIndex == player.UnitIndex {
+					playerHits += target.Hits
+				}
+			}
+		}
+		hits = append(hits, playerHits)
+	}
+	return hits
+}
+
+func makeTankSwapTestCase(tankSwap *proto.TankSwap) *proto.RaidSimRequest {
+	rsr := makeTestCase(getTestPlayerBloodDk())
+	rsr.Raid.Parties[0].Players = append(rsr.Raid.Parties[0].Players, getTestPlayerBloodDk())
+	rsr.Raid.Tanks = []*proto.UnitReference{
+		{Type: proto.UnitReference_Player, Index: 0},
+		{Type: proto.UnitReference_Player, Index: 1},
+	}
+	rsr.SimOptions.Iterations = 5
+	rsr.Encounter.Duration = 120
+	rsr.Encounter.Targets = []*proto.Target{newTestTarget()}
+	rsr.Encounter.TargetGroups = []*proto.TargetGroup{
+		{TargetIndices: []int32{0}, TankSwap: tankSwap},
+	}
+	return rsr
+}
+
+func TestTimedTankSwaps(t *testing.T) {
+	rsr := makeTankSwapTestCase(nil)
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	if hits := targetHitsOnPlayers(result); hits[0] == 0 || hits[1] != 0 {
+		t.Fatalf("expected only the first tank to be hit without swaps, got %v", hits)
+	}
+
+	rsr = makeTankSwapTestCase(&proto.TankSwap{StartTime: 30, Interval: 30})
+	result = core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	// Each tank has the target for half of the fight.
+	hits := targetHitsOnPlayers(result)
+	if hits[0] == 0 || hits[1] == 0 || hits[0] > 2*hits[1] || hits[1] > 2*hits[0] {
+		t.Fatalf("expected both tanks to take a similar number of hits, got %v", hits)
+	}
+}
+
+func TestDebuffTankSwaps(t *testing.T) {
+	const debuffID = 990810
+
+	rsr := makeTankSwapTestCase(&proto.TankSwap{DebuffSpellId: debuffID, DebuffStacks: 4, DebuffDuration: 20})
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	if hits := targetHitsOnPlayers(result); hits[0] == 0 || hits[1] == 0 {
+		t.Fatalf("expected both tanks to be hit, got %v", hits)
+	}
+	for i, player := range result.RaidMetrics.Parties[0].Players {
+		uptime := 0.0
+		for _, aura := range player.Auras {
+			if aura.Id.GetSpellId() == debuffID {
+				uptime = aura.UptimeSecondsAvg
+			}
+		}
+		if uptime <= 0 {
+			t.Fatalf("expected tank %d to get the swap debuff", i)
+		}
+	}
+}
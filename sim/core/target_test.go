@@ -0,0 +1,420 @@
+package core_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+)
+
+func newTestTarget() *proto.Target {
+	return goproto.Clone(core.NewDefaultTarget()).(*proto.Target)
+}
+
+var (
+	testPresets   = map[int32]*core.PresetTarget{}
+	testLegacyIDs = map[int32]bool{}
+)
+
+// Registers the preset under "Test", so its AI is used for targets with the config's
+// ID. Presets are global, so when a test runs again (e.g. with -count) only the AI
+// and stats hook of the preset registered the first time are replaced.
+func setTestPreset(preset *core.PresetTarget) {
+	if registered, ok := testPresets[preset.Config.Id]; ok {
+		registered.AI = preset.AI
+		registered.ScaledStats = preset.ScaledStats
+		return
+	}
+	preset.PathPrefix = "Test"
+	testPresets[preset.Config.Id] = preset
+	core.AddPresetTarget(preset)
+}
+
+// Returns the damage the raid did to a target, summed over all iterations.
+func targetDamageTaken(result *proto.RaidSimResult, targetIndex int) float64 {
+	total := 0.0
+	for _, party := range result.RaidMetrics.Parties {
+		for _, player := range party.Players {
+			units := append([]*proto.UnitMetrics{player}, player.Pets...)
+			for _, unit := range units {
+				for _, action := range unit.Actions {
+					for _, target := range action.Targets {
+						if target.UnitIndex == int32(targetIndex) {
+							total += target.Damage
+						}
+					}
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestTargetActivationWindows(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 20
+	rsr.Encounter.Duration = 60
+
+	late := newTestTarget()
+	late.SpawnTime = 30
+	early := newTestTarget()
+	early.DespawnTime = 30
+	rsr.Encounter.Targets = []*proto.Target{late, early}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	// The player starts on the second target, since the first one isn't up yet, and
+	// switches over once the second one despawns.
+	lateDamage := targetDamageTaken(result, 0)
+	earlyDamage := targetDamageTaken(result, 1)
+	if lateDamage <= 0 || earlyDamage <= 0 {
+		t.Fatalf("expected damage on both targets, got %0.0f and %0.0f", lateDamage, earlyDamage)
+	}
+}
+
+func TestTargetDespawnBeforeSpawn(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 20
+
+	target := newTestTarget()
+	target.SpawnTime = 30
+	target.DespawnTime = 20
+	rsr.Encounter.Targets = []*proto.Target{target}
+
+	result := core.RunRaidSim(rsr)
+	if !strings.Contains(result.ErrorResult, "despawns at 20s, before it spawns at 30s") {
+		t.Fatalf("expected an error for the target despawning before it spawns, got %q", result.ErrorResult)
+	}
+
+	statsResult := core.ComputeStats(&proto.ComputeStatsRequest{Raid: rsr.Raid, Encounter: rsr.Encounter})
+	if !strings.Contains(statsResult.ErrorResult, "despawns at 20s, before it spawns at 30s") {
+		t.Fatalf("expected the same error when computing stats, got %q", statsResult.ErrorResult)
+	}
+}
+
+func TestTargetDespawnOnDeath(t *testing.T) {
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 20
+	rsr.Encounter.Duration = 60
+
+	const addHealth = 300000
+	add := newTestTarget()
+	add.Stats[stats.Health] = addHealth
+	add.DespawnOnDeath = true
+	boss := newTestTarget()
+	rsr.Encounter.Targets = []*proto.Target{add, boss}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	// The killing blow can overkill the add.
+	addDamage := targetDamageTaken(result, 0) / float64(rsr.SimOptions.Iterations)
+	if addDamage < addHealth || addDamage > addHealth*1.2 {
+		t.Fatalf("expected the add to take about %d damage before dying, got %0.0f", addHealth, addDamage)
+	}
+	if bossDamage := targetDamageTaken(result, 1); bossDamage <= 0 {
+		t.Fatalf("expected the player to switch to the boss after the add died")
+	}
+}
+
+// Records the target's health when a health callback fires and when the sim enters
+// the 20% execute phase.
+type healthTrackingAI struct {
+	target *core.Target
+
+	healthAtCallback []float64
+	healthAtExecute  []float64
+	inExecute        bool
+}
+
+func (ai *healthTrackingAI) Initialize(target *core.Target, _ *proto.Target) {
+	ai.target = target
+	target.OnHealthPercent(0.35, func(sim *core.Simulation) {
+		ai.healthAtCallback = append(ai.healthAtCallback, target.HealthPercent())
+	})
+}
+func (ai *healthTrackingAI) Reset(_ *core.Simulation) {
+	ai.inExecute = false
+}
+func (ai *healthTrackingAI) ExecuteCustomRotation(sim *core.Simulation) {
+	if !ai.inExecute && sim.IsExecutePhase20() {
+		ai.inExecute = true
+		ai.healthAtExecute = append(ai.healthAtExecute, ai.target.HealthPercent())
+	}
+	ai.target.WaitUntil(sim, sim.CurrentTime+time.Millisecond*100)
+}
+
+func TestTargetHealthPhases(t *testing.T) {
+	primary := newTestTarget()
+	primary.Id = 990300
+	primary.Name = "Health Tracking Target"
+	primary.Stats[stats.Health] = 1_000_000
+	var ai *healthTrackingAI
+	setTestPreset(&core.PresetTarget{
+		Config: primary,
+		AI: func() core.TargetAI {
+			ai = &healthTrackingAI{}
+			return ai
+		},
+	})
+
+	// The second target is never attacked, so execute phases based on the combined
+	// health of both targets would never be reached.
+	other := newTestTarget()
+	other.Stats[stats.Health] = 1_000_000
+
+	rsr := makeTestCase(getTestPlayerMM())
+	rsr.SimOptions.Iterations = 5
+	rsr.Encounter.UseHealth = true
+	rsr.Encounter.Targets = []*proto.Target{primary, other}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	// Health fights run extra iterations to estimate the duration, so only check
+	// that every iteration saw the callback.
+	if len(ai.healthAtCallback) < int(rsr.SimOptions.Iterations) {
+		t.Fatalf("expected the 35%% callback in every iteration, got %d calls", len(ai.healthAtCallback))
+	}
+	for _, health := range ai.healthAtCallback {
+		if health > 0.35 || health < 0.3 {
+			t.Fatalf("expected the 35%% callback at 35%% health, got %0.3f", health)
+		}
+	}
+
+	if len(ai.healthAtExecute) != len(ai.healthAtCallback) {
+		t.Fatalf("expected execute phase in every iteration, got %d", len(ai.healthAtExecute))
+	}
+	for _, health := range ai.healthAtExecute {
+		if health > 0.2 || health < 0.1 {
+			t.Fatalf("expected execute phase at 20%% health, got %0.3f", health)
+		}
+	}
+}
+
+// Records the stats the target was created with.
+type statsRecordingAI struct {
+	health float64
+	heroic bool
+}
+
+func (ai *statsRecordingAI) Initialize(target *core.Target, _ *proto.Target) {
+	ai.health = target.GetStat(stats.Health)
+	ai.heroic = target.Env.Encounter.GetInput("Heroic").GetBoolValue()
+}
+func (ai *statsRecordingAI) Reset(_ *core.Simulation)                 {}
+func (ai *statsRecordingAI) ExecuteCustomRotation(_ *core.Simulation) {}
+
+func TestPresetTargetInputs(t *testing.T) {
+	heroicInputs := func(heroic bool) []*proto.TargetInput {
+		return []*proto.TargetInput{{Label: "Heroic", InputType: proto.InputType_Bool, BoolValue: heroic}}
+	}
+
+	config := newTestTarget()
+	config.Id = 990400
+	config.Name = "Input Target"
+	config.Stats[stats.Health] = 2_000_000
+	var ai *statsRecordingAI
+	setTestPreset(&core.PresetTarget{
+		Config: config,
+		AI: func() core.TargetAI {
+			ai = &statsRecordingAI{}
+			return ai
+		},
+		ScaledStats: func(inputs []*proto.TargetInput) (float64, float64) {
+			heroic := core.FindTargetInput(inputs, "Heroic").GetBoolValue()
+			return core.Ternary(heroic, 2_000_000.0, 1_000_000.0), config.MinBaseDamage
+		},
+	})
+	// Saved encounters may still use the ID of the old heroic preset.
+	const legacyID = 990401
+	if !testLegacyIDs[legacyID] {
+		testLegacyIDs[legacyID] = true
+		core.AddLegacyPresetTarget(legacyID, config.Id, heroicInputs(true))
+	}
+
+	runTarget := func(target *proto.Target, inputs []*proto.TargetInput) {
+		rsr := makeTestCase(getTestPlayerMM())
+		rsr.SimOptions.Iterations = 1
+		rsr.Encounter.Targets = []*proto.Target{target}
+		rsr.Encounter.Inputs = inputs
+		if result := core.RunRaidSim(rsr); result.ErrorResult != "" {
+			t.Fatalf("sim failed: %s", result.ErrorResult)
+		}
+	}
+
+	for _, heroic := range []bool{false, true} {
+		target := goproto.Clone(config).(*proto.Target)
+		runTarget(target, heroicInputs(heroic))
+
+		expectedHealth := core.Ternary(heroic, 2_000_000.0, 1_000_000.0)
+		if ai.health != expectedHealth || ai.heroic != heroic {
+			t.Fatalf("heroic = %t: expected the target to be created with %0.0f health, got %0.0f", heroic, expectedHealth, ai.health)
+		}
+		if target.Stats[stats.Health] != config.Stats[stats.Health] {
+			t.Fatalf("the request's target config should not be modified")
+		}
+	}
+
+	// Health which was changed from the preset's default is kept.
+	target := goproto.Clone(config).(*proto.Target)
+	target.Stats[stats.Health] = 1_500_000
+	runTarget(target, heroicInputs(false))
+	if ai.health != 1_500_000 {
+		t.Fatalf("expected the edited health of 1500000 to be kept, got %0.0f", ai.health)
+	}
+
+	target = goproto.Clone(config).(*proto.Target)
+	target.Id = legacyID
+	ai = nil
+	runTarget(target, nil)
+	if ai == nil || !ai.heroic || ai.health != 2_000_000 {
+		t.Fatalf("expected the legacy preset ID to be simmed as the heroic preset")
+	}
+}
+
+// Moves the target once, then records where the raid ended up.
+type repositioningAI struct {
+	Target       *core.Target
+	distances    [][]float64
+	positions    [][]core.Vector2
+	petDistances []float64
+}
+
+func (ai *repositioningAI) Initialize(target *core.Target, _ *proto.Target) {
+	ai.Target = target
+}
+func (ai *repositioningAI) Reset(sim *core.Simulation) {
+	core.StartDelayedAction(sim, core.DelayedActionOptions{
+		DoAt: time.Second * 10,
+		OnAction: func(sim *core.Simulation) {
+			ai.Target.Reposition(sim, core.Vector2{Y: 20})
+		},
+	})
+	core.StartDelayedAction(sim, core.DelayedActionOptions{
+		DoAt: time.Second * 20,
+		OnAction: func(sim *core.Simulation) {
+			var distances []float64
+			var positions []core.Vector2
+			for _, unit := range sim.Raid.AllPlayerUnits {
+				unit.UpdatePosition(sim)
+				distances = append(distances, unit.DistanceFromTarget())
+				positions = append(positions, unit.Position)
+			}
+			ai.distances = append(ai.distances, distances)
+			ai.positions = append(ai.positions, positions)
+
+			for _, unit := range sim.Raid.AllUnits {
+				if unit.Type == core.PetUnit && unit.IsEnabled() {
+					unit.UpdatePosition(sim)
+					ai.petDistances = append(ai.petDistances, unit.DistanceFromTarget())
+				}
+			}
+		},
+	})
+}
+func (ai *repositioningAI) ExecuteCustomRotation(_ *core.Simulation) {}
+
+func TestTargetReposition(t *testing.T) {
+	config := newTestTarget()
+	config.Id = 990500
+	config.Name = "Repositioning Target"
+	var ai *repositioningAI
+	setTestPreset(&core.PresetTarget{
+		Config: config,
+		AI: func() core.TargetAI {
+			ai = &repositioningAI{}
+			return ai
+		},
+	})
+
+	hunter := getTestPlayerMM()
+	hunter.DistanceFromTarget = 25
+	cat := getTestPlayerFeralCat()
+	cat.DistanceFromTarget = 5
+
+	rsr := makeTestCase(hunter)
+	rsr.Raid.Parties[0].Players = append(rsr.Raid.Parties[0].Players, cat)
+	rsr.Encounter.Targets = []*proto.Target{config}
+	rsr.SimOptions.Iterations = 5
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+
+	if len(ai.distances) != int(rsr.SimOptions.Iterations) {
+		t.Fatalf("expected positions from every iteration, got %d", len(ai.distances))
+	}
+	for i := range ai.distances {
+		// The hunter is still within casting range, so it stays where it was.
+		if ai.positions[i][0] != (core.Vector2{X: 25}) {
+			t.Fatalf("expected the hunter to stay at (25, 0), got %v", ai.positions[i][0])
+		}
+		if math.Abs(ai.distances[i][0]-math.Hypot(25, 20)) > 1e-9 {
+			t.Fatalf("expected the hunter 32 yards from the target, got %0.2f", ai.distances[i][0])
+		}
+		// The cat follows the target back into melee range.
+		if ai.distances[i][1] > core.MaxMeleeRange {
+			t.Fatalf("expected the cat in melee range, got %0.2f yards", ai.distances[i][1])
+		}
+	}
+
+	// Pets don't move on their own, so they rely on following the target.
+	if len(ai.petDistances) < len(ai.distances) {
+		t.Fatalf("expected the hunter's pet in every iteration, got %d", len(ai.petDistances))
+	}
+	for _, distance := range ai.petDistances {
+		if distance > core.MaxMeleeRange+1e-6 {
+			t.Fatalf("expected the pet in melee range, got %0.2f yards", distance)
+		}
+	}
+}
+
+func TestTargetUnitsInRange(t *testing.T) {
+	near := &core.Unit{Position: core.Vector2{X: 3, Y: 4}}
+	far := &core.Unit{Position: core.Vector2{X: 10, Y: 10}}
+	encounter := core.Encounter{
+		ActiveTargetUnits: []*core.Unit{near, far},
+	}
+
+	if inRange := encounter.TargetUnitsInRange(core.Vector2{}, 5); len(inRange) != 1 || inRange[0] != near {
+		t.Fatalf("expected only the near target within 5 yards, got %d targets", len(inRange))
+	}
+	if inRange := encounter.TargetUnitsInRange(core.Vector2{}, 15); len(inRange) != 2 {
+		t.Fatalf("expected both targets within 15 yards, got %d targets", len(inRange))
+	}
+}
+
+func TestCleaveTargetUnits(t *testing.T) {
+	first := &core.Unit{Index: 0, Position: core.Vector2{X: 3}}
+	far := &core.Unit{Index: 1, Position: core.Vector2{X: 20}}
+	target := &core.Unit{Index: 2}
+	last := &core.Unit{Index: 3, Position: core.Vector2{Y: 4}}
+	env := core.Environment{
+		Encounter: core.Encounter{
+			ActiveTargetUnits: []*core.Unit{first, far, target, last},
+		},
+	}
+
+	// Targets after the main one come first, and out of range targets are skipped.
+	if targets := env.CleaveTargetUnits(target, core.Vector2{}, 5, 3); len(targets) != 3 || targets[0] != target || targets[1] != last || targets[2] != first {
+		t.Fatalf("expected the target, then the last and first targets, got %d targets", len(targets))
+	}
+	if targets := env.CleaveTargetUnits(target, core.Vector2{}, 5, 2); len(targets) != 2 || targets[1] != last {
+		t.Fatalf("expected the target and the last target, got %d targets", len(targets))
+	}
+}
@@ -61,15 +61,18 @@ type Unit struct {
 	// Amount of time following a post-GCD channel tick, to when the next action can be performed.
 	ChannelClipDelay time.Duration
 
-	// How far this unit is from its target(s). Measured in yards, this is used
-	// for calculating spell travel time for certain spells.
+	// How far this unit starts from its target, in yards. Units without an
+	// explicit starting position are placed this far from their target.
 	StartDistanceFromTarget float64
-	DistanceFromTarget      float64
-	Moving                  bool
-	movementCallbacks       []MovementCallback
-	moveAura                *Aura
-	moveSpell               *Spell
-	movementAction          *MovementAction
+	startPosition           *Vector2
+	// Where this unit currently stands, in yards. Used for range checks and
+	// spell travel times.
+	Position          Vector2
+	Moving            bool
+	movementCallbacks []MovementCallback
+	moveAura          *Aura
+	moveSpell         *Spell
+	movementAction    *MovementAction
 
 	// How much uptime of Dark Intent the unit will have
 	DarkIntentUptimePercent float64
@@ -112,6 +115,9 @@ type Unit struct {
 
 	PseudoStats stats.PseudoStats
 
+	// Effects this unit can't be affected by, e.g. bleeds on some bosses.
+	Immunities Immunities
+
 	currentPowerBar PowerBarType
 	healthBar
 	manaBar
@@ -198,6 +204,11 @@ func (unit *Unit) IsActive() bool {
 	return unit.IsEnabled() && unit.CurrentHealthPercent() > 0
 }
 
+// Whether this is an enemy unit which isn't spawned at the moment.
+func (unit *Unit) isDespawned() bool {
+	return unit.Type == EnemyUnit && !unit.enabled
+}
+
 func (unit *Unit) IsOpponent(other *Unit) bool {
 	return (unit.Type == EnemyUnit) != (other.Type == EnemyUnit)
 }
@@ -550,7 +561,6 @@ func (unit *Unit) reset(sim *Simulation, _ Agent) {
 	unit.Hardcast.Expires = startingCDTime
 	unit.ChanneledDot = nil
 	unit.QueuedSpell = nil
-	unit.DistanceFromTarget = unit.StartDistanceFromTarget
 	unit.Metrics.reset()
 	unit.ResetStatDeps()
 	unit.statsWithoutDeps = unit.initialStatsWithoutDeps
@@ -230,3 +230,10 @@ func (x *aggregator) meanAndStdDev() (float64, float64) {
 	stdDev := math.Sqrt(x.sumSq/float64(x.n) - mean*mean)
 	return mean, stdDev
 }
+
+// meanAndStdErr returns the mean and its standard error.
+func (x *aggregator) meanAndStdErr() (float64, float64) {
+	mean := x.sum / float64(x.n)
+	variance := max(0, x.sumSq/float64(x.n)-mean*mean)
+	return mean, math.Sqrt(variance / float64(x.n))
+}
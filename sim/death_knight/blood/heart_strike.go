@@ -8,8 +8,7 @@ import (
 var HeartStrikeActionID = core.ActionID{SpellID: 55050}
 
 func (dk *BloodDeathKnight) registerHeartStrikeSpell() {
-	numHits := min(3, dk.Env.GetNumTargets())
-	results := make([]*core.SpellResult, numHits)
+	results := make([]*core.SpellResult, min(3, dk.Env.GetNumTargets()))
 
 	dk.GetOrRegisterSpell(core.SpellConfig{
 		ActionID:       HeartStrikeActionID,
@@ -38,8 +37,8 @@ func (dk *BloodDeathKnight) registerHeartStrikeSpell() {
 			baseDamage := dk.ClassSpellScaling*0.72799998522 +
 				spell.Unit.MHNormalizedWeaponDamage(sim, spell.MeleeAttackPower())
 
-			currentTarget := target
-			for idx := int32(0); idx < numHits; idx++ {
+			targets := sim.Environment.CleaveTargetUnits(target, dk.Position, core.MaxMeleeRange, 3)
+			for idx, currentTarget := range targets {
 				targetDamage := baseDamage * dk.GetDiseaseMulti(currentTarget, 1.0, 0.15)
 
 				results[idx] = spell.CalcDamage(sim, currentTarget, targetDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
@@ -48,10 +47,9 @@ func (dk *BloodDeathKnight) registerHeartStrikeSpell() {
 				}
 
 				spell.DamageMultiplier *= 0.75
-				currentTarget = dk.Env.NextTargetUnit(currentTarget)
 			}
 
-			for _, result := range results {
+			for _, result := range results[:len(targets)] {
 				spell.DealDamage(sim, result)
 				spell.DamageMultiplier /= 0.75
 			}
@@ -60,8 +58,7 @@ func (dk *BloodDeathKnight) registerHeartStrikeSpell() {
 }
 
 func (dk *BloodDeathKnight) registerDrwHeartStrikeSpell() *core.Spell {
-	numHits := min(3, dk.Env.GetNumTargets())
-	results := make([]*core.SpellResult, numHits)
+	results := make([]*core.SpellResult, min(3, dk.Env.GetNumTargets()))
 	return dk.RuneWeapon.RegisterSpell(core.SpellConfig{
 		ActionID:    HeartStrikeActionID,
 		SpellSchool: core.SpellSchoolPhysical,
@@ -72,17 +69,16 @@ func (dk *BloodDeathKnight) registerDrwHeartStrikeSpell() *core.Spell {
 			baseDamage := dk.ClassSpellScaling*0.72799998522 +
 				spell.Unit.MHNormalizedWeaponDamage(sim, spell.MeleeAttackPower())
 
-			currentTarget := target
-			for idx := int32(0); idx < numHits; idx++ {
+			targets := sim.Environment.CleaveTargetUnits(target, dk.Position, core.MaxMeleeRange, 3)
+			for idx, currentTarget := range targets {
 				targetDamage := baseDamage * dk.RuneWeapon.GetDiseaseMulti(currentTarget, 1.0, 0.15)
 
 				results[idx] = spell.CalcDamage(sim, currentTarget, targetDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
 
 				spell.DamageMultiplier *= 0.75
-				currentTarget = dk.Env.NextTargetUnit(currentTarget)
 			}
 
-			for _, result := range results {
+			for _, result := range results[:len(targets)] {
 				spell.DealDamage(sim, result)
 				spell.DamageMultiplier /= 0.75
 			}
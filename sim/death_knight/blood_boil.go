@@ -31,10 +31,11 @@ func (dk *DeathKnight) registerBloodBoilSpell() {
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			anyHit := false
-			for idx, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := dk.TargetUnitsAround(10)
+			for idx, aoeTarget := range targets {
 				baseDamage := dk.ClassSpellScaling*0.31700000167 + 0.08*spell.MeleeAttackPower()
 				baseDamage *= core.TernaryFloat64(dk.DiseasesAreActive(aoeTarget), 1.5, 1.0)
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 
 				results[idx] = spell.CalcDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 				anyHit = anyHit || results[idx].Landed()
@@ -44,7 +45,7 @@ func (dk *DeathKnight) registerBloodBoilSpell() {
 				dk.AddRunicPower(sim, 10, rpMetric)
 			}
 
-			for _, result := range results {
+			for _, result := range results[:len(targets)] {
 				spell.DealDamage(sim, result)
 			}
 		},
@@ -59,15 +60,16 @@ func (dk *DeathKnight) registerDrwBloodBoilSpell() *core.Spell {
 		ProcMask:    core.ProcMaskSpellDamage,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for idx, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := dk.TargetUnitsAround(10)
+			for idx, aoeTarget := range targets {
 				baseDamage := dk.ClassSpellScaling*0.31700000167 + 0.08*spell.MeleeAttackPower()
 				baseDamage *= core.TernaryFloat64(dk.RuneWeapon.DiseasesAreActive(aoeTarget), 1.5, 1.0)
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 
 				results[idx] = spell.CalcDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 
-			for _, result := range results {
+			for _, result := range results[:len(targets)] {
 				spell.DealDamage(sim, result)
 			}
 		},
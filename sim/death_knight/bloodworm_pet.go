@@ -78,7 +78,7 @@ func (bloodworm *BloodwormPet) Initialize() {
 					continue
 				}
 
-				if target.DistanceFromTarget > core.MaxMeleeRange {
+				if bloodworm.DistanceTo(target) > core.MaxMeleeRange {
 					continue
 				}
 
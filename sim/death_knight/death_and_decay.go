@@ -7,6 +7,9 @@ import (
 )
 
 func (dk *DeathKnight) registerDeathAndDecaySpell() {
+	// D&D stays on the ground where it was cast.
+	var center core.Vector2
+
 	dk.RegisterSpell(core.SpellConfig{
 		ActionID:       core.ActionID{SpellID: 43265},
 		Flags:          core.SpellFlagAPL,
@@ -43,7 +46,7 @@ func (dk *DeathKnight) registerDeathAndDecaySpell() {
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
 				// DnD recalculates everything on each tick
 				baseDamage := 26 + dot.Spell.MeleeAttackPower()*0.06400000304
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range sim.Encounter.TargetUnitsInRange(center, 10) {
 					dot.Spell.SpellMetrics[aoeTarget.UnitIndex].Casts++
 					dot.Spell.CalcAndDealPeriodicDamage(sim, aoeTarget, baseDamage, dot.Spell.OutcomeMagicHitAndCrit)
 				}
@@ -51,6 +54,7 @@ func (dk *DeathKnight) registerDeathAndDecaySpell() {
 		},
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			center = target.Position
 			dot := spell.AOEDot()
 			dot.Apply(sim)
 			dot.TickOnce(sim)
@@ -177,12 +177,12 @@ func (ghoulPet *GhoulPet) registerClaw() *core.Spell {
 		BonusCoefficient: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			results := make([]*core.SpellResult, min(int32(2), min(ghoulPet.Env.GetNumTargets(), core.TernaryInt32(ghoulPet.DarkTransformationAura.IsActive(), 2, 1))))
+			targets := sim.Environment.CleaveTargetUnits(target, ghoulPet.Position, core.MaxMeleeRange, core.TernaryInt32(ghoulPet.DarkTransformationAura.IsActive(), 2, 1))
+			results := make([]*core.SpellResult, len(targets))
 
-			for idx := range results {
+			for idx, aoeTarget := range targets {
 				baseDamage := spell.Unit.MHWeaponDamage(sim, spell.MeleeAttackPower())
-				results[idx] = spell.CalcDamage(sim, target, baseDamage, spell.OutcomeMeleeSpecialHitAndCrit)
-				target = sim.Environment.NextTargetUnit(target)
+				results[idx] = spell.CalcDamage(sim, aoeTarget, baseDamage, spell.OutcomeMeleeSpecialHitAndCrit)
 			}
 
 			for idx, result := range results {
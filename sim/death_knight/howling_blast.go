@@ -37,7 +37,8 @@ func (dk *DeathKnight) registerHowlingBlastSpell() {
 		CritMultiplier: dk.DefaultMeleeCritMultiplier(),
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for idx, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 10)
+			for idx, aoeTarget := range targets {
 				baseDamage := dk.ClassSpellScaling*1.17499995232 + 0.44*spell.MeleeAttackPower()
 
 				if aoeTarget != target {
@@ -53,7 +54,7 @@ func (dk *DeathKnight) registerHowlingBlastSpell() {
 				}
 			}
 
-			for _, result := range results {
+			for _, result := range results[:len(targets)] {
 				spell.DealDamage(sim, result)
 			}
 		},
@@ -234,8 +234,9 @@ func init() {
 				} else {
 					ohRazoriceSpell.Cast(sim, result.Target)
 				}
-				vulnAura.Activate(sim)
-				vulnAura.AddStack(sim)
+				if vulnAura.Activate(sim) {
+					vulnAura.AddStack(sim)
+				}
 			},
 		})
 
@@ -29,7 +29,7 @@ func (druid *Druid) registerDemoralizingRoarSpell() {
 		FlatThreatBonus:  62 * 2, // TODO: Measure for Cata
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			for _, aoeTarget := range druid.TargetUnitsAround(10) {
 				result := spell.CalcAndDealOutcome(sim, aoeTarget, spell.OutcomeMagicHit)
 				if result.Landed() {
 					druid.DemoralizingRoarAuras.Get(aoeTarget).Activate(sim)
@@ -81,9 +81,7 @@ func (druid *Druid) CanApplyFaerieFireDebuff(target *core.Unit) bool {
 func (druid *Druid) TryApplyFaerieFireEffect(sim *core.Simulation, target *core.Unit) {
 	if druid.CanApplyFaerieFireDebuff(target) {
 		aura := druid.FaerieFireAuras.Get(target)
-		aura.Activate(sim)
-
-		if aura.IsActive() {
+		if aura.Activate(sim) {
 			aura.SetStacks(sim, aura.GetStacks()+1+druid.Talents.FeralAggression)
 		}
 	}
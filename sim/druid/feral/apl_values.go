@@ -153,7 +153,7 @@ func (action *APLActionCatOptimalRotationAction) Execute(sim *core.Simulation) {
 	// Keep up Sunder debuff if not provided externally. Do this here since FF can be
 	// cast while moving.
 	if cat.Rotation.MaintainFaerieFire {
-		for _, aoeTarget := range sim.Encounter.TargetUnits {
+		for _, aoeTarget := range sim.Encounter.ActiveTargetUnits {
 			if cat.ShouldFaerieFire(sim, aoeTarget) {
 				cat.FaerieFire.CastOrQueue(sim, aoeTarget)
 			}
@@ -164,7 +164,7 @@ func (action *APLActionCatOptimalRotationAction) Execute(sim *core.Simulation) {
 	if cat.Moving {
 		return
 	}
-	if cat.DistanceFromTarget > core.MaxMeleeRange {
+	if cat.DistanceFromTarget() > core.MaxMeleeRange {
 		// Try leaping first before defaulting to manual movement
 		if cat.CatCharge.CanCast(sim, cat.CurrentTarget) {
 			cat.CatCharge.Cast(sim, cat.CurrentTarget)
@@ -264,7 +264,7 @@ func (cat *FeralDruid) preRotationCleanup(sim *core.Simulation) bool {
 
 		// Bundle a leave-weave with the Cat Form GCD if possible
 		if cat.InForm(druid.Cat) && cat.Rotation.MeleeWeave {
-			timeToMove := core.DurationFromSeconds((cat.CatCharge.MinRange+1-cat.DistanceFromTarget)/cat.GetMovementSpeed()) + cat.ReactionTime
+			timeToMove := core.DurationFromSeconds((cat.CatCharge.MinRange+1-cat.DistanceFromTarget())/cat.GetMovementSpeed()) + cat.ReactionTime
 
 			if cat.CatCharge.TimeToReady(sim) < timeToMove {
 				cat.MoveTo(cat.CatCharge.MinRange+1, sim)
@@ -358,7 +358,7 @@ func (cat *FeralDruid) canMeleeWeave(sim *core.Simulation, regenRate float64, cu
 	}
 
 	// Estimate time to run out and charge back in
-	runOutTime := core.DurationFromSeconds((cat.CatCharge.MinRange+1-cat.DistanceFromTarget)/cat.GetMovementSpeed()) + cat.ReactionTime
+	runOutTime := core.DurationFromSeconds((cat.CatCharge.MinRange+1-cat.DistanceFromTarget())/cat.GetMovementSpeed()) + cat.ReactionTime
 	chargeInTime := core.DurationFromSeconds((cat.CatCharge.MinRange+1)/80) + cat.ReactionTime
 	weaveDuration := runOutTime + chargeInTime
 	weaveEnergy := 100.0 - weaveDuration.Seconds()*regenRate
@@ -468,6 +468,11 @@ func (cat *FeralDruid) doRotation(sim *core.Simulation) (bool, time.Duration) {
 	ripDot := cat.Rip.CurDot()
 	lacerateDot := cat.Lacerate.CurDot()
 	isBleedActive := cat.AssumeBleedActive || ripDot.IsActive() || rakeDot.IsActive() || lacerateDot.IsActive()
+
+	// Rake and Rip are wasted on bleed immune targets, so Bite is the only finisher
+	// besides Roar, and Mangle is only used to build combo points.
+	bleedImmune := cat.CurrentTarget.IsImmuneToMechanic(core.MechanicBleed)
+	mangleDebuffImmune := cat.CurrentTarget.IsImmuneToCategory(core.BleedEffectCategory)
 	regenRate := cat.EnergyRegenPerSecond()
 	isExecutePhase := rotation.BiteDuringExecute && sim.IsExecutePhase25()
 	tfActive := cat.TigersFuryAura.IsActive()
@@ -484,7 +489,7 @@ func (cat *FeralDruid) doRotation(sim *core.Simulation) (bool, time.Duration) {
 	finalTickLeeway := core.TernaryDuration(ripDot.IsActive(), ripDot.TimeUntilNextTick(sim), 0)
 	endThreshForClip := baseEndThresh + finalTickLeeway
 	ripRefreshTime := cat.calcRipRefreshTime(sim, ripDot, isExecutePhase)
-	ripNow := (curCp >= rotation.MinCombosForRip) && (!ripDot.IsActive() || ((sim.CurrentTime > ripRefreshTime) && !isExecutePhase)) && (simTimeRemain >= endThreshForClip) && ripCcCheck
+	ripNow := !bleedImmune && (curCp >= rotation.MinCombosForRip) && (!ripDot.IsActive() || ((sim.CurrentTime > ripRefreshTime) && !isExecutePhase)) && (simTimeRemain >= endThreshForClip) && ripCcCheck
 	biteAtEnd := (curCp >= rotation.MinCombosForBite) && ((simTimeRemain < endThreshForClip) || (ripDot.IsActive() && (simTimeRemain-ripDot.RemainingDuration(sim) < baseEndThresh)))
 
 	// Delay Rip refreshes if Tiger's Fury will be usable soon enough for the snapshot to outweigh the lost Rip ticks from waiting
@@ -508,7 +513,7 @@ func (cat *FeralDruid) doRotation(sim *core.Simulation) (bool, time.Duration) {
 	t11BuildNow := (cat.StrengthOfThePantherAura != nil) && (cat.StrengthOfThePantherAura.GetStacks() < 3) && !rotation.BearWeave
 	t11RefreshNow := t11Active && (cat.StrengthOfThePantherAura.RemainingDuration(sim) < time.Second+cat.ReactionTime) && (simTimeRemain > time.Second)
 	t11RefreshNext := t11Active && (cat.StrengthOfThePantherAura.RemainingDuration(sim) < time.Second*2+cat.ReactionTime) && (simTimeRemain > time.Second*2)
-	mangleRefreshNow := !cat.bleedAura.IsActive() && (simTimeRemain > time.Second)
+	mangleRefreshNow := !mangleDebuffImmune && !cat.bleedAura.IsActive() && (simTimeRemain > time.Second)
 	mangleRefreshPending := (!t11RefreshNow && !mangleRefreshNow) && ((cat.bleedAura.IsActive() && cat.bleedAura.RemainingDuration(sim) < (simTimeRemain-time.Second)) || (t11Active && (cat.StrengthOfThePantherAura.GetStacks() == 3) && (cat.StrengthOfThePantherAura.RemainingDuration(sim) < simTimeRemain-time.Second)))
 	clipMangle := false
 
@@ -520,7 +525,7 @@ func (cat *FeralDruid) doRotation(sim *core.Simulation) (bool, time.Duration) {
 
 	mangleNow := cat.MangleCat != nil && (mangleRefreshNow || clipMangle)
 
-	biteBeforeRip := (curCp >= rotation.MinCombosForBite) && ripDot.IsActive() && cat.SavageRoarAura.IsActive() && (rotation.UseBite || isExecutePhase) && cat.canBite(sim, isExecutePhase)
+	biteBeforeRip := (curCp >= rotation.MinCombosForBite) && (ripDot.IsActive() || bleedImmune) && cat.SavageRoarAura.IsActive() && (rotation.UseBite || isExecutePhase) && cat.canBite(sim, isExecutePhase)
 	biteNow := (biteBeforeRip || biteAtEnd) && !isClearcast
 
 	// Ignore minimum CP enforcement during Execute phase if Rip is about to fall off
@@ -528,7 +533,7 @@ func (cat *FeralDruid) doRotation(sim *core.Simulation) (bool, time.Duration) {
 	biteNow = (biteNow || emergencyBiteNow) && !t11RefreshNext
 
 	// Rake calcs
-	rakeNow := rotation.UseRake && (!rakeDot.IsActive() || (rakeDot.RemainingDuration(sim) < rakeDot.TickLength)) && (simTimeRemain > rakeDot.TickLength) && rakeCcCheck
+	rakeNow := !bleedImmune && rotation.UseRake && (!rakeDot.IsActive() || (rakeDot.RemainingDuration(sim) < rakeDot.TickLength)) && (simTimeRemain > rakeDot.TickLength) && rakeCcCheck
 
 	// Additionally, don't Rake if the current Shred DPE is higher due to
 	// trinket procs etc.
@@ -565,7 +570,7 @@ func (cat *FeralDruid) doRotation(sim *core.Simulation) (bool, time.Duration) {
 	}
 
 	// Roar calcs
-	roarNow := (curCp >= 1) && (!cat.SavageRoarAura.IsActive() || cat.clipRoar(sim, isExecutePhase)) && (ripDot.IsActive() || (curCp < 3) || (simTimeRemain < baseEndThresh))
+	roarNow := (curCp >= 1) && (!cat.SavageRoarAura.IsActive() || cat.clipRoar(sim, isExecutePhase)) && (ripDot.IsActive() || bleedImmune || (curCp < 3) || (simTimeRemain < baseEndThresh))
 
 	// Ravage calc
 	ravageNow := cat.Ravage.CanCast(sim, cat.CurrentTarget) && !isClearcast && (curEnergy+2*regenRate < cat.MaximumEnergy())
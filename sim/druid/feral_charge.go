@@ -32,9 +32,9 @@ func (druid *Druid) registerCatCharge() {
 			// movement aura stacks, so do it directly here by setting the
 			// position to 0 instantaneously but introducing a GCD delay based
 			// on the distance traveled.
-			travelTime := core.DurationFromSeconds(druid.DistanceFromTarget / 80)
+			travelTime := core.DurationFromSeconds(druid.DistanceFromTarget() / 80)
 			druid.ExtendGCDUntil(sim, max(druid.NextGCDAt(), sim.CurrentTime+travelTime))
-			druid.DistanceFromTarget = 0
+			druid.Position = druid.CurrentTarget.Position
 
 			// Measurements from boЯsch indicate that while travel speed (and
 			// therefore special ability delays) is fairly consistent, there
@@ -19,10 +19,11 @@ func (druid *Druid) registerHurricaneSpell() {
 		BonusCoefficient: 0.095,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 8)
 			damage := 0.327 * druid.ClassSpellScaling
-			damage *= sim.Encounter.AOECapMultiplier()
+			damage *= core.AOECapMultiplierForTargets(len(targets))
 
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			for _, aoeTarget := range targets {
 				spell.CalcAndDealDamage(sim, aoeTarget, damage, spell.OutcomeMagicHitAndCrit)
 			}
 		},
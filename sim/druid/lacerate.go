@@ -19,6 +19,7 @@ func (druid *Druid) registerLacerateSpell() {
 		SpellSchool: core.SpellSchoolPhysical,
 		ProcMask:    core.ProcMaskMeleeMHSpecial,
 		Flags:       core.SpellFlagMeleeMetrics | core.SpellFlagAPL | core.SpellFlagIgnoreResists,
+		Mechanic:    core.MechanicBleed,
 
 		RageCost: core.RageCostOptions{
 			Cost:   15,
@@ -9,7 +9,7 @@ import (
 
 func (druid *Druid) registerMaulSpell() {
 	flatBaseDamage := 34.0
-	numHits := core.TernaryInt32(druid.HasMajorGlyph(proto.DruidMajorGlyph_GlyphOfMaul) && druid.Env.GetNumTargets() > 1, 2, 1)
+	hasGlyph := druid.HasMajorGlyph(proto.DruidMajorGlyph_GlyphOfMaul)
 	rendAndTearMod := []float64{1.0, 1.07, 1.13, 1.2}[druid.Talents.RendAndTear]
 
 	druid.Maul = druid.RegisterSpell(Bear, core.SpellConfig{
@@ -38,9 +38,9 @@ func (druid *Druid) registerMaulSpell() {
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			baseDamage := flatBaseDamage + 0.19*spell.MeleeAttackPower()
+			numHits := core.TernaryInt32(hasGlyph, 2, 1)
 
-			curTarget := target
-			for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
+			for hitIndex, curTarget := range sim.Environment.CleaveTargetUnits(target, druid.Position, core.MaxMeleeRange, numHits) {
 				modifier := 1.0
 				if druid.BleedCategories.Get(curTarget).AnyActive() {
 					modifier += .3
@@ -57,8 +57,6 @@ func (druid *Druid) registerMaulSpell() {
 				if !result.Landed() {
 					spell.IssueRefund(sim)
 				}
-
-				curTarget = sim.Environment.NextTargetUnit(curTarget)
 			}
 		},
 	})
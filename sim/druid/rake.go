@@ -22,6 +22,7 @@ func (druid *Druid) registerRakeSpell() {
 		SpellSchool: core.SpellSchoolPhysical,
 		ProcMask:    core.ProcMaskMeleeMHSpecial,
 		Flags:       core.SpellFlagMeleeMetrics | core.SpellFlagIgnoreResists | core.SpellFlagAPL,
+		Mechanic:    core.MechanicBleed,
 
 		EnergyCost: core.EnergyCostOptions{
 			Cost:   35,
@@ -24,6 +24,7 @@ func (druid *Druid) registerRipSpell() {
 		SpellSchool: core.SpellSchoolPhysical,
 		ProcMask:    core.ProcMaskMeleeMHSpecial,
 		Flags:       core.SpellFlagMeleeMetrics | core.SpellFlagAPL,
+		Mechanic:    core.MechanicBleed,
 
 		EnergyCost: core.EnergyCostOptions{
 			Cost:   30,
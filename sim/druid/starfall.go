@@ -66,7 +66,9 @@ func (druid *Druid) registerStarfallSpell() {
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			result := spell.CalcAndDealOutcome(sim, target, spell.OutcomeMagicHit)
 			if result.Landed() {
-				spell.Dot(target).Apply(sim)
+				dot := spell.Dot(target)
+				dot.NumberOfTicks = core.TernaryInt32(sim.GetNumTargets() > 1, 20, 10)
+				dot.Apply(sim)
 			}
 		},
 	})
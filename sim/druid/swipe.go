@@ -35,9 +35,10 @@ func (druid *Druid) registerSwipeBearSpell() {
 		MaxRange:         8,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			targets := druid.TargetUnitsAround(8)
 			baseDamage := flatBaseDamage + 0.123*spell.MeleeAttackPower()
-			baseDamage *= sim.Encounter.AOECapMultiplier()
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			baseDamage *= core.AOECapMultiplierForTargets(len(targets))
+			for _, aoeTarget := range targets {
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMeleeSpecialHitAndCrit)
 			}
 		},
@@ -69,16 +70,17 @@ func (druid *Druid) registerSwipeCatSpell() {
 		BonusCoefficient: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			targets := druid.TargetUnitsAround(8)
 			baseDamage := spell.Unit.MHWeaponDamage(sim, spell.MeleeAttackPower())
-			baseDamage *= sim.Encounter.AOECapMultiplier()
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			baseDamage *= core.AOECapMultiplierForTargets(len(targets))
+			for _, aoeTarget := range targets {
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
 			}
 		},
 
 		ExpectedInitialDamage: func(sim *core.Simulation, target *core.Unit, spell *core.Spell, _ bool) *core.SpellResult {
 			baseDamage := spell.Unit.AutoAttacks.MH().CalculateAverageWeaponDamage(spell.MeleeAttackPower())
-			baseDamage *= sim.Encounter.AOECapMultiplier()
+			baseDamage *= core.AOECapMultiplierForTargets(len(druid.TargetUnitsAround(8)))
 			return spell.CalcDamage(sim, target, baseDamage, spell.OutcomeExpectedMeleeWeaponSpecialHitAndCrit)
 		},
 	})
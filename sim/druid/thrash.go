@@ -46,6 +46,8 @@ func (druid *Druid) registerThrashBearSpell() {
 			Aura: druid.applyRendAndTear(core.Aura{
 				Label:    "Thrash",
 				Duration: time.Second * 6,
+				// Only the bleed, not the initial hit, is affected by immunities.
+				Mechanic: core.MechanicBleed,
 			}),
 			NumberOfTicks: 3,
 			TickLength:    time.Second * 2,
@@ -61,9 +63,10 @@ func (druid *Druid) registerThrashBearSpell() {
 		},
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			targets := druid.TargetUnitsAround(8)
 			baseDamage := flatBaseDamage + 0.0982*spell.MeleeAttackPower()
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
-				perTargetDamage := (baseDamage + (sim.RandomFloat("Thrash") * damageSpread)) * sim.Encounter.AOECapMultiplier()
+			for _, aoeTarget := range targets {
+				perTargetDamage := (baseDamage + (sim.RandomFloat("Thrash") * damageSpread)) * core.AOECapMultiplierForTargets(len(targets))
 				if druid.BleedCategories.Get(aoeTarget).AnyActive() {
 					perTargetDamage *= 1.3
 				}
@@ -43,9 +43,10 @@ func (druid *Druid) registerTyphoonSpell() {
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			spell.WaitTravelTime(sim, func(sim *core.Simulation) {
+				targets := druid.TargetUnitsAround(30)
 				baseDamage := core.CalcScalingSpellAverageEffect(proto.Class_ClassDruid, 1.316)
-				baseDamage *= sim.Encounter.AOECapMultiplier()
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
+				for _, aoeTarget := range targets {
 					spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 				}
 			})
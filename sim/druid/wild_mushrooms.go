@@ -55,13 +55,14 @@ func (druid *Druid) registerWildMushrooms() {
 		BonusCoefficient: 0.6032,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-
+			// The mushrooms are assumed to be planted at the target.
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 6)
 			for i := wildMushroomsStackAura.GetStacks(); i > 0; i-- {
 				min, max := core.CalcScalingSpellEffectVarianceMinMax(proto.Class_ClassDruid, 0.9464, 0.19)
 				baseDamage := sim.Roll(min, max)
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range targets {
 					spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 				}
 
@@ -1,7 +1,6 @@
 package bwd
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/wowsims/cata/sim/core"
@@ -10,58 +9,63 @@ import (
 	"github.com/wowsims/cata/sim/encounters/default_ai"
 )
 
-func createMagmawPreset(bossPrefix string, raidSize int, isHeroic bool,
-	npcId int32, health float64, minBaseDamage float64,
-	addNpcId int32, addHealth float64, addMinBaseDamage float64) {
+const (
+	magmawID           = 41570
+	blazingConstructID = 49416
+)
+
+// Returns the raid size, difficulty and scaling index for the encounter inputs:
+// 0 - 10N, 1 - 25N, 2 - 10H, 3 - 25H
+func magmawDifficulty(inputs []*proto.TargetInput) (raidSize int, isHeroic bool, scalingIndex int) {
+	raidSize = core.TernaryInt(core.FindTargetInput(inputs, "Raid Size").GetEnumValue() == 0, 10, 25)
+	isHeroic = core.FindTargetInput(inputs, "Heroic").GetBoolValue()
+	scalingIndex = core.TernaryInt(raidSize == 10, core.TernaryInt(isHeroic, 2, 0), core.TernaryInt(isHeroic, 3, 1))
+	return
+}
 
-	targetName := fmt.Sprintf("Magmaw %d", raidSize)
-	targetNameAdd := fmt.Sprintf("Blazing Construct %d", raidSize)
-	if isHeroic {
-		targetName = targetName + " H"
-		targetNameAdd = targetNameAdd + " H"
+func magmawDifficultyInputs(raidSize int, isHeroic bool) []*proto.TargetInput {
+	return []*proto.TargetInput{
+		{
+			Label:       "Raid Size",
+			Tooltip:     "The size of the Raid",
+			InputType:   proto.InputType_Enum,
+			EnumValue:   core.TernaryInt32(raidSize == 10, 0, 1),
+			EnumOptions: []string{"10", "25"},
+		},
+		{
+			Label:     "Heroic",
+			Tooltip:   "Is the encounter in Heroic Mode",
+			InputType: proto.InputType_Bool,
+			BoolValue: isHeroic,
+		},
 	}
+}
+
+func addMagmaw(bossPrefix string) {
 	core.AddPresetTarget(&core.PresetTarget{
 		PathPrefix: bossPrefix,
 		Config: &proto.Target{
-			Id:        npcId,
-			Name:      targetName,
+			Id:        magmawID,
+			Name:      "Magmaw",
 			Level:     88,
 			MobType:   proto.MobType_MobTypeBeast,
 			TankIndex: 0,
 
 			Stats: stats.Stats{
-				stats.Health:      health,
+				stats.Health:      120_016_403,
 				stats.Armor:       11977,
 				stats.AttackPower: 650,
 			}.ToFloatArray(),
 
 			SpellSchool:      proto.SpellSchool_SpellSchoolPhysical,
 			SwingSpeed:       2.5,
-			MinBaseDamage:    minBaseDamage,
+			MinBaseDamage:    210000,
 			DamageSpread:     0.4,
 			SuppressDodge:    false,
 			ParryHaste:       false,
 			DualWield:        false,
 			DualWieldPenalty: false,
 			TargetInputs: []*proto.TargetInput{
-				// TODO: Figure out how to make Size and Heroic
-				// pickable for a preset. Right now we have to
-				// make up to 4 presets per boss which sucks...
-				// {
-				// 	Label:     "Raid Size",
-				// 	Tooltip:   "The size of the Raid",
-				// 	InputType: proto.InputType_Enum,
-				// 	EnumValue: 1,
-				// 	EnumOptions: []string{
-				// 		"10", "25",
-				// 	},
-				// },
-				// {
-				// 	Label:     "Heroic",
-				// 	Tooltip:   "Is the encounter in Heroic Mode",
-				// 	InputType: proto.InputType_Bool,
-				// 	BoolValue: true,
-				// },
 				{
 					Label:       "Impale Reaction Time",
 					Tooltip:     "How long will the Raid take to Impale Head in Seconds. (After the initial 10s)",
@@ -71,98 +75,114 @@ func createMagmawPreset(bossPrefix string, raidSize int, isHeroic bool,
 			},
 		},
 		AI: func() core.TargetAI {
-			return makeMagmawAI(raidSize, isHeroic)
+			return &MagmawAI{}
+		},
+		ScaledStats: func(inputs []*proto.TargetInput) (float64, float64) {
+			_, _, scalingIndex := magmawDifficulty(inputs)
+			return []float64{26_798_304, 81_082_048, 39_200_000, 120_016_403}[scalingIndex],
+				[]float64{110000, 150000, 150000, 210000}[scalingIndex]
 		},
 	})
 
-	if isHeroic {
-		core.AddPresetTarget(&core.PresetTarget{
-			PathPrefix: bossPrefix,
-			Config: &proto.Target{
-				Id:        addNpcId,
-				Name:      targetNameAdd,
-				Level:     87,
-				MobType:   proto.MobType_MobTypeBeast,
-				TankIndex: 1,
-
-				Stats: stats.Stats{
-					stats.Health:      addHealth,
-					stats.Armor:       11977,
-					stats.AttackPower: 650,
-				}.ToFloatArray(),
-
-				SpellSchool:   proto.SpellSchool_SpellSchoolPhysical,
-				SwingSpeed:    2.0,
-				MinBaseDamage: addMinBaseDamage,
-				DamageSpread:  0.5,
-				TargetInputs:  []*proto.TargetInput{},
-			},
-			AI: default_ai.NewDefaultAI([]default_ai.TargetAbility{
-				{
-					InitialCD:   time.Second * 5,
-					ChanceToUse: 0,
-					MakeSpell: func(target *core.Target) *core.Spell {
-						// Fiery Slash Next melee Spell
-						nextMeleeSpell := target.GetOrRegisterSpell(core.SpellConfig{
-							ActionID:    core.ActionID{SpellID: 92144},
-							SpellSchool: core.SpellSchoolFire,
-							ProcMask:    core.ProcMaskSpellDamage,
-
-							Cast: core.CastConfig{
-								CD: core.Cooldown{
-									Timer:    target.NewTimer(),
-									Duration: time.Second * 7,
-								},
-							},
-
-							DamageMultiplier: 0.75,
-
-							ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-								spell.CalcAndDealDamage(sim, target, spell.Unit.AutoAttacks.MH().EnemyWeaponDamage(sim, spell.MeleeAttackPower(), 0.5), spell.OutcomeEnemyMeleeWhite)
-							},
-						})
-
-						target.AutoAttacks.SetReplaceMHSwing(func(sim *core.Simulation, mhSwingSpell *core.Spell) *core.Spell {
-							if nextMeleeSpell.CanCast(sim, target.CurrentTarget) && sim.Proc(0.75, "Fiery Slash Cast") {
-								return nextMeleeSpell
-							}
-							return mhSwingSpell
-						})
-
-						target.AutoAttacks.MHConfig().ActionID.Tag = 49416
+	// Only present on heroic, the add stays despawned on normal.
+	core.AddPresetTarget(&core.PresetTarget{
+		PathPrefix: bossPrefix,
+		Config: &proto.Target{
+			Id:        blazingConstructID,
+			Name:      "Blazing Construct",
+			Level:     87,
+			MobType:   proto.MobType_MobTypeBeast,
+			TankIndex: 1,
 
-						return nextMeleeSpell
-					},
-				},
-			}),
-		})
-		core.AddPresetEncounter(targetName, []string{
-			bossPrefix + "/" + targetName,
-			bossPrefix + "/" + targetNameAdd,
-		})
-	} else {
-		core.AddPresetEncounter(targetName, []string{
-			bossPrefix + "/" + targetName,
-		})
-	}
+			Stats: stats.Stats{
+				stats.Health:      4_500_000,
+				stats.Armor:       11977,
+				stats.AttackPower: 650,
+			}.ToFloatArray(),
 
+			SpellSchool:   proto.SpellSchool_SpellSchoolPhysical,
+			SwingSpeed:    2.0,
+			MinBaseDamage: 80000,
+			DamageSpread:  0.5,
+			TargetInputs:  []*proto.TargetInput{},
+		},
+		AI: func() core.TargetAI {
+			return &blazingConstructAI{
+				TargetAI: makeBlazingConstructDefaultAI(),
+			}
+		},
+		ScaledStats: func(inputs []*proto.TargetInput) (float64, float64) {
+			raidSize, _, _ := magmawDifficulty(inputs)
+			return core.Ternary(raidSize == 10, 1_410_000.0, 4_500_000.0), core.Ternary(raidSize == 10, 44000.0, 80000.0)
+		},
+	})
+
+	core.AddPresetEncounterWithInputs("Magmaw", []string{
+		bossPrefix + "/Magmaw",
+		bossPrefix + "/Blazing Construct",
+	}, magmawDifficultyInputs(25, true))
+
+	// Each raid size and difficulty used to be a separate preset. Without inputs
+	// the encounter is 10 normal, like the old preset with Magmaw's own ID.
+	core.AddLegacyPresetTarget(41571, magmawID, magmawDifficultyInputs(25, false))
+	core.AddLegacyPresetTarget(41572, magmawID, magmawDifficultyInputs(10, true))
+	core.AddLegacyPresetTarget(41573, magmawID, magmawDifficultyInputs(25, true))
+	core.AddLegacyPresetTarget(49417, blazingConstructID, magmawDifficultyInputs(25, true))
 }
 
-func addMagmaw(bossPrefix string) {
-	// size, heroic, boss hp, boss min damage, add hp, add min damage
-	createMagmawPreset(bossPrefix, 10, false, 41570, 26_798_304, 110000, 0, 0, 0)
-	createMagmawPreset(bossPrefix, 25, false, 41571, 81_082_048, 150000, 0, 0, 0)
-	createMagmawPreset(bossPrefix, 10, true, 41572, 39_200_000, 150000, 49416, 1_410_000, 44000)
-	createMagmawPreset(bossPrefix, 25, true, 41573, 120_016_403, 210000, 49417, 4_500_000, 80000)
+type blazingConstructAI struct {
+	core.TargetAI
 }
 
-func makeMagmawAI(raidSize int, isHeroic bool) core.TargetAI {
-	return &MagmawAI{
-		raidSize: raidSize,
-		isHeroic: isHeroic,
+func (ai *blazingConstructAI) Initialize(target *core.Target, config *proto.Target) {
+	ai.TargetAI.Initialize(target, config)
+
+	if _, isHeroic, _ := magmawDifficulty(target.Env.Encounter.Inputs); !isHeroic {
+		target.DisableAutoSpawn()
 	}
 }
 
+func makeBlazingConstructDefaultAI() core.TargetAI {
+	return default_ai.NewDefaultAI([]default_ai.TargetAbility{
+		{
+			InitialCD:   time.Second * 5,
+			ChanceToUse: 0,
+			MakeSpell: func(target *core.Target) *core.Spell {
+				// Fiery Slash Next melee Spell
+				nextMeleeSpell := target.GetOrRegisterSpell(core.SpellConfig{
+					ActionID:    core.ActionID{SpellID: 92144},
+					SpellSchool: core.SpellSchoolFire,
+					ProcMask:    core.ProcMaskSpellDamage,
+
+					Cast: core.CastConfig{
+						CD: core.Cooldown{
+							Timer:    target.NewTimer(),
+							Duration: time.Second * 7,
+						},
+					},
+
+					DamageMultiplier: 0.75,
+
+					ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+						spell.CalcAndDealDamage(sim, target, spell.Unit.AutoAttacks.MH().EnemyWeaponDamage(sim, spell.MeleeAttackPower(), 0.5), spell.OutcomeEnemyMeleeWhite)
+					},
+				})
+
+				target.AutoAttacks.SetReplaceMHSwing(func(sim *core.Simulation, mhSwingSpell *core.Spell) *core.Spell {
+					if nextMeleeSpell.CanCast(sim, target.CurrentTarget) && sim.Proc(0.75, "Fiery Slash Cast") {
+						return nextMeleeSpell
+					}
+					return mhSwingSpell
+				})
+
+				target.AutoAttacks.MHConfig().ActionID.Tag = blazingConstructID
+
+				return nextMeleeSpell
+			},
+		},
+	})()
+}
+
 type MagmawAI struct {
 	Target *core.Target
 
@@ -185,24 +205,11 @@ type MagmawAI struct {
 
 func (ai *MagmawAI) Initialize(target *core.Target, config *proto.Target) {
 	ai.Target = target
+	ai.raidSize, ai.isHeroic, _ = magmawDifficulty(target.Env.Encounter.Inputs)
 
-	// if target.Env.Raid.Size() <= 1 {
-	// 	// Individual Sims - use the input configuration
-	// 	ai.raidSize = []int{10, 25}[config.TargetInputs[0].EnumValue]
-	// } else {
-	// 	// Raid sim - Set from number of players
-	// 	ai.raidSize = 10
-	// 	if target.Env.Raid.Size() > 10 {
-	// 		ai.raidSize = 25
-	// 	}
-	// }
-
-	// ai.isHeroic = config.TargetInputs[1].BoolValue
-	// ai.impaleDelay = config.TargetInputs[2].NumberValue
-
-	ai.Target.AutoAttacks.MHConfig().ActionID.Tag = 41570
+	ai.Target.AutoAttacks.MHConfig().ActionID.Tag = magmawID
 
-	ai.impaleDelay = config.TargetInputs[0].NumberValue
+	ai.impaleDelay = core.GetTargetInput(config, "Impale Reaction Time").GetNumberValue()
 	ai.registerSpells()
 }
 
@@ -0,0 +1,409 @@
+package encounters
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Parses combat logs in the plain-text format written by /combatlog, as of patch
+// 4.2 which added the raid flag fields. Each line looks like:
+//
+//	6/2 20:15:42.123  SPELL_DAMAGE,0xF150A26200006B9B,"Magmaw",0xa48,0x0,0x0380000004D2C3A1,"Tank",0x514,0x0,78359,"Magma Spit",0x4,25000,0,4,0,0,0,nil,nil,nil
+
+type CombatLogOptions struct {
+	// Name of the boss, whose death ends the pull. Defaults to the first hostile
+	// NPC to fight the raid.
+	Boss string
+}
+
+// Unit flags from the combat log.
+const (
+	combatLogFlagHostile = 0x40
+	combatLogFlagPlayer  = 0x400
+	combatLogFlagNPC     = 0x800
+)
+
+type combatLogEvent struct {
+	time time.Duration
+	name string
+
+	srcGUID  string
+	srcName  string
+	srcFlags uint64
+	dstGUID  string
+	dstName  string
+	dstFlags uint64
+
+	// The event-specific fields after the unit fields.
+	params []string
+}
+
+func (event *combatLogEvent) srcIsEnemy() bool {
+	return event.srcFlags&combatLogFlagNPC != 0 && event.srcFlags&combatLogFlagHostile != 0
+}
+func (event *combatLogEvent) dstIsEnemy() bool {
+	return event.dstFlags&combatLogFlagNPC != 0 && event.dstFlags&combatLogFlagHostile != 0
+}
+func (event *combatLogEvent) dstIsPlayer() bool {
+	return event.dstFlags&combatLogFlagPlayer != 0
+}
+
+// Builds an encounter replay from the first pull in a combat log. The pull starts
+// with the first fight between a hostile NPC and the raid, and ends when the boss
+// dies or the log does.
+func ParseCombatLog(r io.Reader, options CombatLogOptions) (*proto.EncounterReplay, error) {
+	parser := &combatLogParser{
+		options: options,
+		targets: make(map[string]*proto.ReplayTarget),
+		players: make(map[string]int32),
+		casts:   make(map[string]map[int32]*proto.ReplayEvent),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		event, err := parser.parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if parser.handleEvent(event) {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return parser.finish()
+}
+
+type combatLogParser struct {
+	options CombatLogOptions
+
+	// Timestamp of the first line. Timestamps have no year, so logs can't span
+	// New Year's Eve.
+	firstTimestamp time.Time
+
+	started   bool
+	startTime time.Duration
+	lastTime  time.Duration
+	bossGUID  string
+	bossName  string
+	endTime   time.Duration
+	ended     bool
+
+	targets     map[string]*proto.ReplayTarget
+	targetOrder []*proto.ReplayTarget
+	players     map[string]int32
+	// Casts in progress, by caster GUID and spell ID.
+	casts map[string]map[int32]*proto.ReplayEvent
+}
+
+func (parser *combatLogParser) parseLine(line string) (*combatLogEvent, error) {
+	timestampStr, fieldsStr, ok := strings.Cut(line, "  ")
+	if !ok {
+		return nil, fmt.Errorf("missing timestamp")
+	}
+	timestamp, err := time.Parse("1/2 15:04:05.000", timestampStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", timestampStr)
+	}
+	if parser.firstTimestamp.IsZero() {
+		parser.firstTimestamp = timestamp
+	}
+	eventTime := timestamp.Sub(parser.firstTimestamp)
+
+	reader := csv.NewReader(strings.NewReader(fieldsStr))
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	fields, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < 9 {
+		// Events without units, e.g. COMBAT_LOG_VERSION in later clients.
+		return &combatLogEvent{time: eventTime, name: fields[0]}, nil
+	}
+
+	srcFlags, err := strconv.ParseUint(fields[3], 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source flags %q", fields[3])
+	}
+	dstFlags, err := strconv.ParseUint(fields[7], 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination flags %q", fields[7])
+	}
+
+	return &combatLogEvent{
+		time:     eventTime,
+		name:     fields[0],
+		srcGUID:  fields[1],
+		srcName:  fields[2],
+		srcFlags: srcFlags,
+		dstGUID:  fields[5],
+		dstName:  fields[6],
+		dstFlags: dstFlags,
+		params:   fields[9:],
+	}, nil
+}
+
+func (parser *combatLogParser) isBoss(guid string, name string) bool {
+	if parser.options.Boss != "" {
+		return name == parser.options.Boss
+	}
+	return guid == parser.bossGUID
+}
+
+// Processes an event, returning true once the pull is over.
+func (parser *combatLogParser) handleEvent(event *combatLogEvent) bool {
+	if !parser.started {
+		// The pull starts with the first fight between a hostile NPC and a player.
+		var enemyGUID, enemyName string
+		if event.srcIsEnemy() && event.dstIsPlayer() {
+			enemyGUID, enemyName = event.srcGUID, event.srcName
+		} else if event.dstIsEnemy() && event.srcFlags&combatLogFlagPlayer != 0 {
+			enemyGUID, enemyName = event.dstGUID, event.dstName
+		} else {
+			return false
+		}
+		if parser.options.Boss != "" && enemyName != parser.options.Boss {
+			return false
+		}
+		parser.started = true
+		parser.startTime = event.time
+		parser.bossGUID = enemyGUID
+		parser.bossName = enemyName
+	}
+
+	now := event.time - parser.startTime
+	parser.lastTime = now
+	if event.srcIsEnemy() {
+		parser.getTarget(event.srcGUID, event.srcName, now)
+	}
+	if event.dstIsEnemy() {
+		parser.getTarget(event.dstGUID, event.dstName, now)
+	}
+
+	switch event.name {
+	case "SWING_DAMAGE", "SPELL_DAMAGE", "SPELL_PERIODIC_DAMAGE", "RANGE_DAMAGE":
+		parser.handleDamage(event, now)
+	case "SWING_MISSED", "SPELL_MISSED", "SPELL_PERIODIC_MISSED", "RANGE_MISSED":
+		parser.handleMiss(event, now)
+	case "SPELL_CAST_START":
+		if event.srcIsEnemy() && len(event.params) >= 3 {
+			parser.startCast(event, now)
+		}
+	case "SPELL_CAST_SUCCESS":
+		if event.srcIsEnemy() && len(event.params) >= 1 {
+			parser.finishCast(event.srcGUID, parseCombatLogInt(event.params[0]), now)
+		}
+	case "SPELL_INTERRUPT":
+		if event.dstIsEnemy() && len(event.params) >= 4 {
+			parser.finishCast(event.dstGUID, parseCombatLogInt(event.params[3]), now)
+		}
+	case "UNIT_DIED":
+		if target, ok := parser.targets[event.dstGUID]; ok {
+			target.DeathTime = now.Seconds()
+			delete(parser.casts, event.dstGUID)
+			if parser.isBoss(event.dstGUID, event.dstName) {
+				parser.endTime = now
+				parser.ended = true
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (parser *combatLogParser) getTarget(guid string, name string, now time.Duration) *proto.ReplayTarget {
+	if target, ok := parser.targets[guid]; ok {
+		return target
+	}
+	target := &proto.ReplayTarget{
+		NpcId:     npcIDFromGUID(guid),
+		Name:      name,
+		SpawnTime: now.Seconds(),
+	}
+	parser.targets[guid] = target
+	parser.targetOrder = append(parser.targetOrder, target)
+	return target
+}
+
+func (parser *combatLogParser) getPlayer(guid string) int32 {
+	if player, ok := parser.players[guid]; ok {
+		return player
+	}
+	player := int32(len(parser.players))
+	parser.players[guid] = player
+	return player
+}
+
+func (parser *combatLogParser) handleDamage(event *combatLogEvent, now time.Duration) {
+	spellID, school, params := parseCombatLogSpell(event)
+	if len(params) < 6 {
+		return
+	}
+	amount := parseCombatLogFloat(params[0])
+	overkill := max(parseCombatLogFloat(params[1]), 0)
+	absorbed := parseCombatLogFloat(params[5])
+
+	if event.dstIsEnemy() && !event.srcIsEnemy() {
+		parser.targets[event.dstGUID].DamageTaken += amount - overkill
+		return
+	}
+	if event.srcIsEnemy() && event.dstIsPlayer() {
+		parser.addDamage(event, now, spellID, school, amount+absorbed)
+	}
+}
+
+// Fully absorbed hits are logged as misses, but still count as damage taken.
+func (parser *combatLogParser) handleMiss(event *combatLogEvent, now time.Duration) {
+	spellID, school, params := parseCombatLogSpell(event)
+	if len(params) < 2 || params[0] != "ABSORB" || !event.srcIsEnemy() || !event.dstIsPlayer() {
+		return
+	}
+	parser.addDamage(event, now, spellID, school, parseCombatLogFloat(params[1]))
+}
+
+func (parser *combatLogParser) addDamage(event *combatLogEvent, now time.Duration, spellID int32, school proto.SpellSchool, damage float64) {
+	if damage <= 0 {
+		return
+	}
+	target := parser.targets[event.srcGUID]
+	target.Events = append(target.Events, &proto.ReplayEvent{
+		Type:    proto.ReplayEvent_Damage,
+		Time:    now.Seconds(),
+		SpellId: spellID,
+		School:  school,
+		Damage:  damage,
+		Player:  parser.getPlayer(event.dstGUID),
+	})
+}
+
+func (parser *combatLogParser) startCast(event *combatLogEvent, now time.Duration) {
+	spellID := parseCombatLogInt(event.params[0])
+	cast := &proto.ReplayEvent{
+		Type:    proto.ReplayEvent_CastStart,
+		Time:    now.Seconds(),
+		SpellId: spellID,
+		School:  schoolFromCombatLog(event.params[2]),
+	}
+	target := parser.targets[event.srcGUID]
+	target.Events = append(target.Events, cast)
+
+	if parser.casts[event.srcGUID] == nil {
+		parser.casts[event.srcGUID] = make(map[int32]*proto.ReplayEvent)
+	}
+	parser.casts[event.srcGUID][spellID] = cast
+}
+
+func (parser *combatLogParser) finishCast(guid string, spellID int32, now time.Duration) {
+	if cast, ok := parser.casts[guid][spellID]; ok {
+		cast.CastTime = now.Seconds() - cast.Time
+		delete(parser.casts[guid], spellID)
+	}
+}
+
+func (parser *combatLogParser) finish() (*proto.EncounterReplay, error) {
+	if !parser.started {
+		if parser.options.Boss != "" {
+			return nil, fmt.Errorf("no fight with %s found in the log", parser.options.Boss)
+		}
+		return nil, fmt.Errorf("no fight found in the log")
+	}
+	if !parser.ended {
+		parser.endTime = parser.lastTime
+	}
+
+	// Casts which never finished last until the end of the pull.
+	for _, casts := range parser.casts {
+		for _, cast := range casts {
+			cast.CastTime = parser.endTime.Seconds() - cast.Time
+		}
+	}
+
+	replay := &proto.EncounterReplay{
+		Name:     parser.bossName,
+		Duration: parser.endTime.Seconds(),
+	}
+	replay.Targets = append(replay.Targets, parser.targetOrder...)
+	for _, target := range replay.Targets {
+		target.NumPlayers = int32(len(parser.players))
+	}
+	return replay, nil
+}
+
+// Returns the spell ID and school of a damage or miss event, and the fields
+// after them. Melee swings have no spell fields and use spell ID 0.
+func parseCombatLogSpell(event *combatLogEvent) (int32, proto.SpellSchool, []string) {
+	if strings.HasPrefix(event.name, "SWING_") {
+		return 0, proto.SpellSchool_SpellSchoolPhysical, event.params
+	}
+	if len(event.params) < 3 {
+		return 0, proto.SpellSchool_SpellSchoolPhysical, nil
+	}
+	return parseCombatLogInt(event.params[0]), schoolFromCombatLog(event.params[2]), event.params[3:]
+}
+
+// Creature GUIDs look like 0xF130A2620000638E, with the NPC ID in the 4 hex digits
+// after the unit type.
+func npcIDFromGUID(guid string) int32 {
+	hex := strings.TrimPrefix(strings.ToUpper(guid), "0X")
+	if len(hex) != 16 {
+		return 0
+	}
+	id, err := strconv.ParseInt(hex[4:8], 16, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(id)
+}
+
+// Converts a school bitmask from the combat log. Multi-school spells use the
+// first school found.
+func schoolFromCombatLog(value string) proto.SpellSchool {
+	mask := parseCombatLogInt(value)
+	switch {
+	case mask&0x2 != 0:
+		return proto.SpellSchool_SpellSchoolHoly
+	case mask&0x4 != 0:
+		return proto.SpellSchool_SpellSchoolFire
+	case mask&0x8 != 0:
+		return proto.SpellSchool_SpellSchoolNature
+	case mask&0x10 != 0:
+		return proto.SpellSchool_SpellSchoolFrost
+	case mask&0x20 != 0:
+		return proto.SpellSchool_SpellSchoolShadow
+	case mask&0x40 != 0:
+		return proto.SpellSchool_SpellSchoolArcane
+	default:
+		return proto.SpellSchool_SpellSchoolPhysical
+	}
+}
+
+func parseCombatLogInt(value string) int32 {
+	parsed, err := strconv.ParseInt(value, 0, 64)
+	if err != nil {
+		return 0
+	}
+	return int32(parsed)
+}
+
+func parseCombatLogFloat(value string) float64 {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
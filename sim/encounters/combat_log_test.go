@@ -0,0 +1,178 @@
+package encounters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	goproto "google.golang.org/protobuf/proto"
+)
+
+const testCombatLog = `6/2 20:15:40.000  SPELL_AURA_APPLIED,0x0380000004D2C3A1,"Tank",0x514,0x0,0x0380000004D2C3A1,"Tank",0x514,0x0,25780,"Righteous Fury",0x2,BUFF
+6/2 20:15:42.000  SWING_DAMAGE,0xF150A26200006B9B,"Magmaw",0xa48,0x0,0x0380000004D2C3A1,"Tank",0x514,0x0,30000,0,1,0,5000,2000,nil,nil,nil
+6/2 20:15:42.500  SPELL_DAMAGE,0x0380000004D2C3A2,"Mage",0x514,0x0,0xF150A26200006B9B,"Magmaw",0xa48,0x0,133,"Fireball",0x4,40000,0,4,0,0,0,nil,nil,nil
+6/2 20:15:44.000  SPELL_CAST_START,0xF150A26200006B9B,"Magmaw",0xa48,0x0,0x0000000000000000,nil,0x80000000,0x80000000,78006,"Pillar of Flame",0x4
+6/2 20:15:46.500  SPELL_CAST_SUCCESS,0xF150A26200006B9B,"Magmaw",0xa48,0x0,0x0000000000000000,nil,0x80000000,0x80000000,78006,"Pillar of Flame",0x4
+6/2 20:15:47.000  SPELL_DAMAGE,0xF150A26200006B9B,"Magmaw",0xa48,0x0,0x0380000004D2C3A2,"Mage",0x514,0x0,78006,"Pillar of Flame",0x4,20000,0,4,0,0,0,nil,nil,nil
+6/2 20:15:48.000  SPELL_MISSED,0xF150A26200006B9B,"Magmaw",0xa48,0x0,0x0380000004D2C3A2,"Mage",0x514,0x0,78006,"Pillar of Flame",0x4,ABSORB,8000
+6/2 20:15:50.000  SPELL_DAMAGE,0xF1309D8C00001234,"Lava Parasite",0xa48,0x0,0x0380000004D2C3A2,"Mage",0x514,0x0,78941,"Parasitic Infection",0x8,5000,0,8,0,0,0,nil,nil,nil
+6/2 20:15:51.000  SPELL_DAMAGE,0x0380000004D2C3A2,"Mage",0x514,0x0,0xF1309D8C00001234,"Lava Parasite",0xa48,0x0,133,"Fireball",0x4,60000,10000,4,0,0,0,nil,nil,nil
+6/2 20:15:51.000  UNIT_DIED,0x0000000000000000,nil,0x80000000,0x80000000,0xF1309D8C00001234,"Lava Parasite",0xa48,0x0
+6/2 20:15:52.000  SPELL_DAMAGE,0x0380000004D2C3A2,"Mage",0x514,0x0,0xF150A26200006B9B,"Magmaw",0xa48,0x0,133,"Fireball",0x4,70000,5000,4,0,0,0,nil,nil,nil
+6/2 20:15:52.000  UNIT_DIED,0x0000000000000000,nil,0x80000000,0x80000000,0xF150A26200006B9B,"Magmaw",0xa48,0x0
+6/2 20:16:00.000  SWING_DAMAGE,0xF150A26200006B9B,"Magmaw",0xa48,0x0,0x0380000004D2C3A1,"Tank",0x514,0x0,30000,0,1,0,0,0,nil,nil,nil
+`
+
+func TestParseCombatLog(t *testing.T) {
+	replay, err := ParseCombatLog(strings.NewReader(testCombatLog), CombatLogOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse log: %s", err)
+	}
+
+	expected := &proto.EncounterReplay{
+		Name:     "Magmaw",
+		Duration: 10,
+		Targets: []*proto.ReplayTarget{
+			{
+				NpcId:       41570,
+				Name:        "Magmaw",
+				DeathTime:   10,
+				DamageTaken: 105_000,
+				NumPlayers:  2,
+				Events: []*proto.ReplayEvent{
+					{Type: proto.ReplayEvent_Damage, Damage: 32_000},
+					{Type: proto.ReplayEvent_CastStart, Time: 2, SpellId: 78006, School: proto.SpellSchool_SpellSchoolFire, CastTime: 2.5},
+					{Type: proto.ReplayEvent_Damage, Time: 5, SpellId: 78006, School: proto.SpellSchool_SpellSchoolFire, Damage: 20_000, Player: 1},
+					{Type: proto.ReplayEvent_Damage, Time: 6, SpellId: 78006, School: proto.SpellSchool_SpellSchoolFire, Damage: 8_000, Player: 1},
+				},
+			},
+			{
+				NpcId:       0x9D8C,
+				Name:        "Lava Parasite",
+				SpawnTime:   8,
+				DeathTime:   9,
+				DamageTaken: 50_000,
+				NumPlayers:  2,
+				Events: []*proto.ReplayEvent{
+					{Type: proto.ReplayEvent_Damage, Time: 8, SpellId: 78941, School: proto.SpellSchool_SpellSchoolNature, Damage: 5_000, Player: 1},
+				},
+			},
+		},
+	}
+	if !goproto.Equal(replay, expected) {
+		t.Fatalf("unexpected replay:\n%v\nexpected:\n%v", replay, expected)
+	}
+}
+
+func TestParseCombatLogBossOption(t *testing.T) {
+	if _, err := ParseCombatLog(strings.NewReader(testCombatLog), CombatLogOptions{Boss: "Nefarian"}); err == nil {
+		t.Fatalf("expected an error for a boss which isn't in the log")
+	}
+
+	replay, err := ParseCombatLog(strings.NewReader(testCombatLog), CombatLogOptions{Boss: "Lava Parasite"})
+	if err != nil {
+		t.Fatalf("failed to parse log: %s", err)
+	}
+	// The pull starts when the parasite first attacks, and ends when it dies.
+	if replay.Name != "Lava Parasite" || replay.Duration != 1 {
+		t.Fatalf("expected a 1 second pull against the parasite, got %s for %0.1f seconds", replay.Name, replay.Duration)
+	}
+}
+
+func TestEncounterReplay(t *testing.T) {
+	const (
+		boltID = 990900
+		biteID = 990901
+	)
+
+	replay := &proto.EncounterReplay{
+		Name:     "Replay Test",
+		Duration: 60,
+		Targets: []*proto.ReplayTarget{
+			{
+				Name:       "Replayed Boss",
+				NumPlayers: 4,
+				Events: []*proto.ReplayEvent{
+					{Type: proto.ReplayEvent_Damage, Time: 1, Damage: 20_000, Player: 1},
+					{Type: proto.ReplayEvent_CastStart, Time: 5, SpellId: boltID, School: proto.SpellSchool_SpellSchoolShadow, CastTime: 2},
+					{Type: proto.ReplayEvent_Damage, Time: 7, SpellId: boltID, School: proto.SpellSchool_SpellSchoolShadow, Damage: 30_000, Player: 3},
+				},
+			},
+			{
+				Name:       "Replayed Add",
+				SpawnTime:  10,
+				DeathTime:  20,
+				NumPlayers: 4,
+				Events: []*proto.ReplayEvent{
+					{Type: proto.ReplayEvent_Damage, Time: 12, SpellId: biteID, Damage: 6_000, Player: 2},
+				},
+			},
+		},
+	}
+
+	// Replays are built per request, so the same pull can be replayed again.
+	NewReplayEncounter(replay)
+	encounter := NewReplayEncounter(replay)
+	if preset := core.GetPresetTargetWithID(encounter.Targets[0].Id); preset != nil {
+		t.Fatalf("expected replayed targets not to be registered as presets, got %s", preset.Path())
+	}
+
+	raid := core.SinglePlayerRaidProto(newIdleRogue("Tank"), &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{})
+	raid.Parties[0].Players = append(raid.Parties[0].Players, newIdleRogue("Other"))
+	raid.Tanks = []*proto.UnitReference{{Type: proto.UnitReference_Player, Index: 0}}
+	rsr := &proto.RaidSimRequest{
+		Raid:      raid,
+		Encounter: encounter,
+		SimOptions: &proto.SimOptions{
+			Iterations: 5,
+			IsTest:     true,
+		},
+	}
+
+	result := core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	iterations := rsr.SimOptions.Iterations
+
+	// The logged player taking the boss's swings is its tank, so the swings go to
+	// the simmed tank. Logged damage is dealt exactly, without any further mitigation.
+	melee := int32(0)
+	meleeDamage := 0.0
+	for _, action := range result.EncounterMetrics.Targets[0].Actions {
+		if action.Id.GetOtherId() == proto.OtherAction_OtherActionAttack {
+			for _, target := range action.Targets {
+				melee += target.Hits
+				meleeDamage += target.Damage
+			}
+		}
+	}
+	if melee != iterations || meleeDamage != 20_000*float64(iterations) {
+		t.Fatalf("expected one 20000 damage melee hit per iteration, got %d hits for %0.0f damage", melee, meleeDamage)
+	}
+
+	// The 3 other logged players map onto the single other simmed player, which
+	// takes a third of their damage.
+	bolts := targetActionMetrics(result, 0, boltID)
+	if bolts.Casts != iterations || bolts.Damage != 10_000*float64(iterations) {
+		t.Fatalf("expected one bolt cast and a 10000 damage hit per iteration, got %d casts for %0.0f damage", bolts.Casts, bolts.Damage)
+	}
+	// The add never swings, so its damage is spread over both simmed players.
+	bites := targetActionMetrics(result, 1, biteID)
+	if bites.Hits != iterations || bites.Damage != 3_000*float64(iterations) {
+		t.Fatalf("expected the add to bite for 3000 once per iteration, got %d hits for %0.0f damage", bites.Hits, bites.Damage)
+	}
+
+	// Without a tank in the sim, damage aimed at the logged tank is dropped.
+	rsr.Raid = core.SinglePlayerRaidProto(newIdleRogue("Other"), &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{})
+	result = core.RunRaidSim(rsr)
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	for _, action := range result.EncounterMetrics.Targets[0].Actions {
+		if action.Id.GetOtherId() == proto.OtherAction_OtherActionAttack && len(action.Targets) > 0 && action.Targets[0].Hits > 0 {
+			t.Fatalf("expected no melee hits without a tank, got %d", action.Targets[0].Hits)
+		}
+	}
+}
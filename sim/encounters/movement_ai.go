@@ -1,6 +1,7 @@
 package encounters
 
 import (
+	"math"
 	"time"
 
 	"github.com/wowsims/cata/sim/core"
@@ -107,34 +108,53 @@ func (ai *MovementAI) ExecuteCustomRotation(sim *core.Simulation) {
 		return
 	}
 
-	players := sim.Raid.AllPlayerUnits
+	moveRaid(sim, ai.MoveYards)
 
-	for i := 0; i < len(players); i++ {
-		player := players[i]
-		duration := ai.TimeToMove(ai.MoveYards, player)
+	ai.NextMoveTime = sim.CurrentTime + ai.MoveInterval
+	ai.Target.WaitUntil(sim, ai.NextMoveTime)
+}
+func (ai *MovementAI) TimeToMove(distance float64, unit *core.Unit) time.Duration {
+	return core.DurationFromSeconds(distance / unit.GetMovementSpeed())
+}
+
+// Moves every player in the raid the given distance, letting them finish their
+// current cast first.
+func moveRaid(sim *core.Simulation, yards float64) {
+	for _, player := range sim.Raid.AllPlayerUnits {
+		player := player
 		if player.Hardcast.Expires > sim.CurrentTime && !player.Hardcast.CanMove {
-			castEndsAt := player.Hardcast.Expires - sim.CurrentTime
-			// if castEndsAt < ai.ReactionTime {
 			core.StartDelayedAction(sim, core.DelayedActionOptions{
-				DoAt:     sim.CurrentTime + castEndsAt,
+				DoAt:     player.Hardcast.Expires,
 				Priority: core.ActionPriorityPrePull + 1,
-				OnAction: func(s *core.Simulation) {
-					player.MoveDuration(duration, sim)
+				OnAction: func(sim *core.Simulation) {
+					strafeAroundTarget(sim, player, yards)
 				},
 			})
-			// } else {
-			// 	// Cancel casted spell and move immediately
-			// 	// For now we do nothing in this scenario
-			// 	return
-			// }
 		} else {
-			player.MoveDuration(duration, sim)
+			strafeAroundTarget(sim, player, yards)
 		}
 	}
-
-	ai.NextMoveTime = sim.CurrentTime + ai.MoveInterval
-	ai.Target.WaitUntil(sim, ai.NextMoveTime)
 }
-func (ai *MovementAI) TimeToMove(distance float64, unit *core.Unit) time.Duration {
-	return core.DurationFromSeconds(distance / unit.GetMovementSpeed())
+
+// Moves the unit the given distance to another point on the circle around its
+// target, so that it keeps its range. A unit closer to its target than half the
+// distance crosses to the opposite side instead, and a unit standing on its
+// target steps out to the side.
+func strafeAroundTarget(sim *core.Simulation, unit *core.Unit, yards float64) {
+	if unit.CurrentTarget == nil {
+		unit.MoveDuration(core.DurationFromSeconds(yards/unit.GetMovementSpeed()), sim)
+		return
+	}
+
+	unit.UpdatePosition(sim)
+	center := unit.CurrentTarget.Position
+	offset := unit.Position.Sub(center)
+	radius := offset.Length()
+	if radius == 0 {
+		unit.MoveToPosition(unit.Position.Add(core.Vector2{Y: yards}), sim)
+		return
+	}
+
+	angle := 2 * math.Asin(min(yards/(2*radius), 1))
+	unit.MoveToPosition(center.Add(offset.Rotate(angle)), sim)
 }
@@ -0,0 +1,51 @@
+package encounters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Each player strafes around the target on their own, and a player who is
+// casting moves once the cast finishes.
+func TestMoveRaid(t *testing.T) {
+	raid := core.SinglePlayerRaidProto(newIdleRogue("Caster"), &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{})
+	raid.Parties[0].Players = append(raid.Parties[0].Players, newIdleRogue("Runner"))
+	raid.Parties[0].Players[0].Position = &proto.Position{Y: -20}
+	raid.Parties[0].Players[1].Position = &proto.Position{Y: -10}
+
+	sim := core.NewSim(&proto.RaidSimRequest{
+		Raid: raid,
+		Encounter: &proto.Encounter{
+			Duration: 60,
+			Targets:  []*proto.Target{core.NewDefaultTarget()},
+		},
+		SimOptions: &proto.SimOptions{IsTest: true},
+	})
+	sim.Reset()
+
+	caster, runner := sim.Raid.AllPlayerUnits[0], sim.Raid.AllPlayerUnits[1]
+	casterStart, runnerStart := caster.Position, runner.Position
+	caster.Hardcast = core.Hardcast{Expires: sim.CurrentTime + time.Second}
+	moveRaid(sim, 5)
+	for sim.CurrentTime < 5*time.Second {
+		if sim.Step() {
+			break
+		}
+	}
+
+	for _, player := range []struct {
+		unit  *core.Unit
+		start core.Vector2
+	}{{caster, casterStart}, {runner, runnerStart}} {
+		player.unit.UpdatePosition(sim)
+		if player.unit.Moving || player.unit.Position == player.start {
+			t.Fatalf("expected %s to finish moving away from %v, got %v", player.unit.Label, player.start, player.unit.Position)
+		}
+		if distance, startDistance := player.unit.DistanceFromTarget(), player.start.Length(); !core.WithinToleranceFloat64(distance, startDistance, 0.01) {
+			t.Fatalf("expected %s to stay %0.1f yards from the target, got %0.1f", player.unit.Label, startDistance, distance)
+		}
+	}
+}
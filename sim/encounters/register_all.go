@@ -11,7 +11,8 @@ func init() {
 	AddDefaultPresetEncounter()
 	addMovementAI()
 	bwd.Register()
-
+	registerScriptedEncounters()
+	core.RegisterReplayAIFactory(NewReplayAI)
 }
 
 func AddSingleTargetBossEncounter(presetTarget *core.PresetTarget) {
@@ -0,0 +1,214 @@
+package encounters
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	goproto "google.golang.org/protobuf/proto"
+)
+
+// Implementation of TargetAI which replays the casts and damage of a target from
+// a real pull, e.g. parsed from a combat log with ParseCombatLog.
+type ReplayAI struct {
+	Target *core.Target
+
+	replay *proto.ReplayTarget
+	spells map[int32]*core.Spell
+
+	// The logged player who tanked the unit, or -1 if it never swung at anyone.
+	tank int32
+	// Number of logged players other than the tank.
+	numOthers int32
+
+	// Cast time of the cast being started.
+	castTime time.Duration
+}
+
+func NewReplayAI(replay *proto.ReplayTarget) core.AIFactory {
+	return func() core.TargetAI {
+		return &ReplayAI{
+			replay: replay,
+		}
+	}
+}
+
+func (ai *ReplayAI) Initialize(target *core.Target, _ *proto.Target) {
+	ai.Target = target
+	ai.spells = make(map[int32]*core.Spell)
+	numPlayers := ai.replay.NumPlayers
+	swings := make(map[int32]int)
+	ai.tank = -1
+	for _, event := range ai.replay.Events {
+		if ai.spells[event.SpellId] == nil {
+			ai.spells[event.SpellId] = ai.registerSpell(event)
+		}
+		if event.Type != proto.ReplayEvent_Damage {
+			continue
+		}
+
+		numPlayers = max(numPlayers, event.Player+1)
+		if event.SpellId == 0 {
+			swings[event.Player]++
+			if ai.tank == -1 || swings[event.Player] > swings[ai.tank] {
+				ai.tank = event.Player
+			}
+		}
+	}
+
+	ai.numOthers = numPlayers
+	if ai.tank != -1 {
+		ai.numOthers--
+	}
+}
+
+// Registers the spell used to replay the events of a spell ID. The logged damage
+// was already mitigated, so it is dealt as is.
+func (ai *ReplayAI) registerSpell(event *proto.ReplayEvent) *core.Spell {
+	actionID := core.ActionID{SpellID: event.SpellId}
+	procMask := core.ProcMaskSpellDamage
+	flags := core.SpellFlagIgnoreModifiers | core.SpellFlagIgnoreResists
+	if event.SpellId == 0 {
+		actionID = core.ActionID{OtherID: proto.OtherAction_OtherActionAttack}
+		procMask = core.ProcMaskMeleeMHAuto
+		flags |= core.SpellFlagMeleeMetrics
+	}
+
+	return ai.Target.RegisterSpell(core.SpellConfig{
+		ActionID:    actionID,
+		SpellSchool: core.SpellSchoolFromProto(event.School),
+		ProcMask:    procMask,
+		Flags:       flags,
+
+		DamageMultiplier: 1,
+
+		Cast: core.CastConfig{
+			ModifyCast: func(_ *core.Simulation, _ *core.Spell, cast *core.Cast) {
+				cast.CastTime = ai.castTime
+			},
+		},
+
+		// Casts only show up in the metrics, the damage comes from the damage events.
+		ApplyEffects: func(_ *core.Simulation, _ *core.Unit, _ *core.Spell) {},
+	})
+}
+
+func (ai *ReplayAI) Reset(sim *core.Simulation) {
+	if len(ai.replay.Events) > 0 {
+		ai.scheduleEvent(sim, 0)
+	}
+}
+
+func (ai *ReplayAI) ExecuteCustomRotation(_ *core.Simulation) {}
+
+// Schedules the events one at a time, so that long replays don't flood the
+// pending action queue.
+func (ai *ReplayAI) scheduleEvent(sim *core.Simulation, eventIdx int) {
+	event := ai.replay.Events[eventIdx]
+	core.StartDelayedAction(sim, core.DelayedActionOptions{
+		DoAt: max(core.DurationFromSeconds(event.Time), sim.CurrentTime),
+		OnAction: func(sim *core.Simulation) {
+			if ai.Target.IsActive {
+				ai.doEvent(sim, event)
+			}
+			if eventIdx+1 < len(ai.replay.Events) {
+				ai.scheduleEvent(sim, eventIdx+1)
+			}
+		},
+	})
+}
+
+func (ai *ReplayAI) doEvent(sim *core.Simulation, event *proto.ReplayEvent) {
+	spell := ai.spells[event.SpellId]
+	players := sim.Raid.AllPlayerUnits
+
+	switch event.Type {
+	case proto.ReplayEvent_Damage:
+		if player, multiplier := ai.damagedPlayer(event.Player); player != nil {
+			spell.CalcAndDealDamage(sim, player, event.Damage*multiplier, spell.OutcomeAlwaysHit)
+		}
+	case proto.ReplayEvent_CastStart:
+		if ai.Target.Hardcast.Expires > sim.CurrentTime {
+			// Overlapping casts in the log, e.g. from abilities cast while moving.
+			return
+		}
+		target := ai.Target.CurrentTarget
+		if target == nil {
+			target = players[0]
+		}
+		ai.castTime = core.DurationFromSeconds(event.CastTime)
+		spell.Cast(sim, target)
+	}
+}
+
+// Returns the simmed player who takes the damage a logged player took, and the
+// multiplier for the damage, following the rules described on ReplayEvent.player.
+func (ai *ReplayAI) damagedPlayer(loggedPlayer int32) (*core.Unit, float64) {
+	tank := ai.Target.CurrentTarget
+	if loggedPlayer == ai.tank {
+		return tank, 1
+	}
+
+	others := ai.Target.Env.Raid.AllPlayerUnits
+	if idx := slices.Index(others, tank); idx != -1 && ai.tank != -1 {
+		others = append(others[:idx:idx], others[idx+1:]...)
+	}
+	if len(others) == 0 {
+		return nil, 0
+	}
+
+	otherIdx := loggedPlayer
+	if ai.tank != -1 && loggedPlayer > ai.tank {
+		otherIdx--
+	}
+	return others[int(otherIdx)%len(others)], min(float64(len(others))/float64(ai.numOthers), 1)
+}
+
+// Returns an encounter which fights the targets of a replay for the length of the
+// pull. The targets carry their replay, so nothing is registered globally.
+func NewReplayEncounter(replay *proto.EncounterReplay) *proto.Encounter {
+	encounter := &proto.Encounter{
+		Duration: replay.Duration,
+	}
+
+	// Replayed targets get IDs below zero, so that they never pick up the AI or
+	// inputs of a preset.
+	nextID := int32(-1)
+	nameCounts := make(map[string]int)
+	for _, replayTarget := range replay.Targets {
+		if replayTarget.DeathTime > 0 && replayTarget.DeathTime <= replayTarget.SpawnTime {
+			// Died as soon as it showed up, so there is nothing to replay.
+			continue
+		}
+
+		config := goproto.Clone(core.NewDefaultTarget()).(*proto.Target)
+		config.Id = nextID
+		nextID--
+		config.Replay = replayTarget
+
+		// Adds often share a name, so number them to tell them apart in the results.
+		nameCounts[replayTarget.Name]++
+		config.Name = replayTarget.Name
+		if count := nameCounts[replayTarget.Name]; count > 1 {
+			config.Name = fmt.Sprintf("%s #%d", replayTarget.Name, count)
+		}
+
+		// Melee swings are replayed like any other damage.
+		config.SwingSpeed = 0
+		config.MinBaseDamage = 0
+		config.ParryHaste = false
+
+		config.SpawnTime = replayTarget.SpawnTime
+		config.DespawnTime = replayTarget.DeathTime
+		if replayTarget.DeathTime > 0 {
+			config.Stats[stats.Health] = replayTarget.DamageTaken
+		}
+
+		encounter.Targets = append(encounter.Targets, config)
+	}
+
+	return encounter
+}
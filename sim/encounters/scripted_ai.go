@@ -0,0 +1,295 @@
+package encounters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+// Implementation of TargetAI which executes a TargetScript, so that bosses can be
+// described in data instead of code.
+type ScriptedAI struct {
+	Target *core.Target
+
+	script    *proto.TargetScript
+	npcID     int32
+	abilities map[string]*scriptedAbility
+
+	// Index of the current phase, or -1 before the target has started acting.
+	phase int
+	// Next occurrence of each event in the current phase, by event index.
+	pendingEvents []*core.PendingAction
+}
+
+type scriptedAbility struct {
+	config *proto.TargetScriptAbility
+	spell  *core.Spell
+}
+
+func NewScriptedAI(script *proto.TargetScript) core.AIFactory {
+	return func() core.TargetAI {
+		return &ScriptedAI{
+			script: script,
+		}
+	}
+}
+
+func (ai *ScriptedAI) Initialize(target *core.Target, config *proto.Target) {
+	ai.Target = target
+	ai.npcID = config.Id
+
+	if ai.script.SpawnedByScript {
+		target.DisableAutoSpawn()
+	}
+
+	ai.abilities = make(map[string]*scriptedAbility, len(ai.script.Abilities))
+	for _, abilityConfig := range ai.script.Abilities {
+		if _, ok := ai.abilities[abilityConfig.Name]; ok {
+			panic(fmt.Sprintf("%s: duplicate ability %s", config.Name, abilityConfig.Name))
+		}
+		ai.abilities[abilityConfig.Name] = ai.registerAbility(abilityConfig)
+	}
+
+	for phaseIdx, phase := range ai.script.Phases {
+		phaseIdx, phase := phaseIdx, phase
+		if phaseIdx > 0 && phase.StartHealthPercent > 0 {
+			target.OnHealthPercent(phase.StartHealthPercent/100, func(sim *core.Simulation) {
+				ai.startPhase(sim, phaseIdx)
+			})
+		}
+
+		for eventIdx, event := range phase.Events {
+			eventIdx, event := eventIdx, event
+			if cast := event.GetCast(); cast != "" && ai.abilities[cast] == nil {
+				panic(fmt.Sprintf("%s: phase %s casts unknown ability %s", config.Name, phase.Name, cast))
+			}
+			if event.HealthPercent > 0 {
+				target.OnHealthPercent(event.HealthPercent/100, func(sim *core.Simulation) {
+					if ai.phase == phaseIdx {
+						ai.scheduleEvent(sim, eventIdx, core.DurationFromSeconds(event.Delay), 0)
+					}
+				})
+			}
+		}
+	}
+}
+
+func (ai *ScriptedAI) registerAbility(config *proto.TargetScriptAbility) *scriptedAbility {
+	if config.SpellId == 0 {
+		panic(fmt.Sprintf("%s: ability %s needs a spell ID", ai.Target.Label, config.Name))
+	}
+
+	school := core.SpellSchoolFromProto(config.School)
+	procMask := core.ProcMaskSpellDamage
+	flags := core.SpellFlagNone
+	if school == core.SpellSchoolPhysical {
+		procMask = core.ProcMaskMeleeMHSpecial
+		flags = core.SpellFlagMeleeMetrics
+	}
+	if config.Interruptible {
+		flags |= core.SpellFlagInterruptible
+	}
+
+	var buff *core.Aura
+	if config.BuffDuration > 0 {
+		damageMultiplier := 1 + config.BuffDamagePercent/100
+		buff = ai.Target.RegisterAura(core.Aura{
+			Label:       config.Name,
+			ActionID:    core.ActionID{SpellID: config.SpellId},
+			Duration:    core.DurationFromSeconds(config.BuffDuration),
+			Dispellable: config.Dispellable,
+			OnGain: func(aura *core.Aura, sim *core.Simulation) {
+				aura.Unit.PseudoStats.DamageDealtMultiplier *= damageMultiplier
+			},
+			OnExpire: func(aura *core.Aura, sim *core.Simulation) {
+				aura.Unit.PseudoStats.DamageDealtMultiplier /= damageMultiplier
+			},
+		})
+	}
+
+	damageRoll := func(sim *core.Simulation) float64 {
+		return sim.RollWithLabel(config.MinDamage, max(config.MinDamage, config.MaxDamage), "Scripted Ability Damage")
+	}
+
+	spell := ai.Target.RegisterSpell(core.SpellConfig{
+		ActionID:    core.ActionID{SpellID: config.SpellId},
+		SpellSchool: school,
+		ProcMask:    procMask,
+		Flags:       flags,
+
+		DamageMultiplier: 1,
+
+		Cast: core.CastConfig{
+			IgnoreHaste: true,
+			DefaultCast: core.Cast{
+				CastTime: core.DurationFromSeconds(config.CastTime),
+			},
+			ModifyCast: func(sim *core.Simulation, spell *core.Spell, cast *core.Cast) {
+				if cast.CastTime > 0 {
+					spell.Unit.AutoAttacks.StopMeleeUntil(sim, sim.CurrentTime+cast.CastTime, false)
+				}
+			},
+		},
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			if buff != nil {
+				// Buffs only empower the target's later hits.
+				defer buff.Activate(sim)
+			}
+			if config.MaxDamage <= 0 && config.MinDamage <= 0 {
+				return
+			}
+
+			switch config.Target {
+			case proto.TargetScriptAbility_CurrentTarget:
+				spell.CalcAndDealDamage(sim, target, damageRoll(sim), spell.OutcomeAlwaysHit)
+			case proto.TargetScriptAbility_RandomRaidMember:
+				players := sim.Raid.AllPlayerUnits
+				player := players[int(sim.RandomFloat("Scripted Ability Target")*float64(len(players)))]
+				spell.CalcAndDealDamage(sim, player, damageRoll(sim), spell.OutcomeAlwaysHit)
+			case proto.TargetScriptAbility_AllRaidMembers:
+				for _, player := range sim.Raid.AllPlayerUnits {
+					spell.CalcAndDealDamage(sim, player, damageRoll(sim), spell.OutcomeAlwaysHit)
+				}
+			}
+		},
+	})
+
+	return &scriptedAbility{
+		config: config,
+		spell:  spell,
+	}
+}
+
+func (ai *ScriptedAI) Reset(sim *core.Simulation) {
+	ai.phase = -1
+	ai.pendingEvents = ai.pendingEvents[:0]
+}
+
+func (ai *ScriptedAI) ExecuteCustomRotation(sim *core.Simulation) {
+	// Everything else is driven by the script's timers and health triggers.
+	if ai.phase == -1 && len(ai.script.Phases) > 0 {
+		ai.startPhase(sim, 0)
+	}
+}
+
+// Stops the events of the current phase and starts those of the given phase. Phases
+// only ever move forward, so a phase which is skipped over never starts.
+func (ai *ScriptedAI) startPhase(sim *core.Simulation, phaseIdx int) {
+	if phaseIdx <= ai.phase || !ai.Target.IsActive {
+		return
+	}
+	firstPhase := ai.phase == -1
+	ai.phase = phaseIdx
+
+	phase := ai.script.Phases[phaseIdx]
+	if sim.Log != nil && phase.Name != "" {
+		ai.Target.Log(sim, "Starting phase %s", phase.Name)
+	}
+
+	for _, pa := range ai.pendingEvents {
+		if pa != nil {
+			pa.Cancel(sim)
+		}
+	}
+	ai.pendingEvents = ai.pendingEvents[:0]
+	for range phase.Events {
+		ai.pendingEvents = append(ai.pendingEvents, nil)
+	}
+	for eventIdx, event := range phase.Events {
+		if event.HealthPercent == 0 {
+			ai.scheduleEvent(sim, eventIdx, core.DurationFromSeconds(event.Delay), 0)
+		}
+	}
+
+	if firstPhase {
+		for nextIdx, nextPhase := range ai.script.Phases[1:] {
+			if nextPhase.StartTime <= 0 {
+				continue
+			}
+			nextIdx := nextIdx
+			core.StartDelayedAction(sim, core.DelayedActionOptions{
+				DoAt: max(core.DurationFromSeconds(nextPhase.StartTime), sim.CurrentTime),
+				OnAction: func(sim *core.Simulation) {
+					ai.startPhase(sim, nextIdx+1)
+				},
+			})
+		}
+	}
+}
+
+func (ai *ScriptedAI) scheduleEvent(sim *core.Simulation, eventIdx int, delay time.Duration, count int32) {
+	event := ai.script.Phases[ai.phase].Events[eventIdx]
+	ai.pendingEvents[eventIdx] = core.StartDelayedAction(sim, core.DelayedActionOptions{
+		DoAt: sim.CurrentTime + delay,
+		OnAction: func(sim *core.Simulation) {
+			// Events stop for good once the target is gone.
+			if !ai.Target.IsActive {
+				return
+			}
+			ai.doEvent(sim, event)
+
+			count++
+			if event.Interval > 0 && (event.MaxCount == 0 || count < event.MaxCount) {
+				ai.scheduleEvent(sim, eventIdx, core.DurationFromSeconds(event.Interval), count)
+			}
+		},
+	})
+}
+
+func (ai *ScriptedAI) doEvent(sim *core.Simulation, event *proto.TargetScriptEvent) {
+	switch action := event.Action.(type) {
+	case *proto.TargetScriptEvent_Cast:
+		ai.cast(sim, ai.abilities[action.Cast])
+	case *proto.TargetScriptEvent_MoveYards:
+		moveRaid(sim, action.MoveYards)
+	case *proto.TargetScriptEvent_MoveTo:
+		ai.Target.Reposition(sim, core.Vector2FromProto(action.MoveTo))
+	case *proto.TargetScriptEvent_SpawnTarget:
+		for _, target := range scriptedTargets(sim, action.SpawnTarget) {
+			target.Spawn(sim)
+		}
+	case *proto.TargetScriptEvent_DespawnTarget:
+		for _, target := range scriptedTargets(sim, action.DespawnTarget) {
+			target.Despawn(sim)
+		}
+	}
+}
+
+// Casts the ability, waiting for any cast in progress to finish first.
+func (ai *ScriptedAI) cast(sim *core.Simulation, ability *scriptedAbility) {
+	if ai.Target.Hardcast.Expires > sim.CurrentTime {
+		core.StartDelayedAction(sim, core.DelayedActionOptions{
+			DoAt: ai.Target.Hardcast.Expires,
+			OnAction: func(sim *core.Simulation) {
+				if ai.Target.IsActive {
+					ai.cast(sim, ability)
+				}
+			},
+		})
+		return
+	}
+
+	target := ai.Target.CurrentTarget
+	if target == nil {
+		// Untanked targets can still hit the raid, or buff themselves.
+		if ability.config.Target == proto.TargetScriptAbility_CurrentTarget && ability.config.BuffDuration <= 0 {
+			return
+		}
+		target = sim.Raid.AllPlayerUnits[0]
+	}
+	ability.spell.Cast(sim, target)
+}
+
+// Returns the targets in the encounter which run the script for the given NPC ID.
+func scriptedTargets(sim *core.Simulation, npcID int32) []*core.Target {
+	var targets []*core.Target
+	for _, target := range sim.Encounter.Targets {
+		if targetAI, ok := target.AI.(*ScriptedAI); ok && targetAI.npcID == npcID {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
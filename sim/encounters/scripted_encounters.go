@@ -0,0 +1,64 @@
+package encounters
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Encounter scripts in protojson format, see proto.EncounterScript.
+//
+//go:embed scripts
+var scriptFiles embed.FS
+
+func registerScriptedEncounters() {
+	files, err := fs.Glob(scriptFiles, "scripts/*.json")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range files {
+		data, err := scriptFiles.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+
+		script, err := parseEncounterScript(data)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid encounter script %s: %s", file, err))
+		}
+		RegisterEncounterScript(script)
+	}
+}
+
+func parseEncounterScript(data []byte) (*proto.EncounterScript, error) {
+	script := &proto.EncounterScript{}
+	if err := protojson.Unmarshal(data, script); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// Registers the targets of the script as presets running the scripted AI, and an
+// encounter containing all of them.
+func RegisterEncounterScript(script *proto.EncounterScript) {
+	targetPaths := make([]string, 0, len(script.Targets))
+	for _, targetScript := range script.Targets {
+		if targetScript.Target == nil {
+			panic(fmt.Sprintf("Encounter script %s has a target without a config", script.Name))
+		}
+
+		presetTarget := &core.PresetTarget{
+			PathPrefix: script.PathPrefix,
+			Config:     targetScript.Target,
+			AI:         NewScriptedAI(targetScript),
+		}
+		core.AddPresetTarget(presetTarget)
+		targetPaths = append(targetPaths, presetTarget.Path())
+	}
+	core.AddPresetEncounter(script.Name, targetPaths)
+}
@@ -0,0 +1,292 @@
+package encounters
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	"github.com/wowsims/cata/sim/rogue/combat"
+)
+
+var registerScriptedExample sync.Once
+var registerScriptedPhases sync.Once
+var registerScriptedHealthEvents sync.Once
+var registerCombatRogue sync.Once
+
+// A rogue with no gear or rotation, who never attacks.
+func newIdleRogue(name string) *proto.Player {
+	registerCombatRogue.Do(combat.RegisterCombatRogue)
+	return &proto.Player{
+		Name:      name,
+		Race:      proto.Race_RaceHuman,
+		Class:     proto.Class_ClassRogue,
+		Equipment: &proto.EquipmentSpec{},
+		Spec: &proto.Player_CombatRogue{
+			CombatRogue: &proto.CombatRogue{
+				Options: &proto.CombatRogue_Options{ClassOptions: &proto.RogueOptions{}},
+			},
+		},
+	}
+}
+
+// A rogue with only a main hand, who auto attacks the boss.
+func newAttackingRogue(name string) *proto.Player {
+	player := newIdleRogue(name)
+	player.Equipment.Items = make([]*proto.ItemSpec, proto.ItemSlot_ItemSlotMainHand+1)
+	for i := range player.Equipment.Items {
+		player.Equipment.Items[i] = &proto.ItemSpec{}
+	}
+	player.Equipment.Items[proto.ItemSlot_ItemSlotMainHand].Id = 68600
+	return player
+}
+
+func runScriptedEncounter(t *testing.T, player *proto.Player, duration float64, iterations int32, targets ...*proto.Target) *proto.RaidSimResult {
+	result := core.RunRaidSim(&proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(player, &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{}),
+		Encounter: &proto.Encounter{
+			Duration: duration,
+			Targets:  targets,
+		},
+		SimOptions: &proto.SimOptions{
+			Iterations: iterations,
+			IsTest:     true,
+		},
+	})
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	return result
+}
+
+// Returns a target's metrics for the spell, summed over its targets and all iterations.
+func targetActionMetrics(result *proto.RaidSimResult, targetIndex int, spellID int32) *proto.TargetedActionMetrics {
+	total := &proto.TargetedActionMetrics{}
+	for _, action := range result.EncounterMetrics.Targets[targetIndex].Actions {
+		if action.Id.GetSpellId() != spellID {
+			continue
+		}
+		for _, target := range action.Targets {
+			total.Casts += target.Casts
+			total.Hits += target.Hits
+			total.Damage += target.Damage
+		}
+	}
+	return total
+}
+
+func newScriptedTarget(id int32, name string) *proto.Target {
+	target := goproto.Clone(core.NewDefaultTarget()).(*proto.Target)
+	target.Id = id
+	target.Name = name
+	return target
+}
+
+func readExampleScript(t *testing.T) *proto.EncounterScript {
+	data, err := os.ReadFile("testdata/scripted_example.json")
+	if err != nil {
+		t.Fatalf("failed to read script: %s", err)
+	}
+	script, err := parseEncounterScript(data)
+	if err != nil {
+		t.Fatalf("failed to parse script: %s", err)
+	}
+	return script
+}
+
+func TestParseEncounterScript(t *testing.T) {
+	script := readExampleScript(t)
+
+	if len(script.Targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(script.Targets))
+	}
+	boss, add := script.Targets[0], script.Targets[1]
+	if !add.SpawnedByScript || boss.SpawnedByScript {
+		t.Fatalf("expected only the add to be spawned by the script")
+	}
+
+	abilities := make(map[string]bool)
+	for _, ability := range boss.Abilities {
+		abilities[ability.Name] = true
+	}
+	if len(boss.Phases) != 2 || boss.Phases[1].StartHealthPercent != 35 || boss.Phases[1].StartTime != 240 {
+		t.Fatalf("expected a second phase at 35%% health or 240s, got %v", boss.Phases)
+	}
+	for _, phase := range boss.Phases {
+		for _, event := range phase.Events {
+			if cast := event.GetCast(); cast != "" && !abilities[cast] {
+				t.Fatalf("phase %s casts unknown ability %s", phase.Name, cast)
+			}
+		}
+	}
+	if spawned := boss.Phases[1].Events[0].GetSpawnTarget(); spawned != add.Target.Id {
+		t.Fatalf("expected the second phase to spawn the add %d, got %d", add.Target.Id, spawned)
+	}
+}
+
+// Sims the example script's encounter through its registered presets, with a player
+// who never attacks, so the second phase starts on its timer.
+func TestScriptedExampleEncounter(t *testing.T) {
+	script := readExampleScript(t)
+	registerScriptedExample.Do(func() {
+		RegisterEncounterScript(script)
+	})
+
+	const iterations = 5
+	result := runScriptedEncounter(t, newIdleRogue("Idle Rogue"), 300, iterations, script.Targets[0].Target, script.Targets[1].Target)
+
+	casts := make(map[int32]int32)
+	for _, action := range result.EncounterMetrics.Targets[0].Actions {
+		for _, target := range action.Targets {
+			casts[action.Id.GetSpellId()] += target.Casts
+		}
+	}
+	// Lava Spew every 30s from 15s, then every 15s from 245s. Magma Spit every 7s
+	// from 7s, then every 5s from 243s.
+	if got, want := casts[77690], int32(8+4); got != want*iterations {
+		t.Errorf("expected %d Lava Spews per iteration, got %d", want, got/iterations)
+	}
+	if got, want := casts[78359], int32(34+12); got != want*iterations {
+		t.Errorf("expected %d Magma Spits per iteration, got %d", want, got/iterations)
+	}
+}
+
+// Sims a boss whose second phase starts at 50% health, spawning an add and casting a
+// limited number of bolts.
+func TestScriptedEncounterPhases(t *testing.T) {
+	const (
+		bossID  = 990200
+		addID   = 990201
+		pulseID = 990210
+		boltID  = 990211
+		biteID  = 990212
+	)
+
+	boss := newScriptedTarget(bossID, "Scripted Boss")
+	boss.Stats[stats.Health] = 40_000
+	add := newScriptedTarget(addID, "Scripted Add")
+	add.TankIndex = -1
+
+	registerScriptedPhases.Do(func() {
+		RegisterEncounterScript(&proto.EncounterScript{
+			Name: "Scripted Phases",
+			Targets: []*proto.TargetScript{
+				{
+					Target: boss,
+					Abilities: []*proto.TargetScriptAbility{
+						{Name: "Pulse", SpellId: pulseID, School: proto.SpellSchool_SpellSchoolShadow, Target: proto.TargetScriptAbility_AllRaidMembers, MinDamage: 1000, MaxDamage: 2000},
+						{Name: "Bolt", SpellId: boltID, School: proto.SpellSchool_SpellSchoolShadow, Target: proto.TargetScriptAbility_RandomRaidMember, MinDamage: 1000, CastTime: 1},
+					},
+					Phases: []*proto.TargetScriptPhase{
+						{
+							Events: []*proto.TargetScriptEvent{
+								{Delay: 5, Interval: 5, Action: &proto.TargetScriptEvent_Cast{Cast: "Pulse"}},
+							},
+						},
+						{
+							StartHealthPercent: 50,
+							Events: []*proto.TargetScriptEvent{
+								{Action: &proto.TargetScriptEvent_SpawnTarget{SpawnTarget: addID}},
+								{Delay: 2, Interval: 5, MaxCount: 3, Action: &proto.TargetScriptEvent_Cast{Cast: "Bolt"}},
+							},
+						},
+					},
+				},
+				{
+					Target:          add,
+					SpawnedByScript: true,
+					Abilities: []*proto.TargetScriptAbility{
+						{Name: "Bite", SpellId: biteID, Target: proto.TargetScriptAbility_AllRaidMembers, MinDamage: 500},
+					},
+					Phases: []*proto.TargetScriptPhase{
+						{
+							Events: []*proto.TargetScriptEvent{
+								{Interval: 2, Action: &proto.TargetScriptEvent_Cast{Cast: "Bite"}},
+							},
+						},
+					},
+				},
+			},
+		})
+	})
+
+	const iterations = 20
+	result := runScriptedEncounter(t, newAttackingRogue("Rogue"), 120, iterations, boss, add)
+
+	pulses := targetActionMetrics(result, 0, pulseID).Casts
+	bolts := targetActionMetrics(result, 0, boltID).Casts
+	bites := targetActionMetrics(result, 1, biteID).Casts
+
+	// The boss is pushed below 50% well before the end of the fight, which stops the
+	// pulses and starts the bolts.
+	if pulses <= 0 || pulses >= 23*iterations {
+		t.Fatalf("expected pulses to stop after the first phase, got %d", pulses)
+	}
+	if bolts != 3*iterations {
+		t.Fatalf("expected 3 bolts per iteration, got %d", bolts)
+	}
+	// The add only acts once it has been spawned.
+	if bites <= 0 || bites >= 60*iterations {
+		t.Fatalf("expected the add to act after spawning, got %d bites", bites)
+	}
+}
+
+// Sims a boss whose phases start at health thresholds, each but the first with another
+// threshold within the phase that casts a burst once.
+func TestScriptedEncounterHealthEvents(t *testing.T) {
+	const (
+		bossID    = 990220
+		tickID    = 990230
+		burstID   = 990240
+		numPhases = 3
+	)
+
+	boss := newScriptedTarget(bossID, "Scripted Health Boss")
+	boss.Stats[stats.Health] = 50_000
+
+	script := &proto.TargetScript{Target: boss}
+	startHealth := []float64{0, 80, 40}
+	eventHealth := []float64{0, 70, 30}
+	for phaseIdx := 0; phaseIdx < numPhases; phaseIdx++ {
+		tick := fmt.Sprintf("Tick %d", phaseIdx)
+		script.Abilities = append(script.Abilities, &proto.TargetScriptAbility{Name: tick, SpellId: tickID + int32(phaseIdx), Target: proto.TargetScriptAbility_AllRaidMembers, MinDamage: 100})
+		phase := &proto.TargetScriptPhase{
+			Name:               fmt.Sprintf("Phase %d", phaseIdx+1),
+			StartHealthPercent: startHealth[phaseIdx],
+			Events: []*proto.TargetScriptEvent{
+				{Interval: 2, Action: &proto.TargetScriptEvent_Cast{Cast: tick}},
+			},
+		}
+		if eventHealth[phaseIdx] > 0 {
+			burst := fmt.Sprintf("Burst %d", phaseIdx)
+			script.Abilities = append(script.Abilities, &proto.TargetScriptAbility{Name: burst, SpellId: burstID + int32(phaseIdx), Target: proto.TargetScriptAbility_AllRaidMembers, MinDamage: 100})
+			phase.Events = append(phase.Events, &proto.TargetScriptEvent{HealthPercent: eventHealth[phaseIdx], Action: &proto.TargetScriptEvent_Cast{Cast: burst}})
+		}
+		script.Phases = append(script.Phases, phase)
+	}
+	registerScriptedHealthEvents.Do(func() {
+		RegisterEncounterScript(&proto.EncounterScript{
+			Name:    "Scripted Health Events",
+			Targets: []*proto.TargetScript{script},
+		})
+	})
+
+	const iterations = 10
+	result := runScriptedEncounter(t, newAttackingRogue("Rogue"), 180, iterations, boss)
+
+	for phaseIdx := 0; phaseIdx < numPhases; phaseIdx++ {
+		if ticks := targetActionMetrics(result, 0, tickID+int32(phaseIdx)).Casts; ticks <= 0 {
+			t.Fatalf("expected phase %d to start and cast its ticks, got %d", phaseIdx+1, ticks)
+		}
+		if eventHealth[phaseIdx] > 0 {
+			if bursts := targetActionMetrics(result, 0, burstID+int32(phaseIdx)).Casts; bursts != iterations {
+				t.Fatalf("expected phase %d to cast its burst once per iteration, got %d", phaseIdx+1, bursts)
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 func (hunter *Hunter) registerExplosiveTrapSpell(timer *core.Timer) {
 	bonusPeriodicDamageMultiplier := .10 * float64(hunter.Talents.TrapMastery)
+	var trapPosition core.Vector2 // The trap is launched at the target's position when cast.
 
 	hunter.ExplosiveTrap = hunter.RegisterSpell(core.SpellConfig{
 		ActionID:       core.ActionID{SpellID: 13812},
@@ -44,7 +45,7 @@ func (hunter *Hunter) registerExplosiveTrapSpell(timer *core.Timer) {
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
 				baseDamage := 292 + 0.546*dot.Spell.RangedAttackPower(target)
 				dot.Spell.DamageMultiplierAdditive += bonusPeriodicDamageMultiplier
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range sim.Encounter.TargetUnitsInRange(trapPosition, 10) {
 					dot.Spell.CalcAndDealPeriodicDamage(sim, aoeTarget, baseDamage/10, dot.Spell.OutcomeRangedHitAndCritNoBlock)
 				}
 				dot.Spell.DamageMultiplierAdditive -= bonusPeriodicDamageMultiplier
@@ -52,6 +53,7 @@ func (hunter *Hunter) registerExplosiveTrapSpell(timer *core.Timer) {
 		},
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			trapPosition = target.Position
 			if sim.CurrentTime < 0 {
 				// Traps only last 60s.
 				if sim.CurrentTime < -time.Second*60 {
@@ -63,18 +65,20 @@ func (hunter *Hunter) registerExplosiveTrapSpell(timer *core.Timer) {
 				core.StartDelayedAction(sim, core.DelayedActionOptions{
 					DoAt: 0,
 					OnAction: func(sim *core.Simulation) {
-						for _, aoeTarget := range sim.Encounter.TargetUnits {
+						targets := sim.Encounter.TargetUnitsInRange(trapPosition, 10)
+						for _, aoeTarget := range targets {
 							baseDamage := 292 + (0.0546 * spell.RangedAttackPower(aoeTarget))
-							baseDamage *= sim.Encounter.AOECapMultiplier()
+							baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 							spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeRangedHitAndCritNoBlock)
 						}
 						hunter.ExplosiveTrap.AOEDot().Apply(sim)
 					},
 				})
 			} else {
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				targets := sim.Encounter.TargetUnitsInRange(trapPosition, 10)
+				for _, aoeTarget := range targets {
 					baseDamage := 292 + (0.0546 * spell.RangedAttackPower(aoeTarget))
-					baseDamage *= sim.Encounter.AOECapMultiplier()
+					baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 					spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeRangedHitAndCritNoBlock)
 				}
 				hunter.ExplosiveTrap.AOEDot().Apply(sim)
@@ -163,6 +163,7 @@ func (hunter *Hunter) applyPiercingShots() {
 		SpellSchool: core.SpellSchoolPhysical,
 		ProcMask:    core.ProcMaskEmpty,
 		Flags:       core.SpellFlagNoOnCastComplete | core.SpellFlagIgnoreModifiers,
+		Mechanic:    core.MechanicBleed,
 
 		DamageMultiplier: 1,
 		ThreatMultiplier: 1,
@@ -35,28 +35,26 @@ func (hunter *Hunter) registerMultiShotSpell() {
 		BonusCoefficient: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			numHits := hunter.Env.GetNumTargets() // Multi is uncapped in Cata
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 8) // Multi is uncapped in Cata
 
 			sharedDmg := hunter.AutoAttacks.Ranged().BaseDamage(sim)
 
-			baseDamageArray := make([]*core.SpellResult, numHits)
-			for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
-				currentTarget := hunter.Env.GetTargetUnit(hitIndex)
+			baseDamageArray := make([]*core.SpellResult, len(targets))
+			for hitIndex, currentTarget := range targets {
 				baseDamage := sharedDmg + 0.2*spell.RangedAttackPower(currentTarget)
 				baseDamageArray[hitIndex] = spell.CalcDamage(sim, currentTarget, baseDamage, spell.OutcomeRangedHitAndCrit)
 
 			}
 			spell.WaitTravelTime(sim, func(sim *core.Simulation) {
-				curTarget := target
-				for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
-					spell.DealDamage(sim, baseDamageArray[hitIndex])
+				for _, result := range baseDamageArray {
+					spell.DealDamage(sim, result)
 					if hunter.Talents.SerpentSpread > 0 {
+						curTarget := result.Target
 						ss := hunter.SerpentSting.Dot(curTarget)
 						ss.NumberOfTicks = (3 + (hunter.Talents.SerpentSpread * 3)) / 2
 						ss.Apply(sim)
 						hunter.ImprovedSerpentSting.Cast(sim, curTarget)
 					}
-					curTarget = sim.Environment.NextTargetUnit(curTarget)
 				}
 			})
 
@@ -0,0 +1,175 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+	"github.com/wowsims/cata/sim/core/stats"
+	"github.com/wowsims/cata/sim/encounters"
+)
+
+const (
+	interruptTestBossID = 990750
+	interruptTestBoltID = 990760
+	// Bolts are started every 4 seconds from 1 second on, and all of them would
+	// finish within the fight.
+	interruptTestBolts = 15
+)
+
+var interruptTestBoss = func() *proto.Target {
+	boss := &proto.Target{
+		Id:      interruptTestBossID,
+		Name:    "Interrupt Test Boss",
+		Level:   88,
+		MobType: proto.MobType_MobTypeHumanoid,
+		Stats:   stats.Stats{stats.Armor: 11977, stats.Health: 1_000_000_000}.ToFloatArray(),
+	}
+	encounters.RegisterEncounterScript(&proto.EncounterScript{
+		Name:       "Class Interrupt Test",
+		PathPrefix: "Test",
+		Targets: []*proto.TargetScript{
+			{
+				Target: boss,
+				Abilities: []*proto.TargetScriptAbility{
+					{Name: "Bolt", SpellId: interruptTestBoltID, School: proto.SpellSchool_SpellSchoolShadow, Target: proto.TargetScriptAbility_AllRaidMembers, MinDamage: 1000, CastTime: 2, Interruptible: true},
+				},
+				Phases: []*proto.TargetScriptPhase{
+					{
+						Events: []*proto.TargetScriptEvent{
+							{Delay: 1, Interval: 4, Action: &proto.TargetScriptEvent_Cast{Cast: "Bolt"}},
+						},
+					},
+				},
+			},
+		},
+	})
+	return boss
+}()
+
+// Sims the player against a boss casting interruptible bolts, with an APL which
+// only casts the given spell.
+func runInterruptTest(t *testing.T, player *proto.Player, spellID int32) *proto.RaidSimResult {
+	rotation := &proto.APLRotation{
+		Type: proto.APLRotation_TypeAPL,
+		PriorityList: []*proto.APLListItem{
+			{
+				Action: &proto.APLAction{
+					Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{
+						SpellId: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: spellID}},
+					}},
+				},
+			},
+		},
+	}
+	player.Name = "Interrupter"
+	player.Equipment = &proto.EquipmentSpec{}
+	player.Rotation = rotation
+
+	result := core.RunRaidSim(&proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(player, &proto.PartyBuffs{}, &proto.RaidBuffs{}, &proto.Debuffs{}),
+		Encounter: &proto.Encounter{
+			Duration: 60,
+			Targets:  []*proto.Target{interruptTestBoss},
+		},
+		SimOptions: &proto.SimOptions{
+			Iterations: 5,
+			IsTest:     true,
+		},
+	})
+	if result.ErrorResult != "" {
+		t.Fatalf("sim failed: %s", result.ErrorResult)
+	}
+	return result
+}
+
+// Returns the interrupts done with the spell, and checks that they match the
+// bolts which never finished.
+func checkInterrupts(t *testing.T, result *proto.RaidSimResult, spellID int32) int32 {
+	player := result.RaidMetrics.Parties[0].Players[0]
+	interrupts := int32(0)
+	for _, action := range player.Actions {
+		if action.Id.GetSpellId() != spellID {
+			continue
+		}
+		for _, target := range action.Targets {
+			interrupts += target.Interrupts
+		}
+	}
+
+	bolts := int32(0)
+	for _, action := range result.EncounterMetrics.Targets[0].Actions {
+		if action.Id.GetSpellId() != interruptTestBoltID {
+			continue
+		}
+		for _, target := range action.Targets {
+			bolts += target.Casts
+		}
+	}
+	iterations := int32(5)
+	if interrupts == 0 || interrupts+bolts != interruptTestBolts*iterations {
+		t.Fatalf("expected the %d interrupts and %d finished bolts to add up to %d bolts", interrupts, bolts, interruptTestBolts*iterations)
+	}
+	return interrupts
+}
+
+func auraProcs(result *proto.RaidSimResult, spellID int32) float64 {
+	for _, aura := range result.RaidMetrics.Parties[0].Players[0].Auras {
+		if aura.Id.GetSpellId() == spellID {
+			return aura.ProcsAvg
+		}
+	}
+	return 0
+}
+
+func TestKickInterrupts(t *testing.T) {
+	newRogue := func(glyphs *proto.Glyphs) *proto.Player {
+		return &proto.Player{
+			Race:   proto.Race_RaceHuman,
+			Class:  proto.Class_ClassRogue,
+			Glyphs: glyphs,
+			Spec: &proto.Player_CombatRogue{
+				CombatRogue: &proto.CombatRogue{
+					Options: &proto.CombatRogue_Options{ClassOptions: &proto.RogueOptions{}},
+				},
+			},
+		}
+	}
+
+	interrupts := checkInterrupts(t, runInterruptTest(t, newRogue(nil), 1766), 1766)
+	// Every successful Kick takes 6 seconds off the 14 second glyphed cooldown,
+	// which is 2 seconds shorter than the default.
+	glyphedInterrupts := checkInterrupts(t, runInterruptTest(t, newRogue(&proto.Glyphs{Major1: int32(proto.RogueMajorGlyph_GlyphOfKick)}), 1766), 1766)
+	if glyphedInterrupts <= interrupts {
+		t.Fatalf("expected more interrupts with Glyph of Kick, got %d with and %d without", glyphedInterrupts, interrupts)
+	}
+}
+
+func TestCounterspellInvocation(t *testing.T) {
+	newMage := func(talents string) *proto.Player {
+		return &proto.Player{
+			Race:               proto.Race_RaceHuman,
+			Class:              proto.Class_ClassMage,
+			TalentsString:      talents,
+			DistanceFromTarget: 20,
+			Spec: &proto.Player_ArcaneMage{
+				ArcaneMage: &proto.ArcaneMage{
+					Options: &proto.ArcaneMage_Options{ClassOptions: &proto.MageOptions{}},
+				},
+			},
+		}
+	}
+
+	result := runInterruptTest(t, newMage(""), 2139)
+	checkInterrupts(t, result, 2139)
+	if procs := auraProcs(result, 87098); procs != 0 {
+		t.Fatalf("expected no Invocation without the talent, got %0.1f procs", procs)
+	}
+
+	// Invocation is gained on every successful Counterspell.
+	result = runInterruptTest(t, newMage("00002"), 2139)
+	interrupts := checkInterrupts(t, result, 2139)
+	if procs := auraProcs(result, 87098); procs*5 != float64(interrupts) {
+		t.Fatalf("expected an Invocation proc for each of the %d interrupts, got %0.1f procs per iteration", interrupts, procs)
+	}
+}
@@ -5,6 +5,7 @@ import "C"
 import (
 	"bytes"
 	"compress/zlib"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"log"
@@ -36,7 +37,7 @@ func runSim(json *C.char) *C.char {
 		log.Fatalf("failed to load input json file: %s", err)
 	}
 	sim.RegisterAll()
-	result := core.RunSim(input, nil, nil)
+	result := core.RunSim(context.Background(), input, nil)
 	out, err := protojson.Marshal(result)
 	if err != nil {
 		panic(err)
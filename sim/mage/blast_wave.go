@@ -11,6 +11,9 @@ func (mage *Mage) registerBlastWaveSpell() {
 		return
 	}
 
+	// The Flamestrike from Improved Flamestrike stays on the ground where it was cast.
+	var flamestrikeCenter core.Vector2
+
 	mage.RegisterSpell(core.SpellConfig{
 		ActionID:       core.ActionID{SpellID: 11113},
 		SpellSchool:    core.SpellSchoolFire,
@@ -37,10 +40,11 @@ func (mage *Mage) registerBlastWaveSpell() {
 		ThreatMultiplier:         1,
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			var targetCount int32
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 8)
+			for _, aoeTarget := range targets {
 				targetCount++
 				baseDamage := sim.Roll(1047, 1233)
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 			if targetCount > 1 {
@@ -82,7 +86,7 @@ func (mage *Mage) registerBlastWaveSpell() {
 				dot.Snapshot(target, baseDamage)
 			},
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range sim.Encounter.TargetUnitsInRange(flamestrikeCenter, 8) {
 					dot.CalcAndDealPeriodicSnapshotDamage(sim, aoeTarget, dot.OutcomeSnapshotCrit)
 				}
 			},
@@ -90,9 +94,11 @@ func (mage *Mage) registerBlastWaveSpell() {
 		},
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			flamestrikeCenter = target.Position
+			targets := sim.Encounter.TargetUnitsInRange(flamestrikeCenter, 8)
+			for _, aoeTarget := range targets {
 				baseDamage := 0.662 * mage.ClassSpellScaling
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 			spell.AOEDot().Apply(sim)
@@ -38,9 +38,10 @@ func (mage *Mage) registerBlizzardSpell() {
 		BonusCoefficient: 0.162,
 		ThreatMultiplier: 1,
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 8)
 			damage := 0.542 * mage.ClassSpellScaling
-			damage *= sim.Encounter.AOECapMultiplier()
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			damage *= core.AOECapMultiplierForTargets(len(targets))
+			for _, aoeTarget := range targets {
 				spell.CalcAndDealDamage(sim, aoeTarget, damage, spell.OutcomeMagicHitAndCrit)
 				if iceShardsProcApplication != nil {
 					iceShardsProcApplication.Cast(sim, aoeTarget)
@@ -0,0 +1,39 @@
+package mage
+
+import (
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+)
+
+func (mage *Mage) registerCounterspell() {
+	mage.Counterspell = mage.RegisterSpell(core.SpellConfig{
+		ActionID:       core.ActionID{SpellID: 2139},
+		SpellSchool:    core.SpellSchoolArcane,
+		ProcMask:       core.ProcMaskEmpty,
+		Flags:          core.SpellFlagAPL,
+		ClassSpellMask: MageSpellCounterspell,
+		MaxRange:       40,
+
+		ManaCost: core.ManaCostOptions{
+			BaseCost: 0.09,
+		},
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    mage.NewTimer(),
+				Duration: time.Second * 24,
+			},
+		},
+		ExtraCastCondition: func(sim *core.Simulation, target *core.Unit) bool {
+			return target.IsCastingInterruptible(sim)
+		},
+
+		ThreatMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			if spell.Interrupt(sim, target) && mage.invocationAura != nil {
+				mage.invocationAura.Activate(sim)
+			}
+		},
+	})
+}
@@ -34,9 +34,10 @@ func (mage *Mage) registerDragonsBreathSpell() {
 		BonusCoefficient:         0.193,
 		ThreatMultiplier:         1,
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := mage.TargetUnitsAround(12)
+			for _, aoeTarget := range targets {
 				baseDamage := 1.378 * mage.ClassSpellScaling
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 		},
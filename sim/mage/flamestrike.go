@@ -7,6 +7,8 @@ import (
 )
 
 func (mage *Mage) registerFlamestrikeSpell() {
+	// Flamestrike stays on the ground where it was cast.
+	var center core.Vector2
 
 	mage.Flamestrike = mage.RegisterSpell(core.SpellConfig{
 		ActionID:       core.ActionID{SpellID: 2120},
@@ -43,7 +45,7 @@ func (mage *Mage) registerFlamestrikeSpell() {
 				dot.Snapshot(target, baseDamage)
 			},
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range sim.Encounter.TargetUnitsInRange(center, 8) {
 					dot.CalcAndDealPeriodicSnapshotDamage(sim, aoeTarget, dot.OutcomeSnapshotCrit)
 				}
 			},
@@ -51,9 +53,11 @@ func (mage *Mage) registerFlamestrikeSpell() {
 		},
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			center = target.Position
+			targets := sim.Encounter.TargetUnitsInRange(center, 8)
+			for _, aoeTarget := range targets {
 				baseDamage := 0.662 * mage.ClassSpellScaling
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 			spell.AOEDot().Apply(sim)
@@ -31,9 +31,10 @@ func (mage *Mage) registerFreezeSpell() {
 		ThreatMultiplier:         1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 10)
+			for _, aoeTarget := range targets {
 				baseDamage := 0.409 * mage.ClassSpellScaling
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 
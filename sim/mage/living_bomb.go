@@ -37,9 +37,10 @@ func (mage *Mage) registerLivingBombSpell() {
 		ThreatMultiplier:         1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 10)
 			baseDamage := 0.5 * mage.ClassSpellScaling
-			baseDamage *= sim.Encounter.AOECapMultiplier()
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			baseDamage *= core.AOECapMultiplierForTargets(len(targets))
+			for _, aoeTarget := range targets {
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 		},
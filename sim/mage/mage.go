@@ -42,10 +42,12 @@ type Mage struct {
 	PyroblastImpact      *core.Spell
 	SummonWaterElemental *core.Spell
 	IcyVeins             *core.Spell
+	Counterspell         *core.Spell
 
 	arcaneMissilesProcAura *core.Aura
 	arcanePotencyAura      *core.Aura
 	FingersOfFrostAura     *core.Aura
+	invocationAura         *core.Aura
 
 	ClassSpellScaling float64
 }
@@ -112,6 +114,7 @@ func (mage *Mage) Initialize() {
 	mage.registerCombustionSpell()
 	mage.registerBlastWaveSpell()
 	mage.registerDragonsBreathSpell()
+	mage.registerCounterspell()
 	// mage.registerSummonWaterElementalCD()
 
 	mage.applyArcaneMissileProc()
@@ -318,6 +321,7 @@ const (
 	MageSpellMageArmor
 	MageSpellCombustion
 	MageSpellCombustionApplication
+	MageSpellCounterspell
 	MageSpellLast
 	MageSpellsAll        = MageSpellLast<<1 - 1
 	MageSpellLivingBomb  = MageSpellLivingBombDot | MageSpellLivingBombExplosion
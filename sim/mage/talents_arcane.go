@@ -16,6 +16,7 @@ func (mage *Mage) ApplyArcaneTalents() {
 	mage.applyArcanePotency()
 	mage.applyFocusMagic()
 	mage.registerArcanePowerCD()
+	mage.applyInvocation()
 
 	// Netherwind Presence
 	if mage.Talents.NetherwindPresence > 0 {
@@ -233,6 +234,31 @@ func (mage *Mage) registerPresenceOfMindCD() {
 	})
 }
 
+func (mage *Mage) applyInvocation() {
+	if mage.Talents.Invocation == 0 {
+		return
+	}
+
+	invocationMod := mage.AddDynamicMod(core.SpellModConfig{
+		ClassMask:  MageSpellsAllDamaging,
+		FloatValue: 0.05 * float64(mage.Talents.Invocation),
+		Kind:       core.SpellMod_DamageDone_Pct,
+	})
+
+	// Triggered by successful Counterspells.
+	mage.invocationAura = mage.RegisterAura(core.Aura{
+		Label:    "Invocation",
+		ActionID: core.ActionID{SpellID: 87098},
+		Duration: time.Second * 8,
+		OnGain: func(aura *core.Aura, sim *core.Simulation) {
+			invocationMod.Activate()
+		},
+		OnExpire: func(aura *core.Aura, sim *core.Simulation) {
+			invocationMod.Deactivate()
+		},
+	})
+}
+
 func (mage *Mage) applyArcanePotency() {
 	if mage.Talents.ArcanePotency == 0 {
 		return
@@ -42,15 +42,15 @@ func (paladin *Paladin) registerDivineStorm() {
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			numHits := 0
-			for idx := range results {
+			targets := paladin.TargetUnitsAround(8)
+			for idx, aoeTarget := range targets {
 				baseDamage := spell.Unit.MHWeaponDamage(sim, spell.MeleeAttackPower())
-				results[idx] = spell.CalcDamage(sim, target, baseDamage, spell.OutcomeMeleeSpecialHitAndCrit)
+				results[idx] = spell.CalcDamage(sim, aoeTarget, baseDamage, spell.OutcomeMeleeSpecialHitAndCrit)
 				if results[idx].Landed() {
 					numHits += 1
 				}
-				target = sim.Environment.NextTargetUnit(target)
 			}
-			for _, result := range results {
+			for _, result := range results[:len(targets)] {
 				spell.DealDamage(sim, result)
 			}
 			if numHits >= 4 {
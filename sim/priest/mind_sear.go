@@ -53,7 +53,7 @@ func (priest *Priest) newMindSearSpell() *core.Spell {
 		TickLength:          time.Second,
 		AffectedByCastSpeed: true,
 		OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			for _, aoeTarget := range sim.Encounter.TargetUnitsInRange(target.Position, 10) {
 				mindSearTickSpell.Cast(sim, aoeTarget)
 				mindSearTickSpell.SpellMetrics[target.UnitIndex].Casts -= 1
 			}
@@ -55,8 +55,12 @@ func (comRogue *CombatRogue) registerBladeFlurry() {
 			// Undo armor reduction to get the raw damage value.
 			curDmg = result.Damage / result.ResistanceMultiplier
 
-			bfTarget := comRogue.Env.NextTargetUnit(result.Target)
-			bfHit.Cast(sim, bfTarget)
+			// Strikes one other opponent in melee range.
+			bfTargets := sim.Environment.CleaveTargetUnits(result.Target, comRogue.Position, core.MaxMeleeRange, 2)
+			if len(bfTargets) < 2 {
+				return
+			}
+			bfHit.Cast(sim, bfTargets[1])
 		},
 	})
 
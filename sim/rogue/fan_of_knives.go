@@ -43,13 +43,14 @@ func (rogue *Rogue) registerFanOfKnives() {
 
 		ApplyEffects: func(sim *core.Simulation, unit *core.Unit, spell *core.Spell) {
 			rogue.BreakStealth(sim)
-			for i, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := rogue.TargetUnitsAround(10)
+			for i, aoeTarget := range targets {
 				baseDamage := fokSpell.Unit.RangedWeaponDamage(sim, fokSpell.RangedAttackPower(aoeTarget))
-				baseDamage *= sim.Encounter.AOECapMultiplier()
+				baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 
 				results[i] = fokSpell.CalcDamage(sim, aoeTarget, baseDamage, fokSpell.OutcomeRangedHitAndCrit)
 			}
-			for i, aoeTarget := range sim.Encounter.TargetUnits {
+			for i, aoeTarget := range targets {
 				fokSpell.DealDamage(sim, results[i])
 
 				if rogue.Talents.VilePoisons > 0 {
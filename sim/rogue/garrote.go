@@ -14,6 +14,7 @@ func (rogue *Rogue) registerGarrote() {
 		SpellSchool:    core.SpellSchoolPhysical,
 		ProcMask:       core.ProcMaskMeleeMHSpecial,
 		Flags:          core.SpellFlagMeleeMetrics | SpellFlagBuilder | core.SpellFlagAPL,
+		Mechanic:       core.MechanicBleed,
 		ClassSpellMask: RogueSpellGarrote,
 
 		EnergyCost: core.EnergyCostOptions{
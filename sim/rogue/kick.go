@@ -0,0 +1,41 @@
+package rogue
+
+import (
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
+)
+
+func (rogue *Rogue) registerKickSpell() {
+	// Glyph of Kick adds 4 seconds to the cooldown, but a successful interrupt
+	// takes 6 seconds off again.
+	hasGlyph := rogue.HasMajorGlyph(proto.RogueMajorGlyph_GlyphOfKick)
+
+	rogue.Kick = rogue.RegisterSpell(core.SpellConfig{
+		ActionID:       core.ActionID{SpellID: 1766},
+		SpellSchool:    core.SpellSchoolPhysical,
+		ProcMask:       core.ProcMaskEmpty,
+		Flags:          core.SpellFlagMeleeMetrics | core.SpellFlagAPL,
+		ClassSpellMask: RogueSpellKick,
+		MaxRange:       core.MaxMeleeRange,
+
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    rogue.NewTimer(),
+				Duration: core.TernaryDuration(hasGlyph, time.Second*14, time.Second*10),
+			},
+		},
+		ExtraCastCondition: func(sim *core.Simulation, target *core.Unit) bool {
+			return target.IsCastingInterruptible(sim)
+		},
+
+		ThreatMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			if spell.Interrupt(sim, target) && hasGlyph {
+				spell.CD.Reduce(time.Second * 6)
+			}
+		},
+	})
+}
@@ -65,6 +65,7 @@ type Rogue struct {
 	KillingSpree     *core.Spell
 	AdrenalineRush   *core.Spell
 	Gouge            *core.Spell
+	Kick             *core.Spell
 
 	Envenom      *core.Spell
 	Eviscerate   *core.Spell
@@ -175,6 +176,7 @@ func (rogue *Rogue) Initialize() {
 	rogue.registerShivSpell()
 	rogue.registerThistleTeaCD()
 	rogue.registerGougeSpell()
+	rogue.registerKickSpell()
 
 	rogue.SliceAndDiceBonus = 0.4
 }
@@ -331,6 +333,7 @@ const (
 	RogueSpellWoundPoison
 	RogueSpellInstantPoison
 	RogueSpellDeadlyPoison
+	RogueSpellKick
 
 	RogueSpellLast
 	RogueSpellsAll = RogueSpellLast<<1 - 1
@@ -24,6 +24,7 @@ func (rogue *Rogue) registerRupture() {
 		SpellSchool:    core.SpellSchoolPhysical,
 		ProcMask:       core.ProcMaskMeleeMHSpecial,
 		Flags:          core.SpellFlagMeleeMetrics | SpellFlagFinisher | core.SpellFlagAPL,
+		Mechanic:       core.MechanicBleed,
 		MetricSplits:   6,
 		ClassSpellMask: RogueSpellRupture,
 
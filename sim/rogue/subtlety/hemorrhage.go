@@ -25,6 +25,7 @@ func (subRogue *SubtletyRogue) registerHemorrhageSpell() {
 		SpellSchool: core.SpellSchoolPhysical,
 		ProcMask:    core.ProcMaskMeleeMHSpecial,
 		Flags:       core.SpellFlagIgnoreAttackerModifiers, // From initial testing, Hemo DoT only benefits from debuffs on target, such as 30% bleed damage
+		Mechanic:    core.MechanicBleed,
 
 		ThreatMultiplier: 1,
 		CritMultiplier:   subRogue.MeleeCritMultiplier(false), // Per WoWHead data, Lethality does not boost the DoT directly,
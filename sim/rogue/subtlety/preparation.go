@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/wowsims/cata/sim/core"
+	"github.com/wowsims/cata/sim/core/proto"
 	"github.com/wowsims/cata/sim/rogue"
 )
 
@@ -31,6 +32,9 @@ func (subRogue *SubtletyRogue) registerPreparationCD() {
 			// If Glyph of Preparation is applied, Smoke Bomb, Dismantle, and Kick are also affected
 			subRogue.Shadowstep.CD.Reset()
 			subRogue.Vanish.CD.Reset()
+			if subRogue.HasMajorGlyph(proto.RogueMajorGlyph_GlyphOfPreparation) {
+				subRogue.Kick.CD.Reset()
+			}
 		},
 	})
 
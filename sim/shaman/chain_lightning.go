@@ -28,28 +28,27 @@ func (shaman *Shaman) newChainLightningSpell(isElementalOverload bool) *core.Spe
 	}
 
 	baseDamage := shaman.ClassSpellScaling * 1.08800005913
-	numHits := int32(3)
+	maxHits := int32(3)
 	if shaman.HasMajorGlyph(proto.ShamanMajorGlyph_GlyphOfChainLightning) {
 		spellConfig.DamageMultiplier *= 0.90
-		numHits += 2
+		maxHits += 2
 	}
-	numHits = min(numHits, shaman.Env.GetNumTargets())
 
 	spellConfig.ApplyEffects = func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 		bounceReduction := 0.7
-		curTarget := target
+		// Jumps to other enemies within 10 yards of the target.
+		targets := sim.Environment.CleaveTargetUnits(target, target.Position, 10, maxHits)
 
 		// Damage calculation and DealDamage are in separate loops so that e.g. a spell power proc
 		// can't proc on the first target and apply to the second
-		results := make([]*core.SpellResult, numHits)
-		for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
+		results := make([]*core.SpellResult, len(targets))
+		for hitIndex, curTarget := range targets {
 			results[hitIndex] = shaman.calcDamageStormstrikeCritChance(sim, curTarget, baseDamage, spell)
 
-			curTarget = sim.Environment.NextTargetUnit(curTarget)
 			spell.DamageMultiplier *= bounceReduction
 		}
 
-		for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
+		for hitIndex := range results {
 			if !isElementalOverload && results[hitIndex].Landed() && sim.Proc(shaman.GetOverloadChance()/3, "Chain Lightning Elemental Overload") {
 				shaman.ChainLightningOverloads[hitIndex].Cast(sim, results[hitIndex].Target)
 			}
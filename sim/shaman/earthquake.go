@@ -7,6 +7,9 @@ import (
 )
 
 func (shaman *Shaman) registerEarthquakeSpell() {
+	// Earthquake stays on the ground where it was cast.
+	var center core.Vector2
+
 	shaman.Earthquake = shaman.RegisterSpell(core.SpellConfig{
 		ActionID:         core.ActionID{SpellID: 77478},
 		Flags:            core.SpellFlagAPL | SpellFlagFocusable,
@@ -40,13 +43,14 @@ func (shaman *Shaman) registerEarthquakeSpell() {
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
 				// Coefficient damage calculated manually because it's a Nature spell but deals Physical damage
 				baseDamage := shaman.ClassSpellScaling*0.32400000095 + 0.11*dot.Spell.SpellPower()
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range sim.Encounter.TargetUnitsInRange(center, 8) {
 					dot.Spell.CalcAndDealPeriodicDamage(sim, aoeTarget, baseDamage, dot.Spell.OutcomeMagicHitAndCrit)
 				}
 			},
 		},
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			center = target.Position
 			dot := spell.AOEDot()
 			dot.Apply(sim)
 		},
@@ -46,12 +46,13 @@ func (elemental *ElementalShaman) registerThunderstormSpell() {
 			if elemental.Shaman.ThunderstormInRange {
 				results := make([]*core.SpellResult, elemental.Env.GetNumTargets())
 				baseDamage := elemental.GetShaman().ClassSpellScaling * 1.62999999523
-				aoeMult := sim.Encounter.AOECapMultiplier()
+				targets := elemental.TargetUnitsAround(10)
+				aoeMult := core.AOECapMultiplierForTargets(len(targets))
 				spell.DamageMultiplier *= aoeMult
-				for i, aoeTarget := range sim.Encounter.TargetUnits {
+				for i, aoeTarget := range targets {
 					results[i] = spell.CalcDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 				}
-				for i, _ := range sim.Encounter.TargetUnits {
+				for i, _ := range targets {
 					spell.DealDamage(sim, results[i])
 				}
 				spell.DamageMultiplier /= aoeMult
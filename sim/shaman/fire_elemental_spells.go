@@ -64,8 +64,9 @@ func (fireElemental *FireElemental) registerFireNova() {
 		BonusCoefficient: 1.00,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
-				baseDamage := sim.Roll(453, 537) * sim.Encounter.AOECapMultiplier() //Estimated from beta testing
+			targets := fireElemental.TargetUnitsAround(10)
+			for _, aoeTarget := range targets {
+				baseDamage := sim.Roll(453, 537) * core.AOECapMultiplierForTargets(len(targets)) //Estimated from beta testing
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
 		},
@@ -96,7 +97,7 @@ func (fireElemental *FireElemental) registerFireShieldAura() {
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
 				// TODO is this the right affect should it be Capped?
 				// TODO these are approximation, from base SP
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range fireElemental.TargetUnitsAround(10) {
 					//baseDamage *= sim.Encounter.AOECapMultiplier()
 					dot.Spell.CalcAndDealDamage(sim, aoeTarget, 102, dot.Spell.OutcomeMagicHitAndCrit) //Estimated from beta testing
 				}
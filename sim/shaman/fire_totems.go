@@ -82,12 +82,13 @@ func (shaman *Shaman) registerMagmaTotemSpell() {
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
 				results := make([]*core.SpellResult, shaman.Env.GetNumTargets())
 				baseDamage := shaman.ClassSpellScaling * 0.26699998975
-				aoeMult := sim.Encounter.AOECapMultiplier()
+				targets := shaman.TargetUnitsAround(8)
+				aoeMult := core.AOECapMultiplierForTargets(len(targets))
 				dot.Spell.DamageMultiplier *= aoeMult
-				for i, aoeTarget := range sim.Encounter.TargetUnits {
+				for i, aoeTarget := range targets {
 					results[i] = dot.Spell.CalcDamage(sim, aoeTarget, baseDamage, dot.Spell.OutcomeMagicHitAndCrit)
 				}
-				for i, _ := range sim.Encounter.TargetUnits {
+				for i, _ := range targets {
 					dot.Spell.DealDamage(sim, results[i])
 				}
 				dot.Spell.DamageMultiplier /= aoeMult
@@ -34,28 +34,24 @@ func (shaman *Shaman) registerFireNovaSpell() {
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			results := make([][]*core.SpellResult, shaman.Env.GetNumTargets())
 			baseDamage := shaman.ClassSpellScaling * 0.78500002623
-			for i, aoeTarget := range sim.Encounter.TargetUnits {
+			for i, aoeTarget := range sim.Encounter.ActiveTargetUnits {
 				if shaman.FlameShockDot.Dot(aoeTarget).IsActive() {
-					results[i] = make([]*core.SpellResult, shaman.Env.GetNumTargets())
-					for j, newTarget := range sim.Encounter.TargetUnits {
+					// Each flame shocked target explodes, hitting the other enemies within 10 yards of it.
+					for _, newTarget := range sim.Encounter.TargetUnitsInRange(aoeTarget.Position, 10) {
 						if newTarget != aoeTarget {
-							results[i][j] = spell.CalcDamage(sim, newTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
+							results[i] = append(results[i], spell.CalcDamage(sim, newTarget, baseDamage, spell.OutcomeMagicHitAndCrit))
 						}
 					}
 				}
 			}
-			for i, aoeTarget := range sim.Encounter.TargetUnits {
-				if shaman.FlameShockDot.Dot(aoeTarget).IsActive() {
-					for j, newTarget := range sim.Encounter.TargetUnits {
-						if newTarget != aoeTarget {
-							spell.DealDamage(sim, results[i][j])
-						}
-					}
+			for _, novaResults := range results {
+				for _, result := range novaResults {
+					spell.DealDamage(sim, result)
 				}
 			}
 		},
 		ExtraCastCondition: func(sim *core.Simulation, target *core.Unit) bool {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			for _, aoeTarget := range sim.Encounter.ActiveTargetUnits {
 				if shaman.FlameShockDot.Dot(aoeTarget).IsActive() {
 					return true
 				}
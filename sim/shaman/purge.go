@@ -0,0 +1,34 @@
+package shaman
+
+import (
+	"github.com/wowsims/cata/sim/core"
+)
+
+func (shaman *Shaman) registerPurgeSpell() {
+	shaman.Purge = shaman.RegisterSpell(core.SpellConfig{
+		ActionID:       core.ActionID{SpellID: 370},
+		SpellSchool:    core.SpellSchoolNature,
+		ProcMask:       core.ProcMaskEmpty,
+		Flags:          core.SpellFlagAPL,
+		ClassSpellMask: SpellMaskPurge,
+		MaxRange:       30,
+
+		ManaCost: core.ManaCostOptions{
+			BaseCost: 0.16,
+		},
+		Cast: core.CastConfig{
+			DefaultCast: core.Cast{
+				GCD: core.GCDDefault,
+			},
+		},
+		ExtraCastCondition: func(sim *core.Simulation, target *core.Unit) bool {
+			return target.GetDispellableAura() != nil
+		},
+
+		ThreatMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			spell.Dispel(sim, target)
+		},
+	})
+}
@@ -127,6 +127,9 @@ type Shaman struct {
 	FlameShockDot *core.Spell
 	FrostShock    *core.Spell
 
+	WindShear *core.Spell
+	Purge     *core.Spell
+
 	FeralSpirit  *core.Spell
 	SpiritWolves *SpiritWolves
 
@@ -245,6 +248,8 @@ func (shaman *Shaman) Initialize() {
 	shaman.registerSearingTotemSpell()
 	shaman.registerShocks()
 	shaman.registerUnleashElements()
+	shaman.registerWindShearSpell()
+	shaman.registerPurgeSpell()
 
 	shaman.registerStrengthOfEarthTotemSpell()
 	shaman.registerFlametongueTotemSpell()
@@ -346,6 +351,8 @@ const (
 	SpellMaskUnleashFlame
 	SpellMaskEarthquake
 	SpellMaskFlametongueWeapon
+	SpellMaskWindShear
+	SpellMaskPurge
 
 	SpellMaskFlameShock = SpellMaskFlameShockDirect | SpellMaskFlameShockDot
 	SpellMaskFire       = SpellMaskFlameShock | SpellMaskLavaBurst | SpellMaskLavaBurstOverload | SpellMaskLavaLash | SpellMaskFireNova | SpellMaskUnleashFlame
@@ -661,7 +661,9 @@ func (shaman *Shaman) applySearingFlames() {
 			dot := shaman.SearingFlames.Dot(result.Target)
 
 			if shaman.Talents.SearingFlames == 3 || sim.RandomFloat("Searing Flames") < 0.33*float64(shaman.Talents.SearingFlames) {
-				dot.Aura.Activate(sim)
+				if !dot.Aura.Activate(sim) {
+					return
+				}
 				dot.Aura.AddStack(sim)
 
 				// recalc damage based on stacks, testing with searing totem seems to indicate the damage is updated dynamically on refesh
@@ -0,0 +1,37 @@
+package shaman
+
+import (
+	"time"
+
+	"github.com/wowsims/cata/sim/core"
+)
+
+func (shaman *Shaman) registerWindShearSpell() {
+	shaman.WindShear = shaman.RegisterSpell(core.SpellConfig{
+		ActionID:       core.ActionID{SpellID: 57994},
+		SpellSchool:    core.SpellSchoolNature,
+		ProcMask:       core.ProcMaskEmpty,
+		Flags:          core.SpellFlagAPL,
+		ClassSpellMask: SpellMaskWindShear,
+		MaxRange:       25,
+
+		ManaCost: core.ManaCostOptions{
+			BaseCost: 0.09,
+		},
+		Cast: core.CastConfig{
+			CD: core.Cooldown{
+				Timer:    shaman.NewTimer(),
+				Duration: time.Second * 15,
+			},
+		},
+		ExtraCastCondition: func(sim *core.Simulation, target *core.Unit) bool {
+			return target.IsCastingInterruptible(sim)
+		},
+
+		ThreatMultiplier: 1,
+
+		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			spell.Interrupt(sim, target)
+		},
+	})
+}
@@ -77,6 +77,7 @@ func (demonology *DemonologyWarlock) registerMetamorphosis() {
 		ProcMask:       core.ProcMaskEmpty,
 		Flags:          core.SpellFlagAPL,
 		ClassSpellMask: warlock.WarlockSpellImmolationAura,
+		MaxRange:       8,
 
 		ManaCost: core.ManaCostOptions{
 			BaseCost: 0.64,
@@ -107,9 +108,10 @@ func (demonology *DemonologyWarlock) registerMetamorphosis() {
 			TickLength:          1 * time.Second,
 			AffectedByCastSpeed: true,
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
-				baseDmg := demonology.CalcScalingSpellDmg(0.58899998665) * sim.Encounter.AOECapMultiplier()
+				targets := demonology.TargetUnitsAround(8)
+				baseDmg := demonology.CalcScalingSpellDmg(0.58899998665) * core.AOECapMultiplierForTargets(len(targets))
 
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range targets {
 					dot.Spell.CalcAndDealDamage(sim, aoeTarget, baseDmg, dot.Spell.OutcomeMagicHit)
 				}
 			},
@@ -42,8 +42,9 @@ func (warlock *Warlock) registerSummonInfernal(timer *core.Timer) {
 		BonusCoefficient: 0.76499998569,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
-				baseDamage := sim.Encounter.AOECapMultiplier() *
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 10)
+			for _, aoeTarget := range targets {
+				baseDamage := core.AOECapMultiplierForTargets(len(targets)) *
 					warlock.CalcAndRollDamageRange(sim, 0.48500001431, 0.11999999732)
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
 			}
@@ -147,9 +148,10 @@ func (infernal *InfernalPet) Initialize() {
 				// base formula is 25 + (lvl-50)*0.5 * Warlock_SP*0.2
 				// note this scales with the warlocks SP, NOT with the pets
 				warlockSP := infernal.owner.Unit.GetStat(stats.SpellPower)
-				baseDmg := (40 + warlockSP*0.2) * sim.Encounter.AOECapMultiplier()
+				targets := infernal.TargetUnitsAround(8)
+				baseDmg := (40 + warlockSP*0.2) * core.AOECapMultiplierForTargets(len(targets))
 
-				for _, aoeTarget := range sim.Encounter.TargetUnits {
+				for _, aoeTarget := range targets {
 					dot.Spell.CalcAndDealDamage(sim, aoeTarget, baseDmg, dot.Spell.OutcomeMagicHit)
 				}
 			},
@@ -22,8 +22,9 @@ func (warlock *Warlock) registerSeed() {
 		BonusCoefficient:         0.22920000553,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			baseDmg := warlock.CalcAndRollDamageRange(sim, 0.76560002565, 0.15000000596) * sim.Encounter.AOECapMultiplier()
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 15)
+			baseDmg := warlock.CalcAndRollDamageRange(sim, 0.76560002565, 0.15000000596) * core.AOECapMultiplierForTargets(len(targets))
+			for _, aoeTarget := range targets {
 				spell.CalcAndDealDamage(sim, aoeTarget, baseDmg, spell.OutcomeMagicHitAndCrit)
 			}
 		},
@@ -108,8 +108,9 @@ func (warlock *Warlock) registerShadowEmbrace() {
 		OnSpellHitDealt: func(aura *core.Aura, sim *core.Simulation, spell *core.Spell, result *core.SpellResult) {
 			if spell.Matches(WarlockSpellShadowBolt|WarlockSpellHaunt) && result.Landed() {
 				aura := warlock.ShadowEmbraceAuras.Get(result.Target)
-				aura.Activate(sim)
-				aura.AddStack(sim)
+				if aura.Activate(sim) {
+					aura.AddStack(sim)
+				}
 			}
 		},
 	}))
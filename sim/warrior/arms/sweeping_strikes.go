@@ -47,7 +47,12 @@ func (war *ArmsWarrior) RegisterSweepingStrikes() {
 			// Undo armor reduction to get the raw damage value.
 			curDmg /= result.ResistanceMultiplier
 
-			ssHit.Cast(sim, war.Env.NextTargetUnit(result.Target))
+			// Strikes one other opponent in melee range.
+			cleaveTargets := sim.Environment.CleaveTargetUnits(result.Target, war.Position, core.MaxMeleeRange, 2)
+			if len(cleaveTargets) < 2 {
+				return
+			}
+			ssHit.Cast(sim, cleaveTargets[1])
 			ssHit.SpellMetrics[result.Target.UnitIndex].Casts--
 		},
 	})
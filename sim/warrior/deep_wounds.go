@@ -16,6 +16,7 @@ func (warrior *Warrior) RegisterDeepWounds() {
 		SpellSchool: core.SpellSchoolPhysical,
 		ProcMask:    core.ProcMaskEmpty,
 		Flags:       core.SpellFlagNoOnCastComplete | core.SpellFlagIgnoreModifiers | SpellFlagBleed,
+		Mechanic:    core.MechanicBleed,
 
 		DamageMultiplier: 1,
 		ThreatMultiplier: 1,
@@ -7,9 +7,7 @@ import (
 )
 
 func (warrior *Warrior) RegisterHeroicLeap() {
-
-	numHits := warrior.Env.GetNumTargets()
-	results := make([]*core.SpellResult, numHits)
+	results := make([]*core.SpellResult, warrior.Env.GetNumTargets())
 
 	warrior.RegisterSpell(core.SpellConfig{
 		ActionID:       core.ActionID{SpellID: 6544},
@@ -39,15 +37,14 @@ func (warrior *Warrior) RegisterHeroicLeap() {
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			baseDamage := 1 + 0.5*spell.MeleeAttackPower()
-			curTarget := target
+			targets := sim.Encounter.TargetUnitsInRange(target.Position, 8)
 
-			for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
-				results[hitIndex] = spell.CalcDamage(sim, curTarget, baseDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
-				curTarget = sim.Environment.NextTargetUnit(curTarget)
+			for hitIndex, aoeTarget := range targets {
+				results[hitIndex] = spell.CalcDamage(sim, aoeTarget, baseDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
 			}
 
-			for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
-				spell.DealDamage(sim, results[hitIndex])
+			for _, result := range results[:len(targets)] {
+				spell.DealDamage(sim, result)
 			}
 
 		},
@@ -53,8 +53,7 @@ func (warrior *Warrior) RegisterHeroicStrikeSpell() {
 
 func (warrior *Warrior) RegisterCleaveSpell() {
 	targets := core.TernaryInt32(warrior.HasMajorGlyph(proto.WarriorMajorGlyph_GlyphOfCleaving), 3, 2)
-	numHits := min(targets, warrior.Env.GetNumTargets())
-	results := make([]*core.SpellResult, numHits)
+	results := make([]*core.SpellResult, min(targets, warrior.Env.GetNumTargets()))
 
 	warrior.Cleave = warrior.RegisterSpell(core.SpellConfig{
 		ActionID:       core.ActionID{SpellID: 845},
@@ -83,18 +82,14 @@ func (warrior *Warrior) RegisterCleaveSpell() {
 		CritMultiplier:   warrior.DefaultMeleeCritMultiplier(),
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-			curTarget := target
-			for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
+			cleaveTargets := sim.Environment.CleaveTargetUnits(target, warrior.Position, core.MaxMeleeRange, targets)
+			for hitIndex, curTarget := range cleaveTargets {
 				baseDamage := 6 + (spell.MeleeAttackPower() * 0.45)
 				results[hitIndex] = spell.CalcDamage(sim, curTarget, baseDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
-
-				curTarget = sim.Environment.NextTargetUnit(curTarget)
 			}
 
-			curTarget = target
-			for hitIndex := int32(0); hitIndex < numHits; hitIndex++ {
-				spell.DealDamage(sim, results[hitIndex])
-				curTarget = sim.Environment.NextTargetUnit(curTarget)
+			for _, result := range results[:len(cleaveTargets)] {
+				spell.DealDamage(sim, result)
 			}
 		},
 	})
@@ -15,6 +15,7 @@ func (warrior *Warrior) RegisterRendSpell() {
 		SpellSchool:    core.SpellSchoolPhysical,
 		ProcMask:       core.ProcMaskMeleeMHSpecial,
 		Flags:          core.SpellFlagAPL | core.SpellFlagMeleeMetrics | SpellFlagBleed,
+		Mechanic:       core.MechanicBleed,
 		ClassSpellMask: SpellMaskRend | SpellMaskSpecialAttack,
 
 		RageCost: core.RageCostOptions{
@@ -51,7 +51,7 @@ func (warrior *Warrior) makeStanceSpell(stance Stance, aura *core.Aura, stanceCD
 				//  Alternatively, those casts could just (artificially) happen before the stance change.
 				core.StartDelayedAction(sim, core.DelayedActionOptions{
 					DoAt:     sim.CurrentTime + 10*time.Millisecond,
-					OnAction: aura.Activate,
+					OnAction: func(sim *core.Simulation) { aura.Activate(sim) },
 				})
 			} else {
 				aura.Activate(sim)
@@ -9,7 +9,6 @@ func (warrior *Warrior) RegisterSunderArmor() *core.Spell {
 	warrior.SunderArmorAuras = warrior.NewEnemyAuraArray(core.SunderArmorAura)
 
 	hasGlyph := warrior.HasMajorGlyph(proto.WarriorMajorGlyph_GlyphOfSunderArmor)
-	numTargets := warrior.Env.GetNumTargets()
 	config := core.SpellConfig{
 		ActionID:       core.ActionID{SpellID: 7386},
 		SpellSchool:    core.SpellSchoolPhysical,
@@ -44,9 +43,8 @@ func (warrior *Warrior) RegisterSunderArmor() *core.Spell {
 		if result.Landed() {
 			warrior.TryApplySunderArmorEffect(sim, target)
 			// https://www.wowhead.com/cata/item=43427/glyph-of-sunder-armor - also applies to devastate in cata
-			if hasGlyph && numTargets > 1 {
-				nextTarget := warrior.Env.NextTargetUnit(target)
-				warrior.TryApplySunderArmorEffect(sim, nextTarget)
+			if cleaveTargets := sim.Environment.CleaveTargetUnits(target, warrior.Position, core.MaxMeleeRange, 2); hasGlyph && len(cleaveTargets) > 1 {
+				warrior.TryApplySunderArmorEffect(sim, cleaveTargets[1])
 			}
 		} else {
 			spell.IssueRefund(sim)
@@ -65,8 +63,7 @@ func (warrior *Warrior) CanApplySunderAura(target *core.Unit) bool {
 func (warrior *Warrior) TryApplySunderArmorEffect(sim *core.Simulation, target *core.Unit) {
 	if warrior.CanApplySunderAura(target) {
 		aura := warrior.SunderArmorAuras.Get(target)
-		aura.Activate(sim)
-		if aura.IsActive() {
+		if aura.Activate(sim) {
 			aura.AddStack(sim)
 		}
 	}
@@ -40,10 +40,11 @@ func (warrior *Warrior) RegisterThunderClapSpell() {
 		CritMultiplier:   warrior.DefaultMeleeCritMultiplier(),
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			targets := warrior.TargetUnitsAround(8)
 			baseDamage := 303.0 + 0.228*spell.MeleeAttackPower()
-			baseDamage *= sim.Encounter.AOECapMultiplier()
+			baseDamage *= core.AOECapMultiplierForTargets(len(targets))
 
-			for _, aoeTarget := range sim.Encounter.TargetUnits {
+			for _, aoeTarget := range targets {
 				result := spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeRangedHitAndCrit)
 				if result.Landed() {
 					warrior.ThunderClapAuras.Get(aoeTarget).Activate(sim)
@@ -31,6 +31,7 @@ func main() {
 	js.Global().Set("statWeights", js.FuncOf(statWeights))
 	js.Global().Set("statWeightsAsync", js.FuncOf(statWeightsAsync))
 	js.Global().Set("bulkSimAsync", js.FuncOf(bulkSimAsync))
+	js.Global().Set("statScalingAsync", js.FuncOf(statScalingAsync))
 	js.Global().Call("wasmready")
 	<-c
 }
@@ -217,6 +218,19 @@ func bulkSimAsync(this js.Value, args []js.Value) interface{} {
 	return result
 }
 
+func statScalingAsync(this js.Value, args []js.Value) interface{} {
+	ssr := &proto.StatScalingRequest{}
+	if err := googleProto.Unmarshal(getArgsBinary(args[0]), ssr); err != nil {
+		log.Printf("Failed to parse request: %s", err)
+		return nil
+	}
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.StatScalingAsync(context.Background(), ssr, reporter)
+
+	result := processAsyncProgress(args[1], reporter)
+	return result
+}
+
 // Assumes args[0] is a Uint8Array
 func getArgsBinary(value js.Value) []byte {
 	data := make([]byte, value.Get("length").Int())
@@ -248,7 +262,7 @@ reader:
 			js.CopyBytesToJS(outArray, outbytes)
 			progFunc.Invoke(outArray)
 
-			if progMetric.FinalWeightResult != nil || progMetric.FinalRaidResult != nil || progMetric.FinalBulkResult != nil {
+			if progMetric.FinalWeightResult != nil || progMetric.FinalRaidResult != nil || progMetric.FinalBulkResult != nil || progMetric.FinalScalingResult != nil {
 				return outArray
 			}
 		}
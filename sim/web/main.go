@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"github.com/wowsims/cata/sim/core"
 	proto "github.com/wowsims/cata/sim/core/proto"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	googleProto "google.golang.org/protobuf/proto"
 )
 
@@ -100,19 +102,23 @@ var handlers = map[string]apiHandler{
 	"/computeStats": {msg: func() googleProto.Message { return &proto.ComputeStatsRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
 		return core.ComputeStats(msg.(*proto.ComputeStatsRequest))
 	}},
+	"/statScaling": {msg: func() googleProto.Message { return &proto.StatScalingRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
+		return core.StatScaling(msg.(*proto.StatScalingRequest))
+	}},
 }
 
 var asyncAPIHandlers = map[string]asyncAPIHandler{
-	"/raidSimAsync": {msg: func() googleProto.Message { return &proto.RaidSimRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
-		core.RunRaidSimAsync(msg.(*proto.RaidSimRequest), reporter)
+	"/raidSimAsync": {msg: func() googleProto.Message { return &proto.RaidSimRequest{} }, handle: func(ctx context.Context, msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
+		core.RunRaidSimAsyncCtx(ctx, msg.(*proto.RaidSimRequest), reporter)
+	}},
+	"/statWeightsAsync": {msg: func() googleProto.Message { return &proto.StatWeightsRequest{} }, handle: func(ctx context.Context, msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
+		core.StatWeightsAsyncCtx(ctx, msg.(*proto.StatWeightsRequest), reporter)
 	}},
-	"/statWeightsAsync": {msg: func() googleProto.Message { return &proto.StatWeightsRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
-		core.StatWeightsAsync(msg.(*proto.StatWeightsRequest), reporter)
+	"/bulkSimAsync": {msg: func() googleProto.Message { return &proto.BulkSimRequest{} }, handle: func(ctx context.Context, msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
+		core.RunBulkSimAsync(ctx, msg.(*proto.BulkSimRequest), reporter)
 	}},
-	"/bulkSimAsync": {msg: func() googleProto.Message { return &proto.BulkSimRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
-		// TODO: we can use context's to cancel stuff.
-		// We should have all the async APIs take in context and let it be cancelled via its async ID.
-		core.RunBulkSimAsync(context.Background(), msg.(*proto.BulkSimRequest), reporter)
+	"/statScalingAsync": {msg: func() googleProto.Message { return &proto.StatScalingRequest{} }, handle: func(ctx context.Context, msg googleProto.Message, reporter chan *proto.ProgressMetrics) {
+		core.StatScalingAsync(ctx, msg.(*proto.StatScalingRequest), reporter)
 	}},
 }
 
@@ -127,18 +133,55 @@ type apiHandler struct {
 }
 type asyncAPIHandler struct {
 	msg    func() googleProto.Message
-	handle func(googleProto.Message, chan *proto.ProgressMetrics)
+	handle func(context.Context, googleProto.Message, chan *proto.ProgressMetrics)
 }
 
+// How long a finished sim is kept around so that late readers still get the final result.
+// Polling clients may ask again after a dropped response, so only a progress stream
+// delivering the result removes it sooner.
+const finishedSimRetention = time.Minute
+
+// How often a comment is sent on idle progress streams to keep proxies from closing them.
+const streamKeepAliveInterval = time.Second * 15
+
 type asyncProgress struct {
 	id             string
 	latestProgress atomic.Value
+	cancel         context.CancelFunc
+
+	// updated is closed and replaced every time latestProgress changes, waking up all streams.
+	updateMut sync.Mutex
+	updated   chan struct{}
+
+	// Removes the sim once finishedSimRetention has passed after its final result.
+	expiry *time.Timer
+}
+
+func (p *asyncProgress) setProgress(progMetric *proto.ProgressMetrics) {
+	p.updateMut.Lock()
+	p.latestProgress.Store(progMetric)
+	close(p.updated)
+	p.updated = make(chan struct{})
+	p.updateMut.Unlock()
+}
+
+// waitForProgress returns the latest progress, and a channel that is closed on the next update.
+func (p *asyncProgress) waitForProgress() (*proto.ProgressMetrics, <-chan struct{}) {
+	p.updateMut.Lock()
+	defer p.updateMut.Unlock()
+	return p.latestProgress.Load().(*proto.ProgressMetrics), p.updated
 }
 
-func (s *server) addNewSim() *asyncProgress {
+func isFinalProgress(progMetric *proto.ProgressMetrics) bool {
+	return progMetric.FinalRaidResult != nil || progMetric.FinalWeightResult != nil || progMetric.FinalBulkResult != nil || progMetric.FinalScalingResult != nil
+}
+
+func (s *server) addNewSim(cancel context.CancelFunc) *asyncProgress {
 	newID := uuid.NewString()
 	simProgress := &asyncProgress{
-		id: newID,
+		id:      newID,
+		cancel:  cancel,
+		updated: make(chan struct{}),
 	}
 	simProgress.latestProgress.Store(&proto.ProgressMetrics{})
 
@@ -149,6 +192,39 @@ func (s *server) addNewSim() *asyncProgress {
 	return simProgress
 }
 
+func (s *server) getSim(id string) (*asyncProgress, bool) {
+	s.progMut.RLock()
+	defer s.progMut.RUnlock()
+	progress, ok := s.asyncProgresses[id]
+	return progress, ok
+}
+
+// finishSim stores the final result of a sim, which is kept until it is fetched
+// from a progress stream, the sim is cancelled, or the result expires.
+func (s *server) finishSim(progress *asyncProgress, final *proto.ProgressMetrics) {
+	// Set up the expiry first, so that a stream removing the sim as soon as it sees the result also stops it.
+	progress.updateMut.Lock()
+	progress.expiry = time.AfterFunc(finishedSimRetention, func() { s.removeSim(progress) })
+	progress.updateMut.Unlock()
+
+	progress.setProgress(final)
+}
+
+// removeSim is the only place sims are removed, whichever way they ended.
+func (s *server) removeSim(progress *asyncProgress) {
+	s.progMut.Lock()
+	delete(s.asyncProgresses, progress.id)
+	s.progMut.Unlock()
+
+	progress.updateMut.Lock()
+	if progress.expiry != nil {
+		progress.expiry.Stop()
+	}
+	progress.updateMut.Unlock()
+
+	progress.cancel()
+}
+
 func (s *server) handleAsyncAPI(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -173,32 +249,26 @@ func (s *server) handleAsyncAPI(w http.ResponseWriter, r *http.Request) {
 	//  as the simulation advances it will push changes to the channel
 	//  these changes will be consumed by the goroutine below so the asyncProgress endpoint can fetch the results.
 	reporter := make(chan *proto.ProgressMetrics, 100)
-	handler.handle(msg, reporter)
+	ctx, cancel := context.WithCancel(context.Background())
+	handler.handle(ctx, msg, reporter)
 
 	// Generate a new async simulation
-	simProgress := s.addNewSim()
+	simProgress := s.addNewSim(cancel)
 
 	// Now launch a background process that pulls progress reports off the reporter channel
-	// and pushes it into the async progress cache.
+	// and pushes it into the async progress cache. Sims always end with a final result,
+	// even when cancelled, so there is no timeout while they run.
 	go func() {
-		for {
-			select {
-			case <-time.After(time.Minute * 10):
-				// if we get no progress after 10 minutes, delete the pending sim and exit.
-				s.progMut.Lock()
-				delete(s.asyncProgresses, simProgress.id)
-				s.progMut.Unlock()
+		defer cancel()
+		for progMetric := range reporter {
+			if isFinalProgress(progMetric) {
+				s.finishSim(simProgress, progMetric)
 				return
-			case progMetric := <-reporter:
-				if progMetric == nil {
-					return
-				}
-				simProgress.latestProgress.Store(progMetric)
-				if progMetric.FinalRaidResult != nil || progMetric.FinalWeightResult != nil || progMetric.FinalBulkResult != nil {
-					return
-				}
 			}
+			simProgress.setProgress(progMetric)
 		}
+		// The reporter closed without a final result, so nothing else would ever remove the sim.
+		s.removeSim(simProgress)
 	}()
 
 	protoResult := &proto.AsyncAPIResult{
@@ -236,13 +306,12 @@ func (s *server) setupAsyncServer() {
 		}
 
 		// Read lock the map of all progress statuses, fetching current one.
-		s.progMut.RLock()
-		progress, ok := s.asyncProgresses[msg.ProgressId]
-		s.progMut.RUnlock()
+		progress, ok := s.getSim(msg.ProgressId)
 		if !ok {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
+		// A final result is left for finishSim's expiry, in case this response is dropped.
 		latest := progress.latestProgress.Load().(*proto.ProgressMetrics)
 		outbytes, err := googleProto.Marshal(latest)
 		if err != nil {
@@ -250,16 +319,117 @@ func (s *server) setupAsyncServer() {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-
-		// If this was the last result, delete the cache for this simulation.
-		if latest.FinalRaidResult != nil || latest.FinalWeightResult != nil || latest.FinalBulkResult != nil {
-			s.progMut.Lock()
-			delete(s.asyncProgresses, msg.ProgressId)
-			s.progMut.Unlock()
-		}
 		w.Header().Add("Content-Type", "application/x-protobuf")
 		w.Write(outbytes)
 	})))
+
+	// asyncProgressStream pushes every progress update of a simulation as server-sent events.
+	http.Handle("/asyncProgressStream", corsMiddleware(http.HandlerFunc(s.handleProgressStream)))
+
+	// asyncCancel stops a simulation by its UUID.
+	http.Handle("/asyncCancel", corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		msg := &proto.AsyncAPIResult{}
+		if err := googleProto.Unmarshal(body, msg); err != nil {
+			log.Printf("Failed to parse request: %s", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		progress, ok := s.getSim(msg.ProgressId)
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		// A running sim reports its final (cancelled) result, which is kept like any other.
+		// A finished sim has nothing left to stop, so its result is dropped.
+		if isFinalProgress(progress.latestProgress.Load().(*proto.ProgressMetrics)) {
+			s.removeSim(progress)
+		} else {
+			progress.cancel()
+		}
+		w.WriteHeader(http.StatusOK)
+	})))
+}
+
+// handleProgressStream streams the progress of the simulation given by the 'id' query parameter.
+// Each update is sent as a 'progress' event, and the final result as a 'final' event after which the stream ends.
+// The event data is the ProgressMetrics in protojson format, or base64 encoded binary proto when 'format=binary' is set.
+// Updates arriving faster than the client reads them are coalesced, but the final result is always sent.
+// Once it has been sent, the sim is removed.
+func (s *server) handleProgressStream(w http.ResponseWriter, r *http.Request) {
+	progress, ok := s.getSim(r.URL.Query().Get("id"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	binary := r.URL.Query().Get("format") == "binary"
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var lastSent *proto.ProgressMetrics
+	for {
+		latest, updated := progress.waitForProgress()
+		if latest != lastSent {
+			lastSent = latest
+			data, err := encodeProgressEvent(latest, binary)
+			if err != nil {
+				log.Printf("[ERROR] Failed to marshal result: %s", err.Error())
+				return
+			}
+			event := "progress"
+			if isFinalProgress(latest) {
+				event = "final"
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+				return
+			}
+			flusher.Flush()
+			if event == "final" {
+				s.removeSim(progress)
+				return
+			}
+		}
+
+		select {
+		case <-updated:
+		case <-r.Context().Done():
+			return
+		case <-time.After(streamKeepAliveInterval):
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func encodeProgressEvent(progMetric *proto.ProgressMetrics, binary bool) (string, error) {
+	if binary {
+		outbytes, err := googleProto.Marshal(progMetric)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(outbytes), nil
+	}
+	// protojson output never contains newlines unless multiline is requested, so it fits in a single data line.
+	outbytes, err := protojson.Marshal(progMetric)
+	if err != nil {
+		return "", err
+	}
+	return string(outbytes), nil
 }
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	_ "github.com/wowsims/cata/sim/common"
 	"github.com/wowsims/cata/sim/core"
 	"github.com/wowsims/cata/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
 	googleProto "google.golang.org/protobuf/proto"
 )
 
@@ -112,3 +114,192 @@ func TestIndividualSim(t *testing.T) {
 
 	log.Printf("RESULT: %#v", rsr)
 }
+
+func newAsyncRaidSimRequest() *proto.RaidSimRequest {
+	return &proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(
+			&proto.Player{
+				Race:      proto.Race_RaceTroll,
+				Class:     proto.Class_ClassShaman,
+				Equipment: &proto.EquipmentSpec{},
+				Spec:      basicSpec,
+			},
+			&proto.PartyBuffs{},
+			&proto.RaidBuffs{},
+			&proto.Debuffs{}),
+		Encounter: &proto.Encounter{
+			Duration: 120,
+			Targets: []*proto.Target{
+				{},
+			},
+		},
+		SimOptions: &proto.SimOptions{
+			Iterations: 500,
+			RandomSeed: 1,
+		},
+	}
+}
+
+// Starts an async raid sim and returns its progress ID.
+func startAsyncRaidSim(t *testing.T, req *proto.RaidSimRequest) string {
+	msgBytes, err := googleProto.Marshal(req)
+	if err != nil {
+		t.Fatalf("Failed to encode request: %s", err.Error())
+	}
+
+	r, err := http.Post("http://localhost:3339/raidSimAsync", "application/x-protobuf", bytes.NewReader(msgBytes))
+	if err != nil {
+		t.Fatalf("Failed to POST request: %s", err.Error())
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("Failed to read result body: %s", err.Error())
+	}
+	asyncResult := &proto.AsyncAPIResult{}
+	if err := googleProto.Unmarshal(body, asyncResult); err != nil {
+		t.Fatalf("Failed to parse request: %s", err.Error())
+	}
+	return asyncResult.ProgressId
+}
+
+// Polls the progress of an async sim, returning nil when the sim is unknown.
+func pollAsyncProgress(t *testing.T, id string) *proto.ProgressMetrics {
+	msgBytes, err := googleProto.Marshal(&proto.AsyncAPIResult{ProgressId: id})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %s", err.Error())
+	}
+
+	r, err := http.Post("http://localhost:3339/asyncProgress", "application/x-protobuf", bytes.NewReader(msgBytes))
+	if err != nil {
+		t.Fatalf("Failed to POST request: %s", err.Error())
+	}
+	defer r.Body.Close()
+	if r.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("Failed to read result body: %s", err.Error())
+	}
+	progress := &proto.ProgressMetrics{}
+	if err := googleProto.Unmarshal(body, progress); err != nil {
+		t.Fatalf("Failed to parse progress: %s", err.Error())
+	}
+	return progress
+}
+
+// Reads the progress stream of an async sim until its final event.
+func readAsyncProgressStream(t *testing.T, id string) *proto.ProgressMetrics {
+	stream, err := http.Get("http://localhost:3339/asyncProgressStream?id=" + id)
+	if err != nil {
+		t.Fatalf("Failed to GET progress stream: %s", err.Error())
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK {
+		t.Fatalf("Progress stream returned status %d", stream.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(stream.Body)
+	scanner.Buffer(nil, 1<<24)
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			event = name
+		} else if data, ok := strings.CutPrefix(line, "data: "); ok && event == "final" {
+			final := &proto.ProgressMetrics{}
+			if err := protojson.Unmarshal([]byte(data), final); err != nil {
+				t.Fatalf("Failed to parse final event: %s", err.Error())
+			}
+			if final.FinalRaidResult == nil || final.FinalRaidResult.ErrorResult != "" {
+				t.Fatalf("Final event has no raid result: %v", final)
+			}
+			return final
+		}
+	}
+	t.Fatalf("Progress stream ended without a final event: %v", scanner.Err())
+	return nil
+}
+
+func TestAsyncProgressStream(t *testing.T) {
+	readAsyncProgressStream(t, startAsyncRaidSim(t, newAsyncRaidSimRequest()))
+}
+
+func TestAsyncProgressKeepsFinalResult(t *testing.T) {
+	id := startAsyncRaidSim(t, newAsyncRaidSimRequest())
+
+	var progress *proto.ProgressMetrics
+	for deadline := time.Now().Add(time.Minute); progress == nil || progress.FinalRaidResult == nil; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Sim didn't finish in time")
+		}
+		progress = pollAsyncProgress(t, id)
+		if progress == nil {
+			t.Fatalf("Sim was removed before finishing")
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	// Polling again, e.g. after a dropped response, or switching to the stream still gets the result.
+	if progress := pollAsyncProgress(t, id); progress == nil || progress.FinalRaidResult == nil {
+		t.Fatalf("Final result was removed after being polled once: %v", progress)
+	}
+	readAsyncProgressStream(t, id)
+
+	// Once the stream has delivered the result, the sim is gone.
+	if progress := pollAsyncProgress(t, id); progress != nil {
+		t.Fatalf("Expected the sim to be removed after its result was streamed, got %v", progress)
+	}
+}
+
+// Cancels an async sim and returns the response status.
+func cancelAsyncSim(t *testing.T, id string) int {
+	msgBytes, err := googleProto.Marshal(&proto.AsyncAPIResult{ProgressId: id})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %s", err.Error())
+	}
+
+	r, err := http.Post("http://localhost:3339/asyncCancel", "application/x-protobuf", bytes.NewReader(msgBytes))
+	if err != nil {
+		t.Fatalf("Failed to POST request: %s", err.Error())
+	}
+	r.Body.Close()
+	return r.StatusCode
+}
+
+func TestAsyncCancel(t *testing.T) {
+	// Enough iterations that the sim is still running when it is cancelled.
+	req := newAsyncRaidSimRequest()
+	req.SimOptions.Iterations = 1_000_000
+	id := startAsyncRaidSim(t, req)
+	if status := cancelAsyncSim(t, id); status != http.StatusOK {
+		t.Fatalf("Expected cancelling a running sim to return %d, got %d", http.StatusOK, status)
+	}
+
+	// The cancelled sim still reports its final result.
+	var progress *proto.ProgressMetrics
+	for deadline := time.Now().Add(time.Minute); progress == nil || progress.FinalRaidResult == nil; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Cancelled sim didn't finish in time")
+		}
+		progress = pollAsyncProgress(t, id)
+		if progress == nil {
+			t.Fatalf("Sim was removed before reporting its cancelled result")
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	if !progress.FinalRaidResult.Cancelled {
+		t.Fatalf("Expected the final result to be cancelled, got %v", progress.FinalRaidResult)
+	}
+
+	// Cancelling a finished sim drops its result, after which the sim is unknown.
+	if status := cancelAsyncSim(t, id); status != http.StatusOK {
+		t.Fatalf("Expected cancelling a finished sim to return %d, got %d", http.StatusOK, status)
+	}
+	if progress := pollAsyncProgress(t, id); progress != nil {
+		t.Fatalf("Expected the sim to be removed after cancelling it once finished, got %v", progress)
+	}
+	if status := cancelAsyncSim(t, id); status != http.StatusNoContent {
+		t.Fatalf("Expected cancelling an unknown sim to return %d, got %d", http.StatusNoContent, status)
+	}
+}
@@ -391,7 +391,7 @@ func getSpellIdsFromTalentJson(infile *string) []int32 {
 }
 
 func GetAllTalentSpellIds(inputsDir *string) map[string][]int32 {
-	talentsDir := fmt.Sprintf("%s/../../ui/core/talents/trees", *inputsDir)
+	talentsDir := fmt.Sprintf("%s/../talents", *inputsDir)
 	specFiles := []string{
 		"death_knight.json",
 		"druid.json",
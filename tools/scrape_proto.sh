@@ -7,5 +7,5 @@ for class in "${classes[@]}"; do
     # Replace "-" with "" for filenames
     #python3 ./scrape_talents_proto.py $class ../proto/${class}.proto
     python3 ./scrape_glyphs.py $class ../proto/tmp/${class}.proto.test
-    #python3 ./scrape_talents_config.py $class ../ui/core/talents/trees/${class}.json
+    #python3 ./scrape_talents_config.py $class ../assets/talents/${class}.json
 done
\ No newline at end of file
@@ -73,6 +73,7 @@ export class EncounterPicker extends Component {
 					}
 				},
 			});
+			makeEncounterInputsPicker(this.rootElem, modEncounter);
 
 			//new EnumPicker<Encounter>(this.rootElem, modEncounter, {
 			//	label: 'Target Level',
@@ -545,7 +546,7 @@ class TargetPicker extends Input<Encounter, TargetProto> {
 
 class TargetInputPicker extends Input<Encounter, TargetInput> {
 	private readonly encounter: Encounter;
-	private readonly targetIndex: number;
+	private readonly getInputs: () => Array<TargetInput>;
 	private readonly targetInputIndex: number;
 
 	private boolPicker: Input<null, boolean> | null;
@@ -553,19 +554,19 @@ class TargetInputPicker extends Input<Encounter, TargetInput> {
 	private enumPicker: EnumPicker<null> | null;
 
 	private getTargetInput(): TargetInput {
-		return this.encounter.targets[this.targetIndex].targetInputs[this.targetInputIndex] || TargetInput.create();
+		return this.getInputs()[this.targetInputIndex] || TargetInput.create();
 	}
 
 	constructor(
 		parent: HTMLElement,
 		encounter: Encounter,
-		targetIndex: number,
+		getInputs: () => Array<TargetInput>,
 		targetInputIndex: number,
 		config: ListItemPickerConfig<Encounter, TargetInput>,
 	) {
 		super(parent, 'target-input-picker-root', encounter, config);
 		this.encounter = encounter;
-		this.targetIndex = targetIndex;
+		this.getInputs = getInputs;
 		this.targetInputIndex = targetInputIndex;
 
 		this.boolPicker = null;
@@ -757,15 +758,39 @@ function addEncounterFieldPickers(rootElem: HTMLElement, encounter: Encounter, s
 }
 
 function makeTargetInputsPicker(parent: HTMLElement, encounter: Encounter, targetIndex: number) {
+	return makeInputsPicker(
+		parent,
+		encounter,
+		() => encounter.targets[targetIndex].targetInputs,
+		newValue => (encounter.targets[targetIndex].targetInputs = newValue),
+	);
+}
+
+// Inputs shared by all targets of a preset encounter, e.g. raid size and difficulty.
+function makeEncounterInputsPicker(parent: HTMLElement, encounter: Encounter) {
+	return makeInputsPicker(
+		parent,
+		encounter,
+		() => encounter.inputs,
+		newValue => (encounter.inputs = newValue),
+	);
+}
+
+function makeInputsPicker(
+	parent: HTMLElement,
+	encounter: Encounter,
+	getInputs: () => Array<TargetInput>,
+	setInputs: (newValue: Array<TargetInput>) => void,
+) {
 	return new ListPicker<Encounter, TargetInput>(parent, encounter, {
 		allowedActions: [],
 		itemLabel: 'Target Input',
 		extraCssClasses: ['mt-2'],
 		isCompact: true,
 		changedEvent: (encounter: Encounter) => encounter.targetsChangeEmitter,
-		getValue: (encounter: Encounter) => encounter.targets[targetIndex].targetInputs,
+		getValue: (_encounter: Encounter) => getInputs(),
 		setValue: (eventID: EventID, encounter: Encounter, newValue: Array<TargetInput>) => {
-			encounter.targets[targetIndex].targetInputs = newValue;
+			setInputs(newValue);
 			encounter.targetsChangeEmitter.emit(eventID);
 		},
 		newItem: () => TargetInput.create(),
@@ -775,7 +800,7 @@ function makeTargetInputsPicker(parent: HTMLElement, encounter: Encounter, targe
 			listPicker: ListPicker<Encounter, TargetInput>,
 			index: number,
 			config: ListItemPickerConfig<Encounter, TargetInput>,
-		) => new TargetInputPicker(parent, encounter, targetIndex, index, config),
+		) => new TargetInputPicker(parent, encounter, getInputs, index, config),
 	});
 }
 
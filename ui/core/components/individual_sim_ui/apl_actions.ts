@@ -4,6 +4,7 @@ import {
 	APLAction,
 	APLActionActivateAura,
 	APLActionAutocastOtherCooldowns,
+	APLActionCallList,
 	APLActionCancelAura,
 	APLActionCastFriendlySpell,
 	APLActionCastSpell,
@@ -18,8 +19,10 @@ import {
 	APLActionMultidot,
 	APLActionMultishield,
 	APLActionResetSequence,
+	APLActionRunList,
 	APLActionSchedule,
 	APLActionSequence,
+	APLActionSetVariable,
 	APLActionStrictSequence,
 	APLActionTriggerICD,
 	APLActionWait,
@@ -533,6 +536,38 @@ const actionKindFactories: { [f in NonNullable<APLActionKind>]: ActionKindConfig
 		newValue: APLActionStrictSequence.create,
 		fields: [actionListFieldConfig('actions')],
 	}),
+	['callList']: inputBuilder({
+		label: 'Call List',
+		submenu: ['Action Lists'],
+		shortDescription: 'Performs the first ready action of a named action list, or continues with the next action if none is ready.',
+		fullDescription: `
+			<p>Use the <b>name</b> field to refer to the action list to be called, which is defined under <b>Action Lists</b>. Action lists cannot call themselves, directly or through other lists.</p>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: APLActionCallList.create,
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+	['runList']: inputBuilder({
+		label: 'Run List',
+		submenu: ['Action Lists'],
+		shortDescription: 'Performs the first ready action of a named action list, and never continues with the actions after this one.',
+		fullDescription: `
+			<p>Use the <b>name</b> field to refer to the action list to be run, which is defined under <b>Action Lists</b>. Action lists cannot call themselves, directly or through other lists.</p>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: APLActionRunList.create,
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+	['setVariable']: inputBuilder({
+		label: 'Set Variable',
+		submenu: ['Misc'],
+		shortDescription: 'Stores a value in a named variable, which can be read with the <b>Variable</b> value.',
+		fullDescription: `
+			<p>This action doesn't take any time, and the list continues with the next action after it. Variables keep their value until they are set again, and are reset at the start of each iteration.</p>
+		`,
+		newValue: APLActionSetVariable.create,
+		fields: [AplHelpers.stringFieldConfig('name'), AplValues.valueFieldConfig('value')],
+	}),
 	['changeTarget']: inputBuilder({
 		label: 'Change Target',
 		submenu: ['Misc'],
@@ -1,7 +1,7 @@
 import tippy, { Instance as TippyInstance } from 'tippy.js';
 
 import { Player } from '../../player';
-import { APLAction, APLListItem, APLPrepullAction, APLValue } from '../../proto/apl';
+import { APLAction, APLActionList, APLListItem, APLPrepullAction, APLValue } from '../../proto/apl';
 import { ActionId } from '../../proto_utils/action_id';
 import { SimUI } from '../../sim_ui';
 import { EventID, TypedEvent } from '../../typed_event';
@@ -66,7 +66,30 @@ export class APLRotationPicker extends Component {
 				listPicker: ListPicker<Player<any>, APLListItem>,
 				index: number,
 				config: ListItemPickerConfig<Player<any>, APLListItem>,
-			) => new APLListItemPicker(parent, modPlayer, config, index),
+			) =>
+				new APLListItemPicker(parent, modPlayer, config, player => player.getCurrentStats().rotationStats?.priorityList[index]?.warnings || []),
+			inlineMenuBar: true,
+		});
+
+		new ListPicker<Player<any>, APLActionList>(this.rootElem, modPlayer, {
+			extraCssClasses: ['apl-action-list-picker'],
+			title: 'Action Lists',
+			titleTooltip: 'Named lists of actions, which can be performed from the priority list or from each other with the Call List and Run List actions.',
+			itemLabel: 'Action List',
+			changedEvent: (player: Player<any>) => player.rotationChangeEmitter,
+			getValue: (player: Player<any>) => player.aplRotation.actionLists,
+			setValue: (eventID: EventID, player: Player<any>, newValue: Array<APLActionList>) => {
+				player.aplRotation.actionLists = newValue;
+				player.rotationChangeEmitter.emit(eventID);
+			},
+			newItem: () => APLActionList.create(),
+			copyItem: (oldItem: APLActionList) => APLActionList.clone(oldItem),
+			newItemPicker: (
+				parent: HTMLElement,
+				listPicker: ListPicker<Player<any>, APLActionList>,
+				index: number,
+				config: ListItemPickerConfig<Player<any>, APLActionList>,
+			) => new APLActionListPicker(parent, modPlayer, config, index),
 			inlineMenuBar: true,
 		});
 
@@ -190,13 +213,18 @@ class APLListItemPicker extends Input<Player<any>, APLListItem> {
 		);
 	}
 
-	constructor(parent: HTMLElement, player: Player<any>, config: ListItemPickerConfig<Player<any>, APLListItem>, index: number) {
+	constructor(
+		parent: HTMLElement,
+		player: Player<any>,
+		config: ListItemPickerConfig<Player<any>, APLListItem>,
+		getWarnings: (player: Player<any>) => Array<string>,
+	) {
 		config.enableWhen = () => !this.getItem().hide;
 		super(parent, 'apl-list-item-picker-root', player, config);
 		this.player = player;
 
 		const itemHeaderElem = ListPicker.getItemHeaderElem(this);
-		makeListItemWarnings(itemHeaderElem, player, player => player.getCurrentStats().rotationStats?.priorityList[index]?.warnings || []);
+		makeListItemWarnings(itemHeaderElem, player, getWarnings);
 
 		this.hidePicker = new HidePicker(itemHeaderElem, player, {
 			changedEvent: () => this.player.rotationChangeEmitter,
@@ -239,6 +267,85 @@ class APLListItemPicker extends Input<Player<any>, APLListItem> {
 	}
 }
 
+class APLActionListPicker extends Input<Player<any>, APLActionList> {
+	private readonly player: Player<any>;
+
+	private readonly namePicker: Input<Player<any>, string>;
+	private readonly itemsPicker: ListPicker<Player<any>, APLListItem>;
+
+	private getItem(): APLActionList {
+		return this.getSourceValue() || APLActionList.create();
+	}
+
+	constructor(parent: HTMLElement, player: Player<any>, config: ListItemPickerConfig<Player<any>, APLActionList>, index: number) {
+		super(parent, 'apl-action-list-picker-root', player, config);
+		this.player = player;
+
+		this.namePicker = new AdaptiveStringPicker(this.rootElem, this.player, {
+			id: randomUUID(),
+			label: 'Name',
+			labelTooltip: 'The name which Call List and Run List actions use to refer to this list.',
+			extraCssClasses: ['apl-action-list-name'],
+			changedEvent: () => this.player.rotationChangeEmitter,
+			getValue: () => this.getItem().name,
+			setValue: (eventID: EventID, player: Player<any>, newValue: string) => {
+				this.getItem().name = newValue;
+				this.player.rotationChangeEmitter.emit(eventID);
+			},
+			inline: true,
+		});
+
+		this.itemsPicker = new ListPicker<Player<any>, APLListItem>(this.rootElem, this.player, {
+			extraCssClasses: ['apl-list-item-picker'],
+			itemLabel: 'Action',
+			changedEvent: () => this.player.rotationChangeEmitter,
+			getValue: () => this.getItem().items,
+			setValue: (eventID: EventID, player: Player<any>, newValue: Array<APLListItem>) => {
+				this.getItem().items = newValue;
+				this.player.rotationChangeEmitter.emit(eventID);
+			},
+			newItem: () =>
+				APLListItem.create({
+					action: {},
+				}),
+			copyItem: (oldItem: APLListItem) => APLListItem.clone(oldItem),
+			newItemPicker: (
+				parent: HTMLElement,
+				listPicker: ListPicker<Player<any>, APLListItem>,
+				itemIndex: number,
+				config: ListItemPickerConfig<Player<any>, APLListItem>,
+			) =>
+				new APLListItemPicker(
+					parent,
+					this.player,
+					config,
+					player => player.getCurrentStats().rotationStats?.actionLists[index]?.items[itemIndex]?.warnings || [],
+				),
+			inlineMenuBar: true,
+		});
+		this.init();
+	}
+
+	getInputElem(): HTMLElement | null {
+		return this.rootElem;
+	}
+
+	getInputValue(): APLActionList {
+		return APLActionList.create({
+			name: this.namePicker.getInputValue(),
+			items: this.itemsPicker.getInputValue(),
+		});
+	}
+
+	setInputValue(newValue: APLActionList) {
+		if (!newValue) {
+			return;
+		}
+		this.namePicker.setInputValue(newValue.name);
+		this.itemsPicker.setInputValue(newValue.items);
+	}
+}
+
 function makeListItemWarnings(itemHeaderElem: HTMLElement, player: Player<any>, getWarnings: (player: Player<any>) => Array<string>) {
 	const warningsElem = ListPicker.makeActionElem('apl-warnings', 'fa-exclamation-triangle');
 	warningsElem.classList.add('warning', 'link-warning');
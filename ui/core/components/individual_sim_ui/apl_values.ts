@@ -72,8 +72,10 @@ import {
 	APLValueSpellIsReady,
 	APLValueSpellTimeToReady,
 	APLValueSpellTravelTime,
+	APLValueTargetHealthReached,
 	APLValueTotemRemainingTime,
 	APLValueUnitIsMoving,
+	APLValueVariable,
 	APLValueWarlockShouldRecastDrainSoul,
 	APLValueWarlockShouldRefreshCorruption,
 } from '../../proto/apl.js';
@@ -559,6 +561,23 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		newValue: APLValueNumberTargets.create,
 		fields: [],
 	}),
+	targetHealthReached: inputBuilder({
+		label: 'Target Health Reached',
+		submenu: ['Encounter'],
+		shortDescription:
+			"<b>True</b> if the target's health has dropped to the given percent of its maximum, otherwise <b>False</b>. The rotation is re-evaluated as soon as the threshold is crossed. Requires targets with health.",
+		newValue: () =>
+			APLValueTargetHealthReached.create({
+				healthPercent: 35,
+			}),
+		fields: [
+			AplHelpers.unitFieldConfig('targetUnit', 'targets'),
+			AplHelpers.numberFieldConfig('healthPercent', true, {
+				label: 'Health %',
+				labelTooltip: "Percent of the target's maximum health.",
+			}),
+		],
+	}),
 	frontOfTarget: inputBuilder({
 		label: 'Front of Target',
 		submenu: ['Encounter'],
@@ -990,6 +1009,18 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		fields: [AplHelpers.stringFieldConfig('sequenceName')],
 	}),
 
+	// Variable values
+	variable: inputBuilder({
+		label: 'Variable',
+		submenu: ['Variable'],
+		shortDescription: 'Returns the value most recently stored in a variable by a <b>Set Variable</b> action.',
+		fullDescription: `
+			<p>Variables are reset at the start of each iteration.</p>
+		`,
+		newValue: APLValueVariable.create,
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+
 	// Class/spec specific values
 	totemRemainingTime: inputBuilder({
 		label: 'Totem Remaining Time',
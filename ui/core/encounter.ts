@@ -18,6 +18,8 @@ export class Encounter {
 	private useHealth = false;
 	targets: Array<TargetProto>;
 	targetsMetadata: UnitMetadataList;
+	// Inputs of the preset encounter, e.g. raid size and difficulty.
+	inputs: Array<TargetInput> = [];
 
 	readonly targetsChangeEmitter = new TypedEvent<void>();
 	readonly durationChangeEmitter = new TypedEvent<void>();
@@ -113,6 +115,7 @@ export class Encounter {
 
 	applyPreset(eventID: EventID, preset: PresetEncounter) {
 		this.targets = preset.targets.map(presetTarget => presetTarget.target || TargetProto.create());
+		this.inputs = preset.inputs.map(input => TargetInput.clone(input));
 		this.targetsChangeEmitter.emit(eventID);
 	}
 
@@ -131,6 +134,7 @@ export class Encounter {
 			executeProportion90: this.executeProportion90,
 			useHealth: this.useHealth,
 			targets: this.targets,
+			inputs: this.inputs,
 		});
 	}
 
@@ -144,6 +148,7 @@ export class Encounter {
 			this.setExecuteProportion90(eventID, proto.executeProportion90);
 			this.setUseHealth(eventID, proto.useHealth);
 			this.targets = proto.targets;
+			this.inputs = proto.inputs;
 			this.targetsChangeEmitter.emit(eventID);
 		});
 	}
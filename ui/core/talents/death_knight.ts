@@ -1,7 +1,7 @@
 import {DeathKnightMajorGlyph, DeathKnightMinorGlyph, DeathKnightPrimeGlyph, DeathKnightTalents } from '../proto/death_knight';
 import { GlyphsConfig } from './glyphs_picker.jsx';
 import { newTalentsConfig, TalentsConfig } from './talents_picker.jsx';
-import DkTalentsJson from './trees/death_knight.json';
+import DkTalentsJson from '../../../assets/talents/death_knight.json';
 
 export const deathKnightTalentsConfig: TalentsConfig<DeathKnightTalents> = newTalentsConfig(DkTalentsJson);
 
@@ -1,7 +1,7 @@
 import {DruidMajorGlyph, DruidMinorGlyph,DruidPrimeGlyph, DruidTalents } from '../proto/druid.js';
 import { GlyphsConfig, } from './glyphs_picker.js';
 import { newTalentsConfig,TalentsConfig } from './talents_picker.js';
-import DruidTalentsJson from './trees/druid.json';
+import DruidTalentsJson from '../../../assets/talents/druid.json';
 
 export const druidTalentsConfig: TalentsConfig<DruidTalents> = newTalentsConfig(DruidTalentsJson);
 
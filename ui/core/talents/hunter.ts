@@ -1,7 +1,7 @@
 import { HunterMajorGlyph, HunterMinorGlyph, HunterPetTalents, HunterPrimeGlyph, HunterTalents } from '../proto/hunter.js';
 import { GlyphsConfig } from './glyphs_picker.js';
 import { newTalentsConfig,TalentsConfig } from './talents_picker.js';
-import HunterTalentJson from './trees/hunter.json';
+import HunterTalentJson from '../../../assets/talents/hunter.json';
 
 export const hunterTalentsConfig: TalentsConfig<HunterTalents> = newTalentsConfig(HunterTalentJson);
 
@@ -12,9 +12,9 @@ import { SimUI } from '../sim_ui.js';
 import { EventID, TypedEvent } from '../typed_event.js';
 import { protoToTalentString, talentStringToProto } from './factory.js';
 import { newTalentsConfig, TalentsConfig, TalentsPicker } from './talents_picker.jsx';
-import HunterPetCunningJson from './trees/hunter_cunning.json';
-import HunterPetFerocityJson from './trees/hunter_ferocity.json';
-import HunterPetTenacityJson from './trees/hunter_tenacity.json';
+import HunterPetCunningJson from '../../../assets/talents/hunter_cunning.json';
+import HunterPetFerocityJson from '../../../assets/talents/hunter_ferocity.json';
+import HunterPetTenacityJson from '../../../assets/talents/hunter_tenacity.json';
 
 export function makePetTypeInputConfig<SpecType extends HunterSpecs>(): InputHelpers.TypedIconEnumPickerConfig<Player<SpecType>, PetType> {
 	return InputHelpers.makeClassOptionsEnumIconInput<SpecType, PetType>({
@@ -1,7 +1,7 @@
 import {MageMajorGlyph, MageMinorGlyph,MagePrimeGlyph, MageTalents } from '../proto/mage.js';
 import { GlyphsConfig } from './glyphs_picker.js';
 import { newTalentsConfig,TalentsConfig } from './talents_picker.js';
-import MageTalentJson from './trees/mage.json';
+import MageTalentJson from '../../../assets/talents/mage.json';
 
 export const mageTalentsConfig: TalentsConfig<MageTalents> = newTalentsConfig(MageTalentJson);
 
@@ -1,7 +1,7 @@
 import { PriestMajorGlyph, PriestMinorGlyph,PriestPrimeGlyph, PriestTalents } from '../proto/priest.js';
 import { GlyphsConfig } from './glyphs_picker.js';
 import { newTalentsConfig,TalentsConfig } from './talents_picker.js';
-import PriestTalentJson from './trees/priest.json';
+import PriestTalentJson from '../../../assets/talents/priest.json';
 
 export const priestTalentsConfig: TalentsConfig<PriestTalents> = newTalentsConfig(PriestTalentJson);
 
@@ -1,7 +1,7 @@
 import { WarlockMajorGlyph, WarlockMinorGlyph,WarlockPrimeGlyph, WarlockTalents } from '../proto/warlock.js';
 import { GlyphsConfig } from './glyphs_picker.js';
 import { newTalentsConfig,TalentsConfig } from './talents_picker.js';
-import WarlockTalentJson from './trees/warlock.json';
+import WarlockTalentJson from '../../../assets/talents/warlock.json';
 
 export const warlockTalentsConfig: TalentsConfig<WarlockTalents> = newTalentsConfig(WarlockTalentJson);
 
@@ -1,7 +1,7 @@
 import { WarriorMajorGlyph, WarriorMinorGlyph,WarriorPrimeGlyph, WarriorTalents } from '../proto/warrior.js';
 import { GlyphsConfig } from './glyphs_picker.js';
 import { newTalentsConfig,TalentsConfig } from './talents_picker.js';
-import WarriorTalentJson from './trees/warrior.json';
+import WarriorTalentJson from '../../../assets/talents/warrior.json';
 
 export const warriorTalentsConfig: TalentsConfig<WarriorTalents> = newTalentsConfig(WarriorTalentJson);
 